@@ -0,0 +1,3 @@
+alter table sessions
+    drop column if exists user_agent,
+    drop column if exists device_name;
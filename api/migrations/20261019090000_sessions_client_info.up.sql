@@ -0,0 +1,3 @@
+alter table sessions
+    add column if not exists user_agent  varchar(512) not null default '',
+    add column if not exists device_name varchar(128) not null default '';
@@ -0,0 +1,10 @@
+create table if not exists user_devices
+(
+    device_id     bigserial primary key,
+    user_idref    bigint       not null references users (user_id) on delete cascade,
+    device_ip     varchar(64)  not null default '',
+    user_agent    varchar(512) not null default '',
+    first_seen_at bigint       not null,
+    last_seen_at  bigint       not null,
+    unique (user_idref, device_ip, user_agent)
+);
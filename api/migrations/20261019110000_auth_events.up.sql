@@ -0,0 +1,16 @@
+create table if not exists auth_events
+(
+    event_id      bigserial primary key,
+    user_idref    bigint       null references users (user_id) on delete set null,
+    actor         varchar(255) not null default '',
+    event_type    varchar(32)  not null,
+    result        varchar(16)  not null,
+    event_ip      varchar(64)  not null default '',
+    user_agent    varchar(512) not null default '',
+    session_idref bigint       null,
+    reason        text         not null default '',
+    created_at    timestamp    not null default now()
+);
+
+create index if not exists auth_events_user_idx on auth_events (user_idref, created_at desc);
+create index if not exists auth_events_created_at_idx on auth_events (created_at desc);
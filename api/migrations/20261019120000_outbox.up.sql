@@ -0,0 +1,14 @@
+create table if not exists outbox
+(
+    outbox_id       bigserial primary key,
+    message_id      uuid        not null unique,
+    topic           varchar(32) not null,
+    payload         jsonb       not null,
+    attempts        int         not null default 0,
+    last_error      text        not null default '',
+    created_at      timestamp   not null default now(),
+    next_attempt_at timestamp   not null default now(),
+    published_at    timestamp   null
+);
+
+create index if not exists outbox_pending_idx on outbox (next_attempt_at) where published_at is null;
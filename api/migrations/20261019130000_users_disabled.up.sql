@@ -0,0 +1,3 @@
+alter table users
+    add column if not exists disabled_at      timestamp null,
+    add column if not exists email_bounced_at timestamp null;
@@ -0,0 +1,12 @@
+drop index if exists sessions_tenant_id_idx;
+drop index if exists users_tenant_email_key;
+-- fails while the same email is registered in several tenants, such users must be removed first
+alter table users
+    add constraint users_email_key unique (email);
+
+alter table sessions
+    drop column if exists tenant_id;
+alter table users
+    drop column if exists tenant_id;
+
+drop table if exists tenants;
@@ -0,0 +1,45 @@
+create table if not exists tenants
+(
+    tenant_id                  bigserial primary key,
+    tenant_code                varchar(64)  not null unique,
+    name                       varchar(255) not null,
+    hosts                      text[]       not null default '{}',
+    access_secret              text         not null default '',
+    refresh_secret             text         not null default '',
+    google_client_id           text         not null default '',
+    google_client_secret       text         not null default '',
+    google_server_callback_uri text         not null default '',
+    google_client_callback_uri text         not null default '',
+    allow_sign_up              boolean      not null default true,
+    allow_google               boolean      not null default true,
+    created_at                 timestamp    not null default now()
+);
+
+insert into tenants (tenant_code, name)
+values ('default', 'Default')
+on conflict (tenant_code) do nothing;
+
+alter table users
+    add column if not exists tenant_id bigint references tenants (tenant_id);
+update users
+set tenant_id = (select tenant_id from tenants where tenant_code = 'default')
+where tenant_id is null;
+alter table users
+    alter column tenant_id set not null;
+
+alter table sessions
+    add column if not exists tenant_id bigint references tenants (tenant_id);
+update sessions s
+set tenant_id = u.tenant_id
+from users u
+where u.user_id = s.user_idref
+  and s.tenant_id is null;
+alter table sessions
+    alter column tenant_id set not null;
+
+-- the same email may be registered once in every tenant
+alter table users
+    drop constraint if exists users_email_key;
+create unique index if not exists users_tenant_email_key
+    on users (tenant_id, email) where deleted_at is null;
+create index if not exists sessions_tenant_id_idx on sessions (tenant_id);
@@ -0,0 +1,53 @@
+create table if not exists permissions
+(
+    permission_id bigserial primary key,
+    name          varchar(128) not null unique,
+    description   text         not null default '',
+    created_at    timestamp    not null default now()
+);
+
+create table if not exists roles
+(
+    role_id     bigserial primary key,
+    tenant_id   bigint       not null references tenants (tenant_id),
+    name        varchar(64)  not null,
+    description text         not null default '',
+    created_at  timestamp    not null default now(),
+    unique (tenant_id, name)
+);
+
+create table if not exists role_permissions
+(
+    role_idref       bigint not null references roles (role_id) on delete cascade,
+    permission_idref bigint not null references permissions (permission_id) on delete cascade,
+    primary key (role_idref, permission_idref)
+);
+
+create table if not exists user_roles
+(
+    user_idref  bigint    not null references users (user_id) on delete cascade,
+    role_idref  bigint    not null references roles (role_id) on delete cascade,
+    assigned_at timestamp not null default now(),
+    primary key (user_idref, role_idref)
+);
+create index if not exists user_roles_role_idref_idx on user_roles (role_idref);
+
+insert into permissions (name, description)
+values ('users:read', 'View users, their sessions and activity'),
+       ('users:manage', 'Disable, enable, log out and delete users'),
+       ('roles:manage', 'Manage roles, permissions and their assignments'),
+       ('audit:read', 'Search authentication audit log'),
+       ('mails:manage', 'Inspect and requeue dead-lettered mails')
+on conflict (name) do nothing;
+
+insert into roles (tenant_id, name, description)
+select tenant_id, 'admin', 'Full access to the admin api'
+from tenants
+on conflict (tenant_id, name) do nothing;
+
+insert into role_permissions (role_idref, permission_idref)
+select r.role_id, p.permission_id
+from roles r
+         cross join permissions p
+where r.name = 'admin'
+on conflict do nothing;
@@ -0,0 +1,5 @@
+drop index if exists users_erase_at_idx;
+
+alter table users
+    drop column if exists erase_at,
+    drop column if exists erased_at;
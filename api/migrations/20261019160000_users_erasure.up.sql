@@ -0,0 +1,5 @@
+alter table users
+    add column if not exists erase_at  timestamp null,
+    add column if not exists erased_at timestamp null;
+
+create index if not exists users_erase_at_idx on users (erase_at) where erase_at is not null;
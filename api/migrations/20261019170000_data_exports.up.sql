@@ -0,0 +1,17 @@
+create table if not exists data_exports
+(
+    export_id  uuid primary key,
+    user_idref bigint      not null references users (user_id) on delete cascade,
+    format     varchar(8)  not null,
+    status     varchar(16) not null default 'pending',
+    locale     varchar(35) not null default '',
+    content    bytea       null,
+    last_error text        not null default '',
+    created_at timestamp   not null default now(),
+    ready_at   timestamp   null,
+    expires_at timestamp   null
+);
+
+-- a user may have only one export being prepared
+create unique index if not exists data_exports_pending_idx on data_exports (user_idref) where status = 'pending';
+create index if not exists data_exports_expires_at_idx on data_exports (expires_at) where expires_at is not null;
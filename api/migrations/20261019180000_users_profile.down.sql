@@ -0,0 +1,4 @@
+alter table users
+    drop column if exists first_name,
+    drop column if exists last_name,
+    drop column if exists locale;
@@ -0,0 +1,4 @@
+alter table users
+    add column if not exists first_name varchar(100) not null default '',
+    add column if not exists last_name  varchar(100) not null default '',
+    add column if not exists locale     varchar(35)  not null default '';
@@ -0,0 +1,5 @@
+drop table if exists user_identities;
+
+alter table users
+    drop column if exists display_name,
+    drop column if exists picture_url;
@@ -0,0 +1,20 @@
+alter table users
+    add column if not exists display_name varchar(255) not null default '',
+    add column if not exists picture_url  text         not null default '';
+
+-- external accounts of the user, matched by the subject the provider gives, not by email
+create table if not exists user_identities
+(
+    identity_id    bigserial primary key,
+    user_idref     bigint       not null references users (user_id) on delete cascade,
+    tenant_id      bigint       not null references tenants (tenant_id),
+    provider       varchar(32)  not null,
+    subject        varchar(255) not null,
+    email          varchar(255) not null default '',
+    email_verified boolean      not null default false,
+    created_at     timestamp    not null default now(),
+    last_used_at   timestamp    not null default now(),
+    unique (tenant_id, provider, subject)
+);
+
+create index if not exists user_identities_user_idref_idx on user_identities (user_idref);
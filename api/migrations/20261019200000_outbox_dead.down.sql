@@ -0,0 +1,4 @@
+drop index if exists outbox_pending_idx;
+create index if not exists outbox_pending_idx on outbox (next_attempt_at) where published_at is null;
+
+alter table outbox drop column if exists dead_at;
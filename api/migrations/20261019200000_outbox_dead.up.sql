@@ -0,0 +1,8 @@
+alter table outbox add column if not exists dead_at timestamp null;
+
+update outbox set dead_at = now() where published_at is null and attempts >= 20;
+update outbox set payload = '{}'::jsonb
+where topic in ('mails', 'sms') and (published_at is not null or dead_at is not null);
+
+drop index if exists outbox_pending_idx;
+create index if not exists outbox_pending_idx on outbox (next_attempt_at) where published_at is null and dead_at is null;
@@ -0,0 +1,3 @@
+-- sealed secrets can not be decrypted here, they must be written to google_client_secret again
+alter table tenants
+    drop column if exists google_client_secret_sealed;
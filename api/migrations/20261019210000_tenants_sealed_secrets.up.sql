@@ -0,0 +1,6 @@
+create extension if not exists pgcrypto;
+
+-- google client secrets are kept encrypted with TENANT_SECRETS_KEY, secrets written to
+-- google_client_secret in plain text are sealed into this column and cleared by the app
+alter table tenants
+    add column if not exists google_client_secret_sealed bytea null;
@@ -0,0 +1,2 @@
+drop trigger if exists tenants_seed_admin_role on tenants;
+drop function if exists seed_tenant_admin_role();
@@ -0,0 +1,39 @@
+-- every new tenant gets the admin role with all permissions, like the tenants seeded by the rbac migration
+create or replace function seed_tenant_admin_role() returns trigger as
+$$
+begin
+    insert into roles (tenant_id, name, description)
+    values (new.tenant_id, 'admin', 'Full access to the admin api')
+    on conflict (tenant_id, name) do nothing;
+
+    insert into role_permissions (role_idref, permission_idref)
+    select r.role_id, p.permission_id
+    from roles r
+             cross join permissions p
+    where r.tenant_id = new.tenant_id
+      and r.name = 'admin'
+    on conflict do nothing;
+
+    return new;
+end;
+$$ language plpgsql;
+
+drop trigger if exists tenants_seed_admin_role on tenants;
+create trigger tenants_seed_admin_role
+    after insert
+    on tenants
+    for each row
+execute function seed_tenant_admin_role();
+
+-- tenants created after the rbac migration
+insert into roles (tenant_id, name, description)
+select tenant_id, 'admin', 'Full access to the admin api'
+from tenants
+on conflict (tenant_id, name) do nothing;
+
+insert into role_permissions (role_idref, permission_idref)
+select r.role_id, p.permission_id
+from roles r
+         cross join permissions p
+where r.name = 'admin'
+on conflict do nothing;
@@ -0,0 +1,3 @@
+drop index if exists auth_events_tenant_created_at_idx;
+alter table auth_events
+    drop column if exists tenant_id;
@@ -0,0 +1,11 @@
+alter table auth_events
+    add column if not exists tenant_id bigint references tenants (tenant_id);
+update auth_events e
+set tenant_id = coalesce(
+        (select u.tenant_id from users u where u.user_id = e.user_idref),
+        (select t.tenant_id from tenants t where t.tenant_code = 'default'))
+where e.tenant_id is null;
+alter table auth_events
+    alter column tenant_id set not null;
+
+create index if not exists auth_events_tenant_created_at_idx on auth_events (tenant_id, created_at desc);
@@ -0,0 +1,3 @@
+drop index if exists outbox_user_idx;
+alter table outbox
+    drop column if exists user_idref;
@@ -0,0 +1,13 @@
+alter table outbox
+    add column if not exists user_idref bigint null references users (user_id) on delete cascade;
+
+-- pending events carry the id of the user, mails and sms written before are published as they were
+update outbox o
+set user_idref = u.user_id
+from users u
+where o.topic = 'events'
+  and o.published_at is null
+  and o.user_idref is null
+  and u.user_idcode = o.payload -> 'payload' ->> 'user_id';
+
+create index if not exists outbox_user_idx on outbox (user_idref) where user_idref is not null;
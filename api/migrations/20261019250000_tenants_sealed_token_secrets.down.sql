@@ -0,0 +1,4 @@
+-- sealed secrets can not be decrypted here, they must be written to access_secret and refresh_secret again
+alter table tenants
+    drop column if exists access_secret_sealed,
+    drop column if exists refresh_secret_sealed;
@@ -0,0 +1,5 @@
+-- token secrets of tenants are kept encrypted with TENANT_SECRETS_KEY as well, secrets written to
+-- access_secret and refresh_secret in plain text are sealed into these columns and cleared on start
+alter table tenants
+    add column if not exists access_secret_sealed  bytea null,
+    add column if not exists refresh_secret_sealed bytea null;
@@ -3,23 +3,27 @@ package interfaces
 import (
 	"auth-api/internal/models"
 	"context"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"time"
 )
 
 type IProcessor interface {
 	Cache() ICacheProcessor
 	Queue() IQueueProcessor
+	GeoIP() IGeoIPProcessor
 }
 
 // Cache
 
 type ICacheProcessor interface {
 	Set(ctx context.Context, key string, value []byte) error
+	SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	SetJSON(ctx context.Context, key string, value interface{}) error
 	SetJSONWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
 	Get(ctx context.Context, key string) ([]byte, error)
 	GetJSON(ctx context.Context, key string, v interface{}) error
+	GetDel(ctx context.Context, key string) ([]byte, error)
+	Incr(ctx context.Context, key string) (int64, error)
+	IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error)
 	Delete(ctx context.Context, key string) error
 	FlushAll(ctx context.Context) error
 }
@@ -28,25 +32,89 @@ type ICacheProvider interface {
 	Set(ctx context.Context, key string, value []byte) error
 	SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Get(ctx context.Context, key string) ([]byte, error)
+	// GetDel returns the value and deletes the key atomically, only one caller gets the value
+	GetDel(ctx context.Context, key string) ([]byte, error)
+	// Incr increments the counter and keeps its expiry, missing key is not created and 0 is returned
+	Incr(ctx context.Context, key string) (int64, error)
+	// IncrWithTTL increments the counter, the new counter expires after ttl
+	IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error)
 	Delete(ctx context.Context, key string) error
 	FlushAll(ctx context.Context) error
 	Close() error
 }
 
+// GeoIP
+
+type IGeoIPProcessor interface {
+	Locate(ip string) *models.Location
+}
+
+type IGeoIPProvider interface {
+	Lookup(ip string) (*models.Location, error)
+	Close() error
+}
+
 // Queue
 
 type IQueueProcessor interface {
 	Producers() IQueueProducersProcessor
+	Consumers() IQueueConsumersProcessor
+	DeadLetters() IQueueDeadLettersProcessor
 }
 
 type IQueueProducersProcessor interface {
 	Mails() IQueueProducerProcessor
+	Events() IQueueEventsProducerProcessor
+	Sms() IQueueSmsProducerProcessor
+	DeclareTopology(ctx context.Context) error
 }
 
 type IQueueProducerProcessor interface {
-	Send(ctx context.Context, message *models.MailsProducerMsg) error
+	Send(ctx context.Context, message *models.MailCommand) error
+}
+
+type IQueueSmsProducerProcessor interface {
+	Send(ctx context.Context, message *models.SmsCommand) error
+}
+
+type IQueueEventsProducerProcessor interface {
+	Publish(ctx context.Context, event *models.Event) error
 }
 
 type IQueueProducerProvider interface {
-	Send(ctx context.Context, qName string, message []byte, args ...amqp.Table) (err error)
+	SetQueueTopology(qName string, topology *models.QueueTopology)
+	DeclareTopology(ctx context.Context) error
+	Send(ctx context.Context, qName, messageID string, message []byte, headers models.QueueHeaders) (err error)
+	Publish(ctx context.Context, exchange, routingKey, messageID string, message []byte) (err error)
+	Close() error
+}
+
+type IQueueConsumersProcessor interface {
+	Commands() IQueueCommandsConsumerProcessor
+}
+
+type IQueueCommandsConsumerProcessor interface {
+	Register(commandType models.CommandType, handler CommandHandler)
+	Run(ctx context.Context) error
+}
+
+// CommandHandler handles the payload of a single inbound command
+type CommandHandler func(ctx context.Context, payload []byte) error
+
+// QueueMessageHandler handles a single consumed message, returned error nacks the message
+type QueueMessageHandler func(ctx context.Context, message *models.QueueMessage) error
+
+// QueueMessageFilter reports whether message should be processed
+type QueueMessageFilter func(message *models.QueueMessage) bool
+
+type IQueueConsumerProvider interface {
+	Consume(ctx context.Context, qName string, handler QueueMessageHandler) error
+	PeekDeadLetters(ctx context.Context, qName string, limit int, match QueueMessageFilter) ([]models.QueueMessage, error)
+	RequeueDeadLetters(ctx context.Context, qName string, limit int, match QueueMessageFilter) (int, error)
+	Close() error
+}
+
+type IQueueDeadLettersProcessor interface {
+	Mails(ctx context.Context, templateID models.MailTemplate, limit int) ([]models.DeadLetteredMail, error)
+	RequeueMails(ctx context.Context, templateID models.MailTemplate, ids []string) (int, error)
 }
@@ -3,47 +3,156 @@ package interfaces
 import (
 	"auth-api/internal/models"
 	"context"
+	"time"
 )
 
 type IRepository interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	Tenants() ITenantsRepository
 	Users() IUserRepository
 	Sessions() ISessionRepository
+	Roles() IRolesRepository
+	Permissions() IPermissionsRepository
+	UserDevices() IUserDevicesRepository
+	UserIdentities() IUserIdentitiesRepository
+	AuthEvents() IAuthEventsRepository
+	DataExports() IDataExportsRepository
+	Outbox() IOutboxRepository
 	SessionsCache() ISessionsCacheRepository
-	GoogleAPICodes() IGoogleAPICodesRepository
+	OAuthCodes(provider models.OAuthProvider) IOAuthCodesRepository
+	OAuthResults() IOAuthResultsRepository
 	VerificationCodes() IVerificationCodesRepository
+	ContactChanges() IContactChangesRepository
+	ReauthAttempts() IReauthAttemptsRepository
+}
+
+type ITenantsRepository interface {
+	FindAll(ctx context.Context) ([]models.Tenant, error)
+	SealSecrets(ctx context.Context) error
 }
 
 type IUserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByID(ctx context.Context, id int64) (result *models.User, err error)
-	FindByEmail(ctx context.Context, email string) (result *models.User, err error)
-	FindByUserIDCode(ctx context.Context, userIDCode string) (user *models.User, err error)
-	FindWSessionByToken(ctx context.Context, refreshToken string) (*models.UserSession, error)
+	FindByEmail(ctx context.Context, tenantID int64, email string) (result *models.User, err error)
+	FindByUserIDCode(ctx context.Context, tenantID int64, userIDCode string) (user *models.User, err error)
+	FindByUserIDCodeInAnyTenant(ctx context.Context, userIDCode string) (user *models.User, err error)
+	FindWSessionByToken(ctx context.Context, tenantID int64, refreshToken string) (*models.UserSession, error)
+	Update(ctx context.Context, user *models.User) error
+	SetPassword(ctx context.Context, userID int64, hashedPassword string) error
+	SetDisabledAt(ctx context.Context, userID int64, disabledAt *time.Time) error
+	SetEmailBouncedAt(ctx context.Context, tenantID int64, email string, bouncedAt *time.Time) error
+	SetEraseAt(ctx context.Context, userID int64, eraseAt *time.Time) error
+	FindDueForErasure(ctx context.Context, now time.Time) (*models.User, error)
+	Erase(ctx context.Context, userID int64) error
+	Search(ctx context.Context, filter *models.UsersFilter) ([]models.User, error)
+	Count(ctx context.Context, filter *models.UsersFilter) (int64, error)
 }
 
 type ISessionRepository interface {
 	Create(ctx context.Context, session *models.Session) error
 	FindByID(ctx context.Context, sessionID int64) (*models.Session, error)
-	FindByToken(ctx context.Context, refreshToken string) (*models.Session, error)
+	FindByToken(ctx context.Context, tenantID int64, refreshToken string) (*models.Session, error)
+	FindByUserID(ctx context.Context, userID int64, limit uint64) ([]models.Session, error)
 	UpdateByID(ctx context.Context, session *models.Session) error
 	UpdateByUserID(ctx context.Context, session *models.Session) error
 	EndSession(ctx context.Context, sessionID int64) error
+	EndByUserID(ctx context.Context, userID int64) (sessionIDs []int64, err error)
 	DeleteByID(ctx context.Context, sessionID int64) error
 }
 
+type IRolesRepository interface {
+	FindAll(ctx context.Context, tenantID int64) ([]models.Role, error)
+	FindByName(ctx context.Context, tenantID int64, name string) (*models.Role, error)
+	FindByUserID(ctx context.Context, userID int64) ([]models.Role, error)
+	Save(ctx context.Context, role *models.Role) error
+	SetPermissions(ctx context.Context, roleID int64, permissions []string) (granted int64, err error)
+	Delete(ctx context.Context, roleID int64) error
+	Assign(ctx context.Context, userID, roleID int64) error
+	Unassign(ctx context.Context, userID, roleID int64) error
+}
+
+type IPermissionsRepository interface {
+	FindAll(ctx context.Context) ([]models.Permission, error)
+	Save(ctx context.Context, permission *models.Permission) error
+}
+
+type IUserDevicesRepository interface {
+	FindByUserID(ctx context.Context, userID int64) ([]models.UserDevice, error)
+	Save(ctx context.Context, device *models.UserDevice) error
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
+type IUserIdentitiesRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	FindBySubject(ctx context.Context, tenantID int64, provider models.OAuthProvider, subject string) (*models.UserIdentity, error)
+	FindByUserID(ctx context.Context, userID int64) ([]models.UserIdentity, error)
+	Touch(ctx context.Context, identity *models.UserIdentity) error
+	Delete(ctx context.Context, userID int64, provider models.OAuthProvider) (bool, error)
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
+type IAuthEventsRepository interface {
+	Create(ctx context.Context, event *models.AuthEvent) error
+	EraseByUserID(ctx context.Context, userID int64) error
+	Find(ctx context.Context, filter *models.AuthEventsFilter) ([]models.AuthEvent, error)
+	Count(ctx context.Context, filter *models.AuthEventsFilter) (int64, error)
+}
+
+type IDataExportsRepository interface {
+	Create(ctx context.Context, export *models.DataExport) (created bool, err error)
+	FindByID(ctx context.Context, exportID string) (*models.DataExport, error)
+	FetchPending(ctx context.Context) (*models.DataExport, error)
+	MarkReady(ctx context.Context, exportID string, content []byte, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, exportID string, reason string) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
+type IOutboxRepository interface {
+	Create(ctx context.Context, message *models.OutboxMessage) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error
+	MarkDead(ctx context.Context, id int64, reason string) error
+	Release(ctx context.Context, id int64) error
+	DeletePublished(ctx context.Context, before time.Time) error
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
 type ISessionsCacheRepository interface {
-	Get(ctx context.Context, userIDCode string) (int64, error)
-	Set(ctx context.Context, userIDCode string, startedAt int64) error
+	Get(ctx context.Context, tenantID int64, userIDCode string) (int64, error)
+	Set(ctx context.Context, tenantID int64, userIDCode string, startedAt int64) error
+	Delete(ctx context.Context, tenantID int64, userIDCode string) error
 }
 
-type IGoogleAPICodesRepository interface {
-	Get(ctx context.Context, code string) (int64, error)
-	Set(ctx context.Context, code string, value int64) error
-	Delete(ctx context.Context, code string) error
+type IOAuthCodesRepository interface {
+	Get(ctx context.Context, tenantID int64, code string) (*models.OAuthState, error)
+	Set(ctx context.Context, tenantID int64, code string, state *models.OAuthState) error
+	Delete(ctx context.Context, tenantID int64, code string) error
+}
+
+type IOAuthResultsRepository interface {
+	GetDel(ctx context.Context, tenantID int64, code string) (*models.AuthResponse, error)
+	Set(ctx context.Context, tenantID int64, code string, result *models.AuthResponse) error
 }
 
 type IVerificationCodesRepository interface {
-	Get(ctx context.Context, email string) (string, error)
-	Set(ctx context.Context, email, code string) error
-	Delete(ctx context.Context, email string) error
+	Get(ctx context.Context, tenantID int64, email string) (string, error)
+	Set(ctx context.Context, tenantID int64, email, code string) error
+	Attempt(ctx context.Context, tenantID int64, email string) (int64, error)
+	Delete(ctx context.Context, tenantID int64, email string) error
+}
+
+type IContactChangesRepository interface {
+	Get(ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) (*models.ContactChange, error)
+	Set(ctx context.Context, tenantID int64, userIDCode string, change *models.ContactChange) error
+	Attempt(ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) (int64, error)
+	Delete(ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) error
+}
+
+type IReauthAttemptsRepository interface {
+	Incr(ctx context.Context, tenantID int64, userIDCode string) (int64, error)
+	Delete(ctx context.Context, tenantID int64, userIDCode string) error
 }
@@ -9,30 +9,104 @@ type IService interface {
 	Auth() IAuthService
 	User() IUserService
 	OAuth() IOAuthService
+	Audit() IAuditService
+	Outbox() IOutboxService
+	Mail() IMailService
+	Tenant() ITenantService
+	Role() IRoleService
 }
 
 type IAuthService interface {
-	NewPairTokens(uSession *models.UserSession) (result *models.Tokens, err error)
+	NewPairTokens(ctx context.Context, uSession *models.UserSession) (result *models.Tokens, err error)
 	NewSession(ctx context.Context, user *models.User) (result *models.Tokens, err error)
 	UpdateSession(ctx context.Context, user *models.User) (result *models.Tokens, err error)
+	RefreshSession(ctx context.Context, user *models.User) (result *models.Tokens, err error)
 	ValidateRefreshToken(ctx context.Context, refreshToken string) (*models.UserSession, error)
 	ValidateAccessToken(ctx context.Context, accessToken string) (*models.UserSession, error)
+	RevokeSession(ctx context.Context, revokeToken string) error
 }
 
 type IUserService interface {
 	Create(ctx context.Context, userDTO *models.UserDTO) (*models.AuthResponse, error)
+	CreateWithIdentity(ctx context.Context, userDTO *models.UserDTO, identity *models.UserIdentity) (*models.AuthResponse, error)
 	Authenticate(ctx context.Context, userDTO *models.UserDTO) (*models.AuthResponse, error)
 	Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error)
 	Logout(ctx context.Context, refreshToken string) (err error)
 	SendVerifyCode(ctx context.Context, email string) error
 	GetByUserIDCode(ctx context.Context, userIDCode string) (*models.UserDTO, error)
+	UpdateProfile(ctx context.Context, userIDCode string, request *models.UpdateProfileReq) (*models.ProfileUpdateRes, error)
+	ConfirmContactChange(ctx context.Context, userIDCode string, request *models.ConfirmContactReq) (*models.AuthResponse, error)
+	Identities(ctx context.Context, userIDCode string) (*models.IdentitiesRes, error)
+	StartLink(ctx context.Context, userIDCode string, provider models.OAuthProvider, reauth *models.ReauthReq) (*models.OAuthRedirectRes, error)
+	UnlinkIdentity(ctx context.Context, userIDCode string, provider models.OAuthProvider) error
+	SetPassword(ctx context.Context, userIDCode string, request *models.SetPasswordReq) (*models.AuthResponse, error)
+	Deactivate(ctx context.Context, userIDCode, reason string) (err error)
+	ForceLogout(ctx context.Context, userIDCode, reason string) (err error)
+	MarkEmailBounced(ctx context.Context, email string) error
+	Enable(ctx context.Context, userIDCode, reason string) (err error)
+	ScheduleDeletion(ctx context.Context, userIDCode, reason string) (*models.DeletionRes, error)
+	CancelDeletion(ctx context.Context, userIDCode, reason string) (err error)
+	RunErasure(ctx context.Context)
+	RequestExport(ctx context.Context, userIDCode string, format models.ExportFormat) (*models.DataExportRes, error)
+	GetExport(ctx context.Context, userIDCode, exportID string) (*models.DataExportRes, error)
+	DownloadExport(ctx context.Context, token string) (*models.DataExport, error)
+	RunExports(ctx context.Context)
+	ResendVerification(ctx context.Context, userIDCode string) error
+	Search(ctx context.Context, filter *models.UsersFilter) (*models.UsersPage, error)
+	GetDetail(ctx context.Context, userIDCode string) (*models.UserDetailRes, error)
+}
+
+type IAuditService interface {
+	Record(ctx context.Context, event *models.AuthEvent, err *error)
+	ListByUser(ctx context.Context, userIDCode string, limit, offset uint64) (*models.AuthEventsPage, error)
+	Search(ctx context.Context, filter *models.AuthEventsFilter) (*models.AuthEventsPage, error)
+}
+
+type IOutboxService interface {
+	Mail(ctx context.Context, userID int64, message *models.MailCommand) error
+	Sms(ctx context.Context, userID int64, message *models.SmsCommand) error
+	Event(ctx context.Context, userID int64, eventType models.EventType, payload interface{}) error
+	RunRelay(ctx context.Context)
+}
+
+type IMailService interface {
+	DeadLetters(ctx context.Context, limit int) ([]models.DeadLetteredMail, error)
+	RequeueDeadLetters(ctx context.Context, ids []string) (int, error)
+}
+
+type ITenantService interface {
+	Resolve(ctx context.Context, code, host string) (*models.Tenant, error)
+	Current(ctx context.Context) (*models.Tenant, error)
+	GetByID(ctx context.Context, tenantID int64) (*models.Tenant, error)
+}
+
+type IRoleService interface {
+	Roles(ctx context.Context) ([]models.Role, error)
+	SaveRole(ctx context.Context, request *models.SaveRoleReq) (*models.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	Permissions(ctx context.Context) ([]models.Permission, error)
+	SavePermission(ctx context.Context, permission *models.Permission) error
+	UserRoles(ctx context.Context, userIDCode string) (*models.UserRolesRes, error)
+	AssignRole(ctx context.Context, userIDCode, roleName string) error
+	UnassignRole(ctx context.Context, userIDCode, roleName string) error
+	Claims(ctx context.Context, userID int64) (roles, permissions []string, err error)
 }
 
 type IOAuthService interface {
 	Google() IGoogleAPI
+	Provider(name models.OAuthProvider) (IOAuthProvider, error)
+	GetLinkURL(ctx context.Context, provider models.OAuthProvider, user *models.User) (*models.OAuthRedirectRes, error)
+	Exchange(ctx context.Context, code string) (*models.AuthResponse, error)
+}
+
+type IOAuthProvider interface {
+	GetRedirectURL(ctx context.Context, returnTo string) (*models.OAuthRedirectRes, error)
+	GetLinkURL(ctx context.Context, user *models.User) (*models.OAuthRedirectRes, error)
+	HandleCallBack(ctx context.Context, state, exchangeCode string) (*models.OAuthCallBackRes, error)
 }
 
 type IGoogleAPI interface {
-	GetRedirectURL(ctx context.Context, state string) (*models.GoogleRedirectRes, error)
-	HandleCallBack(ctx context.Context, code, exchangeCode string) (string, error)
+	GetRedirectURL(ctx context.Context, returnTo string) (*models.OAuthRedirectRes, error)
+	GetLinkURL(ctx context.Context, user *models.User) (*models.OAuthRedirectRes, error)
+	HandleCallBack(ctx context.Context, code, exchangeCode string) (*models.OAuthCallBackRes, error)
 }
@@ -3,12 +3,12 @@ package manager
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
-	"auth-api/internal/pkg/rabbitmq"
-	"auth-api/internal/pkg/redis"
+	"auth-api/internal/pkg/geoip"
 	"auth-api/internal/processor"
 	"auth-api/internal/repository"
 	"auth-api/internal/service"
 	"auth-api/server"
+	"context"
 	_ "github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -21,6 +21,8 @@ type Manager struct {
 	config                *models.Config
 	cacheProvider         interfaces.ICacheProvider
 	queueProducerProvider interfaces.IQueueProducerProvider
+	queueConsumerProvider interfaces.IQueueConsumerProvider
+	geoIPProvider         interfaces.IGeoIPProvider
 
 	service       interfaces.IService
 	serviceRunner sync.Once
@@ -33,20 +35,28 @@ type Manager struct {
 
 	server       interfaces.IServer
 	serverRunner sync.Once
+
+	// stops background workers started by Run
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
 }
 
 func InitManager(
 	db *sqlx.DB,
 	log *zap.Logger,
 	config *models.Config,
-	rabbitmqProducer *rabbitmq.ProducerClient,
-	redisConn *redis.Conn) *Manager {
+	queueProducer interfaces.IQueueProducerProvider,
+	queueConsumer interfaces.IQueueConsumerProvider,
+	cacheProvider interfaces.ICacheProvider,
+	geoIPReader *geoip.Reader) *Manager {
 	return &Manager{
 		db:                    db,
 		log:                   log,
 		config:                config,
-		cacheProvider:         redisConn,
-		queueProducerProvider: rabbitmqProducer,
+		cacheProvider:         cacheProvider,
+		queueProducerProvider: queueProducer,
+		queueConsumerProvider: queueConsumer,
+		geoIPProvider:         geoIPReader,
 	}
 }
 
@@ -59,7 +69,7 @@ func (m *Manager) Repository() interfaces.IRepository {
 
 func (m *Manager) Service() interfaces.IService {
 	m.serviceRunner.Do(func() {
-		m.service = service.InitService(m, m.config)
+		m.service = service.InitService(m, m.config, m.log.Named("[SERVICE]"))
 	})
 	return m.service
 }
@@ -67,7 +77,7 @@ func (m *Manager) Service() interfaces.IService {
 func (m *Manager) Processor() interfaces.IProcessor {
 	m.processorRunner.Do(func() {
 		m.processor = processor.InitProcessor(m.log, m.config,
-			m.Service(), m.queueProducerProvider, m.cacheProvider)
+			m.Service(), m.queueProducerProvider, m.queueConsumerProvider, m.cacheProvider, m.geoIPProvider)
 	})
 	return m.processor
 }
@@ -0,0 +1,158 @@
+// Package managertest builds the manager on memory drivers for service tests
+package managertest
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/manager"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/geoip"
+	"auth-api/internal/pkg/memory"
+	memorydb "auth-api/internal/repository/memory"
+	"context"
+	"encoding/json"
+	"github.com/doxanocap/pkg/ctxholder"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+)
+
+// New builds the manager on the memory cache, queue and database
+func New(t *testing.T) (*manager.Manager, *memory.Queue) {
+	t.Helper()
+
+	queue := memory.NewQueue()
+	return WithProducer(t, queue, queue), queue
+}
+
+// WithProducer builds the manager that publishes with the producer instead of the memory queue
+func WithProducer(t *testing.T, producer interfaces.IQueueProducerProvider, consumer interfaces.IQueueConsumerProvider) *manager.Manager {
+	t.Helper()
+
+	config := &models.Config{
+		ServerPublicURL: "http://localhost:5000",
+		QueueDriver:     consts.QueueDriverMemory,
+		CacheDriver:     consts.CacheDriverMemory,
+		DatabaseDriver:  consts.DatabaseDriverMemory,
+		Token: models.Token{
+			RefreshSecret:    "refresh-secret",
+			AccessSecret:     "access-secret",
+			RevokeSecret:     "revoke-secret",
+			ExportSecret:     "export-secret",
+			StateSecret:      "state-secret",
+			TenantSecretsKey: "tenant-secrets-key",
+		},
+		RabbitMQ: models.RabbitMQ{
+			MailsQueue:     "mails",
+			SmsQueue:       "sms",
+			EventsExchange: "events",
+			CommandsQueue:  "commands",
+		},
+		Privacy: models.Privacy{
+			DeletionGraceHours: 720,
+			ExportTTLHours:     72,
+		},
+	}
+
+	cache := memory.NewCache()
+	t.Cleanup(func() { _ = cache.Close() })
+
+	return manager.InitManager(nil, zap.NewNop(), config, producer, consumer, cache, &geoip.Reader{})
+}
+
+// AddTenant stores the tenant in the memory database, it must be called before the first request
+// because tenants are cached
+func AddTenant(t *testing.T, m *manager.Manager, tenant *models.Tenant) {
+	t.Helper()
+
+	repository, ok := m.Repository().(interface{ MemoryStore() *memorydb.Store })
+	require.True(t, ok)
+	repository.MemoryStore().AddTenant(tenant)
+}
+
+// TenantContext is the request context of the device resolved to the tenant
+func TenantContext(tenantID int64, ip, userAgent string) context.Context {
+	ctx := RequestContext(ip, userAgent)
+	ctxholder.SetKV(ctx, consts.CtxKeyTenantID, tenantID)
+	return ctx
+}
+
+// RequestContext is the context of the request made from the device
+func RequestContext(ip, userAgent string) context.Context {
+	ctx := context.WithValue(context.Background(), ctxholder.ContextHolderKey, &sync.Map{})
+	ctxholder.SetKV(ctx, consts.CtxKeyClientIP, ip)
+	ctxholder.SetKV(ctx, consts.CtxKeyUserAgent, userAgent)
+	ctxholder.SetKV(ctx, consts.CtxKeyLocale, "en")
+	return ctx
+}
+
+// SignUp creates the user with the password "Password123!"
+func SignUp(t *testing.T, m *manager.Manager, ctx context.Context, email string) *models.AuthResponse {
+	t.Helper()
+
+	response, err := m.Service().User().Create(ctx, &models.UserDTO{Email: email, Password: "Password123!", OAuthProvider: models.DefaultOAuth})
+	require.NoError(t, err)
+	return response
+}
+
+// PendingEvents returns payloads of events of the type waiting in the outbox
+func PendingEvents(t *testing.T, m *manager.Manager, eventType models.EventType) []json.RawMessage {
+	t.Helper()
+
+	var payloads []json.RawMessage
+	for _, message := range pending(t, m, models.OutboxTopicEvents) {
+		var event models.Event
+		require.NoError(t, json.Unmarshal(message.Payload, &event))
+		if event.Type == eventType {
+			payloads = append(payloads, event.Payload)
+		}
+	}
+	return payloads
+}
+
+// PendingMails returns mails of the template waiting in the outbox
+func PendingMails(t *testing.T, m *manager.Manager, template models.MailTemplate) []models.MailCommand {
+	t.Helper()
+
+	var mails []models.MailCommand
+	for _, message := range pending(t, m, models.OutboxTopicMails) {
+		var mail models.MailCommand
+		require.NoError(t, json.Unmarshal(message.Payload, &mail))
+		if mail.TemplateID == template {
+			mails = append(mails, mail)
+		}
+	}
+	return mails
+}
+
+// PendingSms returns sms of the template waiting in the outbox
+func PendingSms(t *testing.T, m *manager.Manager, template models.SmsTemplate) []models.SmsCommand {
+	t.Helper()
+
+	var messages []models.SmsCommand
+	for _, message := range pending(t, m, models.OutboxTopicSms) {
+		var sms models.SmsCommand
+		require.NoError(t, json.Unmarshal(message.Payload, &sms))
+		if sms.TemplateID == template {
+			messages = append(messages, sms)
+		}
+	}
+	return messages
+}
+
+func pending(t *testing.T, m *manager.Manager, topic models.OutboxTopic) []models.OutboxMessage {
+	t.Helper()
+
+	// zero lease keeps claimed messages pending
+	messages, err := m.Repository().Outbox().Claim(context.Background(), consts.OutboxBatchSize, 0)
+	require.NoError(t, err)
+
+	var found []models.OutboxMessage
+	for _, message := range messages {
+		if message.Topic == topic {
+			found = append(found, message)
+		}
+	}
+	return found
+}
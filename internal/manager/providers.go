@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/memory"
+	"auth-api/internal/pkg/nats"
+	"auth-api/internal/pkg/postgres"
+	"auth-api/internal/pkg/rabbitmq"
+	"auth-api/internal/pkg/redis"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// InitDatabase connects to the DATABASE_DRIVER backend, memory driver keeps rows
+// in the repository and needs no connection
+func InitDatabase(config *models.Config, log *zap.Logger) (*sqlx.DB, error) {
+	switch config.DatabaseDriver {
+	case consts.DatabaseDriverPostgres:
+		return postgres.InitConnection(config, log), nil
+	case consts.DatabaseDriverMemory:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown database driver %q", config.DatabaseDriver)
+}
+
+// InitCacheProvider creates cache provider of the CACHE_DRIVER backend
+func InitCacheProvider(config *models.Config, log *zap.Logger) (interfaces.ICacheProvider, error) {
+	switch config.CacheDriver {
+	case consts.CacheDriverRedis:
+		return redis.InitConnection(config, log), nil
+	case consts.CacheDriverMemory:
+		return memory.NewCache(), nil
+	}
+	return nil, fmt.Errorf("unknown cache driver %q", config.CacheDriver)
+}
+
+// InitQueueProducer creates queue producer of the QUEUE_DRIVER backend,
+// memory queue is shared by the producer and the consumer
+func InitQueueProducer(
+	config *models.Config,
+	log *zap.Logger,
+	memoryQueue *memory.Queue) (interfaces.IQueueProducerProvider, error) {
+	switch config.QueueDriver {
+	case consts.QueueDriverRabbitMQ:
+		return rabbitmq.NewProducerClient(config, log), nil
+	case consts.QueueDriverNATS:
+		return nats.NewProducerClient(config, log)
+	case consts.QueueDriverMemory:
+		return memoryQueue, nil
+	}
+	return nil, fmt.Errorf("unknown queue driver %q", config.QueueDriver)
+}
+
+// InitQueueConsumer creates queue consumer of the QUEUE_DRIVER backend,
+// inbound commands are not supported by NATS
+func InitQueueConsumer(
+	config *models.Config,
+	log *zap.Logger,
+	memoryQueue *memory.Queue) (interfaces.IQueueConsumerProvider, error) {
+	switch config.QueueDriver {
+	case consts.QueueDriverRabbitMQ:
+		return rabbitmq.NewConsumerClient(config, log), nil
+	case consts.QueueDriverMemory:
+		return memoryQueue, nil
+	case consts.QueueDriverNATS:
+		return &unsupportedConsumer{log: log.Named("[QUEUE]"), driver: config.QueueDriver}, nil
+	}
+	return nil, fmt.Errorf("unknown queue driver %q", config.QueueDriver)
+}
+
+type unsupportedConsumer struct {
+	log    *zap.Logger
+	driver string
+}
+
+func (c *unsupportedConsumer) Consume(ctx context.Context, qName string, _ interfaces.QueueMessageHandler) error {
+	c.log.Warn(fmt.Sprintf("queue %s is not consumed: not supported by %s driver", qName, c.driver))
+	<-ctx.Done()
+	return nil
+}
+
+func (c *unsupportedConsumer) PeekDeadLetters(context.Context, string, int, interfaces.QueueMessageFilter) ([]models.QueueMessage, error) {
+	return nil, models.ErrQueueUnsupported
+}
+
+func (c *unsupportedConsumer) RequeueDeadLetters(context.Context, string, int, interfaces.QueueMessageFilter) (int, error) {
+	return 0, models.ErrQueueUnsupported
+}
+
+func (c *unsupportedConsumer) Close() error {
+	return nil
+}
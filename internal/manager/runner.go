@@ -1,7 +1,10 @@
 package manager
 
 import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"context"
+	"fmt"
 	"go.uber.org/fx"
 )
 
@@ -11,38 +14,132 @@ func Run(
 ) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) (err error) {
+			if err = manager.config.Privacy.Validate(); err != nil {
+				return err
+			}
+			// secrets written to the tenants in plain text are sealed once, not on every reload
+			if err = manager.Repository().Tenants().SealSecrets(ctx); err != nil {
+				return err
+			}
 
 			processor := manager.Processor()
 			{
 				processor.Queue().Producers().Mails()
+				processor.Queue().Producers().Events()
+				processor.Queue().Producers().Sms()
+				processor.Queue().Consumers().Commands()
 				processor.Cache()
+				processor.GeoIP()
+			}
+			// queues left by older versions with other arguments stop the start, see README
+			declareCtx, cancelDeclare := context.WithTimeout(ctx, consts.RabbitMQAwaitTimeout)
+			err = processor.Queue().Producers().DeclareTopology(declareCtx)
+			cancelDeclare()
+			if err != nil {
+				return err
 			}
 			repository := manager.Repository()
 			{
+				repository.Tenants()
 				repository.Users()
 				repository.Sessions()
-				repository.GoogleAPICodes()
+				repository.Roles()
+				repository.Permissions()
+				repository.UserDevices()
+				repository.UserIdentities()
+				repository.AuthEvents()
+				repository.DataExports()
+				repository.OAuthCodes(models.GoogleOAuth)
+				repository.OAuthResults()
 				repository.SessionsCache()
 				repository.VerificationCodes()
+				repository.ContactChanges()
+				repository.ReauthAttempts()
 			}
 			service := manager.Service()
 			{
 				service.User()
 				service.Auth()
 				service.OAuth()
+				service.Audit()
+				service.Outbox()
+				service.Tenant()
+				service.Role()
 			}
 
+			workersCtx, cancel := context.WithCancel(context.Background())
+			manager.stopWorkers = cancel
+			manager.runWorker(func() {
+				service.Outbox().RunRelay(workersCtx)
+			})
+			manager.runWorker(func() {
+				service.User().RunErasure(workersCtx)
+			})
+			manager.runWorker(func() {
+				service.User().RunExports(workersCtx)
+			})
+			manager.runWorker(func() {
+				if err := processor.Queue().Consumers().Commands().Run(workersCtx); err != nil {
+					manager.log.Error(fmt.Sprintf("commands consumer: %s", err))
+				}
+			})
+
 			manager.Server().REST().Run()
 			return
 		},
 		OnStop: func(ctx context.Context) (err error) {
-			if err = manager.db.Close(); err != nil {
+			if err = manager.Server().REST().Shutdown(ctx); err != nil {
+				return err
+			}
+			if manager.stopWorkers != nil {
+				manager.stopWorkers()
+			}
+			// consumers finish messages that are being handled before connections are closed
+			if err = manager.waitWorkers(ctx); err != nil {
+				return err
+			}
+			if err = manager.queueConsumerProvider.Close(); err != nil {
+				return err
+			}
+			// there is no connection when the memory database driver is selected
+			if manager.db != nil {
+				if err = manager.db.Close(); err != nil {
+					return err
+				}
+			}
+			if err = manager.queueProducerProvider.Close(); err != nil {
 				return err
 			}
 			if err = manager.cacheProvider.Close(); err != nil {
 				return err
 			}
+			if err = manager.geoIPProvider.Close(); err != nil {
+				return err
+			}
 			return nil
 		},
 	})
 }
+
+func (m *Manager) runWorker(fn func()) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn()
+	}()
+}
+
+func (m *Manager) waitWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+type UserStatus string
+
+const (
+	UserStatusActive     UserStatus = "active"
+	UserStatusUnverified UserStatus = "unverified"
+	UserStatusDisabled   UserStatus = "disabled"
+	UserStatusDeleted    UserStatus = "deleted"
+)
+
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserStatusActive, UserStatusUnverified, UserStatusDisabled, UserStatusDeleted:
+		return true
+	}
+	return false
+}
+
+type UsersFilter struct {
+	TenantID      int64
+	Email         string
+	PhoneNumber   string
+	OAuthProvider OAuthProvider
+	Status        UserStatus
+	Limit         uint64
+	Offset        uint64
+}
+
+// AdminUser is a user as seen by the support team
+type AdminUser struct {
+	IDCode         string        `json:"id"`
+	Email          string        `json:"email"`
+	PhoneNumber    string        `json:"phone_number"`
+	Activated      bool          `json:"activated"`
+	OAuthProvider  OAuthProvider `json:"oauth_provider"`
+	FirstName      string        `json:"first_name"`
+	LastName       string        `json:"last_name"`
+	Locale         string        `json:"locale"`
+	DisplayName    string        `json:"display_name"`
+	PictureURL     string        `json:"picture_url"`
+	Status         UserStatus    `json:"status"`
+	DisabledAt     *time.Time    `json:"disabled_at"`
+	EmailBouncedAt *time.Time    `json:"email_bounced_at"`
+	EraseAt        *time.Time    `json:"erase_at"`
+	CreatedAt      *time.Time    `json:"created_at"`
+	UpdatedAt      *time.Time    `json:"updated_at"`
+	DeletedAt      *time.Time    `json:"deleted_at"`
+}
+
+// AdminSession is a session of the user without its refresh token
+type AdminSession struct {
+	ID         int64  `json:"id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	DeviceName string `json:"device_name"`
+	StartedAt  *int64 `json:"started_at"`
+	EndedAt    *int64 `json:"ended_at"`
+}
+
+type UsersPage struct {
+	Items  []AdminUser `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  uint64      `json:"limit"`
+	Offset uint64      `json:"offset"`
+}
+
+type UserDetailRes struct {
+	User        AdminUser      `json:"user"`
+	Roles       []string       `json:"roles"`
+	Permissions []string       `json:"permissions"`
+	Sessions    []AdminSession `json:"sessions"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+type (
+	AuthEventType   string
+	AuthEventResult string
+)
+
+const (
+	AuthEventSignUp         AuthEventType = "sign_up"
+	AuthEventSignIn         AuthEventType = "sign_in"
+	AuthEventRefresh        AuthEventType = "refresh"
+	AuthEventLogout         AuthEventType = "logout"
+	AuthEventOAuthCallBack  AuthEventType = "oauth_callback"
+	AuthEventSessionRevoke  AuthEventType = "session_revoke"
+	AuthEventDeactivate     AuthEventType = "deactivate"
+	AuthEventForceLogout    AuthEventType = "force_logout"
+	AuthEventRoleAssign     AuthEventType = "role_assign"
+	AuthEventRoleUnassign   AuthEventType = "role_unassign"
+	AuthEventEnable         AuthEventType = "enable"
+	AuthEventDelete         AuthEventType = "delete"
+	AuthEventCancelDelete   AuthEventType = "cancel_delete"
+	AuthEventErase          AuthEventType = "erase"
+	AuthEventExport         AuthEventType = "export"
+	AuthEventExportDownload AuthEventType = "export_download"
+	AuthEventProfileUpdate  AuthEventType = "profile_update"
+	AuthEventContactChange  AuthEventType = "contact_change"
+	AuthEventIdentityLink   AuthEventType = "identity_link"
+	AuthEventIdentityUnlink AuthEventType = "identity_unlink"
+	AuthEventPasswordChange AuthEventType = "password_change"
+	AuthEventEmailBounced   AuthEventType = "email_bounced"
+
+	AuthResultSuccess AuthEventResult = "success"
+	AuthResultFailure AuthEventResult = "failure"
+)
+
+func (t AuthEventType) IsValid() bool {
+	switch t {
+	case AuthEventSignUp, AuthEventSignIn, AuthEventRefresh,
+		AuthEventLogout, AuthEventOAuthCallBack, AuthEventSessionRevoke,
+		AuthEventDeactivate, AuthEventForceLogout, AuthEventRoleAssign,
+		AuthEventRoleUnassign, AuthEventEnable, AuthEventDelete, AuthEventCancelDelete,
+		AuthEventErase, AuthEventExport, AuthEventExportDownload, AuthEventProfileUpdate,
+		AuthEventContactChange, AuthEventIdentityLink, AuthEventIdentityUnlink, AuthEventPasswordChange,
+		AuthEventEmailBounced:
+		return true
+	}
+	return false
+}
+
+func (r AuthEventResult) IsValid() bool {
+	return r == AuthResultSuccess || r == AuthResultFailure
+}
+
+// AuthEvent is a single record of the authentication audit log
+type AuthEvent struct {
+	ID           int64           `json:"id" db:"event_id"`
+	TenantID     int64           `json:"-" db:"tenant_id"`
+	UserIDRef    *int64          `json:"-" db:"user_idref"`
+	Actor        string          `json:"actor" db:"actor"`
+	Type         AuthEventType   `json:"type" db:"event_type"`
+	Result       AuthEventResult `json:"result" db:"result"`
+	IP           string          `json:"ip" db:"event_ip"`
+	UserAgent    string          `json:"user_agent" db:"user_agent"`
+	SessionIDRef *int64          `json:"session_id" db:"session_idref"`
+	Reason       string          `json:"reason" db:"reason"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+type AuthEventsFilter struct {
+	TenantID   int64
+	UserIDRef  *int64
+	UserIDCode string
+	Actor      string
+	Type       AuthEventType
+	Result     AuthEventResult
+	IP         string
+	From       *time.Time
+	To         *time.Time
+	Limit      uint64
+	Offset     uint64
+}
+
+type AuthEventsPage struct {
+	Items  []AuthEvent `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  uint64      `json:"limit"`
+	Offset uint64      `json:"offset"`
+}
@@ -6,4 +6,5 @@ type Tokens struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
 	IssuedAt     time.Time `json:"-"`
+	SessionID    int64     `json:"-"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CommandType string
+
+const (
+	CommandUserDeactivate  CommandType = "user.deactivate"
+	CommandUserForceLogout CommandType = "user.force_logout"
+	CommandEmailBounced    CommandType = "email.bounced"
+)
+
+// Command is an envelope of the inbound command sent by other services
+type Command struct {
+	ID       string          `json:"id"`
+	Type     CommandType     `json:"type"`
+	IssuedAt time.Time       `json:"issued_at"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+type UserCommandPayload struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type EmailBouncedPayload struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
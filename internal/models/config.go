@@ -1,24 +1,54 @@
 package models
 
+import (
+	"auth-api/internal/pkg/tools"
+	"errors"
+	"time"
+)
+
 type Config struct {
 	ENV string `env:"APP_ENV"`
 
-	ServerPORT string `env:"SERVER_PORT"`
+	ServerPORT      string `env:"SERVER_PORT"`
+	ServerPublicURL string `env:"SERVER_PUBLIC_URL"`
+	TrustedProxies  string `env:"SERVER_TRUSTED_PROXIES"`
+	AdminToken      string `env:"ADMIN_API_TOKEN"`
+	QueueDriver     string `env:"QUEUE_DRIVER"`
+	CacheDriver     string `env:"CACHE_DRIVER"`
+	DatabaseDriver  string `env:"DATABASE_DRIVER"`
 
 	PSQL
 	Token
 	Redis
 	OAuth
 	RabbitMQ
+	NATS
+	GeoIP
+	Privacy
 }
 
 func (c Config) Env() string {
 	return c.ENV
 }
 
+// TrustedProxiesList returns proxies whose X-Forwarded-For headers are trusted,
+// "none" disables proxy headers completely
+func (c Config) TrustedProxiesList() []string {
+	if c.TrustedProxies == "none" {
+		return nil
+	}
+	return tools.SplitList(c.TrustedProxies)
+}
+
 type Token struct {
 	RefreshSecret string `env:"REFRESH_TOKEN_SECRET"`
 	AccessSecret  string `env:"ACCESS_TOKEN_SECRET"`
+	RevokeSecret  string `env:"REVOKE_TOKEN_SECRET"`
+	ExportSecret  string `env:"EXPORT_TOKEN_SECRET"`
+	// StateSecret signs the return path sealed in oauth states
+	StateSecret string `env:"STATE_TOKEN_SECRET"`
+	// TenantSecretsKey encrypts oauth client secrets of tenants in the database
+	TenantSecretsKey string `env:"TENANT_SECRETS_KEY"`
 }
 
 type PSQL struct {
@@ -30,14 +60,32 @@ type PSQL struct {
 	PqSSL      string `env:"PSQL_SSL"`
 }
 
+// Redis string settings may be set to "" to leave them empty
 type Redis struct {
-	Host     string `env:"REDIS_HOST"`
-	Password string `env:"REDIS_PASSWORD"`
-	Database int    `env:"REDIS_DATABASE"`
+	// Host is a comma separated list of addresses: node, sentinels or cluster seeds
+	Host             string `env:"REDIS_HOST"`
+	Mode             string `env:"REDIS_MODE"`
+	MasterName       string `env:"REDIS_MASTER_NAME"`
+	Username         string `env:"REDIS_USERNAME"`
+	Password         string `env:"REDIS_PASSWORD"`
+	SentinelPassword string `env:"REDIS_SENTINEL_PASSWORD"`
+	Database         int    `env:"REDIS_DATABASE"`
+	TLS              bool   `env:"REDIS_TLS"`
+	TLSSkipVerify    bool   `env:"REDIS_TLS_SKIP_VERIFY"`
+	KeyPrefix        string `env:"REDIS_KEY_PREFIX"`
+
+	// zero values keep client defaults
+	PoolSize       int `env:"REDIS_POOL_SIZE"`
+	MinIdleConns   int `env:"REDIS_MIN_IDLE_CONNS"`
+	MaxRetries     int `env:"REDIS_MAX_RETRIES"`
+	DialTimeoutMs  int `env:"REDIS_DIAL_TIMEOUT_MS"`
+	ReadTimeoutMs  int `env:"REDIS_READ_TIMEOUT_MS"`
+	WriteTimeoutMs int `env:"REDIS_WRITE_TIMEOUT_MS"`
 }
 
 type OAuth struct {
 	GoogleAPI
+	OAuthProviders
 }
 
 type GoogleAPI struct {
@@ -47,7 +95,66 @@ type GoogleAPI struct {
 	ClientCallBackURI string `env:"OAUTH_CLIENT_CALL_BACK_URI"`
 }
 
+// OAuthProviders configures social providers besides google, the provider is enabled when
+// its client id is not "". {provider} in CallBackURI is replaced by the provider name
+type OAuthProviders struct {
+	CallBackURI string `env:"OAUTH_PROVIDERS_CALL_BACK_URI"`
+
+	GitHubClientID     string `env:"OAUTH_GITHUB_CLIENT_ID"`
+	GitHubClientSecret string `env:"OAUTH_GITHUB_CLIENT_SECRET"`
+
+	// issuers of OIDC providers, endpoints are taken from their discovery documents
+	GitLabIssuer       string `env:"OAUTH_GITLAB_ISSUER"`
+	GitLabClientID     string `env:"OAUTH_GITLAB_CLIENT_ID"`
+	GitLabClientSecret string `env:"OAUTH_GITLAB_CLIENT_SECRET"`
+
+	MicrosoftIssuer       string `env:"OAUTH_MICROSOFT_ISSUER"`
+	MicrosoftClientID     string `env:"OAUTH_MICROSOFT_CLIENT_ID"`
+	MicrosoftClientSecret string `env:"OAUTH_MICROSOFT_CLIENT_SECRET"`
+
+	YandexClientID     string `env:"OAUTH_YANDEX_CLIENT_ID"`
+	YandexClientSecret string `env:"OAUTH_YANDEX_CLIENT_SECRET"`
+}
+
 type RabbitMQ struct {
-	ServerURL  string `env:"RABBITMQ_SERVER_URL"`
-	MailsQueue string `env:"RABBITMQ_MAILS_QUEUE"`
+	ServerURL      string `env:"RABBITMQ_SERVER_URL"`
+	MailsQueue     string `env:"RABBITMQ_MAILS_QUEUE"`
+	SmsQueue       string `env:"RABBITMQ_SMS_QUEUE"`
+	EventsExchange string `env:"RABBITMQ_EVENTS_EXCHANGE"`
+	CommandsQueue  string `env:"RABBITMQ_COMMANDS_QUEUE"`
+}
+
+type NATS struct {
+	ServerURL string `env:"NATS_SERVER_URL"`
+}
+
+type GeoIP struct {
+	DatabasePath string `env:"GEOIP_DATABASE_PATH"`
+}
+
+type Privacy struct {
+	DeletionGraceHours int `env:"PRIVACY_DELETION_GRACE_HOURS"`
+	ExportTTLHours     int `env:"PRIVACY_EXPORT_TTL_HOURS"`
+}
+
+// Validate rejects periods that would erase users right after the deletion request
+// or expire exports before they are downloaded
+func (p Privacy) Validate() error {
+	if p.DeletionGraceHours <= 0 {
+		return errors.New("PRIVACY_DELETION_GRACE_HOURS must be positive")
+	}
+	if p.ExportTTLHours <= 0 {
+		return errors.New("PRIVACY_EXPORT_TTL_HOURS must be positive")
+	}
+	return nil
+}
+
+// DeletionGracePeriod is a time between the deletion request and erasure of the user
+func (p Privacy) DeletionGracePeriod() time.Duration {
+	return time.Duration(p.DeletionGraceHours) * time.Hour
+}
+
+// ExportTTL is a time the ready data export could be downloaded
+func (p Privacy) ExportTTL() time.Duration {
+	return time.Duration(p.ExportTTLHours) * time.Hour
 }
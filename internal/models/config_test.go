@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPrivacy_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		privacy Privacy
+		isValid bool
+	}{
+		{name: "valid", privacy: Privacy{DeletionGraceHours: 720, ExportTTLHours: 72}, isValid: true},
+		{name: "zero grace period", privacy: Privacy{DeletionGraceHours: 0, ExportTTLHours: 72}},
+		{name: "negative grace period", privacy: Privacy{DeletionGraceHours: -1, ExportTTLHours: 72}},
+		{name: "zero export ttl", privacy: Privacy{DeletionGraceHours: 720, ExportTTLHours: 0}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.privacy.Validate()
+			if testCase.isValid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// MailsRetryDelays are TTLs of the mails delayed retry queues, n-th retry waits n-th delay
+var MailsRetryDelays = []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute}
+
+// SmsRetryDelays are TTLs of the sms delayed retry queues, codes expire soon, so retries are short
+var SmsRetryDelays = []time.Duration{10 * time.Second, 30 * time.Second, 2 * time.Minute}
+
 // App constants
 const (
 	EnvProd  = "prod"
@@ -12,20 +18,123 @@ const (
 
 	RefreshTokenTTL      = 30 * 24 * time.Hour
 	AccessTokenTTL       = 30 * time.Minute
-	OAuthCodeTTl         = time.Minute
+	OAuthCodeTTl         = OAuthAwaitTime // states live as long as the user is awaited on the provider page
+	OAuthResultTTL       = time.Minute
 	VerificationCodesTTL = 5 * time.Minute
+	ContactChangeTTL     = 15 * time.Minute
+	ReauthAttemptsWindow = 15 * time.Minute
 
 	AuthHashCost = 10
 
-	GoogleAwaitTime        = 5 * time.Minute
+	RevokeTokenTTL = 7 * 24 * time.Hour
+
+	RabbitMQChannelPoolSize = 8
+	RabbitMQConfirmTimeout  = 5 * time.Second
+	RabbitMQAwaitTimeout    = 10 * time.Second
+	RabbitMQAwaitInterval   = 100 * time.Millisecond
+	RabbitMQMinBackoff      = time.Second
+	RabbitMQMaxBackoff      = 30 * time.Second
+	RabbitMQPrefetchCount   = 10
+	QueueHandleTimeout      = 30 * time.Second
+
+	QueueDriverRabbitMQ = "rabbitmq"
+	QueueDriverNATS     = "nats"
+	QueueDriverMemory   = "memory"
+
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+	RedisScanCount    = 500
+
+	CacheDriverRedis  = "redis"
+	CacheDriverMemory = "memory"
+
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverMemory   = "memory"
+
+	MemoryCacheSweepInterval = time.Minute
+	MemoryQueueSize          = 1000
+	MemoryQueueRecordsLimit  = 10000
+
+	NATSReconnectWait    = 2 * time.Second
+	NATSDuplicatesWindow = 10 * time.Minute
+	NATSTopicMaxAge      = 7 * 24 * time.Hour
+
+	QueueHeaderAttempt     = "x-attempt"
+	QueueHeaderMaxAttempts = "x-max-attempts"
+	MailsMaxAttempts       = 5
+	SmsMaxAttempts         = 4
+	DeadLettersScanLimit   = 10000
+
+	OutboxRelayInterval = time.Second
+	OutboxBatchSize     = 100
+	OutboxMaxAttempts   = 20
+	OutboxClaimLease    = 5 * time.Minute
+	OutboxMinBackoff    = time.Second
+	OutboxMaxBackoff    = 10 * time.Minute
+	OutboxRetention     = 7 * 24 * time.Hour
+
+	ErasureInterval  = time.Minute
+	ErasureBatchSize = 100
+
+	ExportInterval      = 10 * time.Second
+	ExportBatchSize     = 10
+	ExportSessionsLimit = 1000
+	ExportEventsLimit   = 10000
+
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+
+	OAuthAwaitTime         = 5 * time.Minute
 	GoogleScopeEmail       = "https://www.googleapis.com/auth/userinfo.email"
 	GoogleScopeUserProfile = "https://www.googleapis.com/auth/userinfo.profile"
+	GoogleScopeOpenID      = "openid"
+	OIDCTenantPlaceholder  = "{tenantid}"
 
 	CacheSessionsPrefix    = "ses"
-	CacheGoogleOAuthPrefix = "oauth2"
+	CacheOAuthPrefix       = "oauth2"
 	CacheVerifyCodePrefix  = "verify"
+	CacheContactPrefix     = "contact"
+	CacheReauthPrefix      = "reauth"
+	CacheOAuthResultPrefix = "oauth_result"
+
+	CtxKeyClientIP   = "client_ip"
+	CtxKeyUserAgent  = "user_agent"
+	CtxKeyDeviceName = "device_name"
+	CtxKeyLocale     = "locale"
+	CtxKeyTenantID   = "tenant_id"
+
+	DefaultLocale = "en"
+
+	DefaultTenantCode      = "default"
+	TenantsRefreshInterval = time.Minute
+	QueryTenant            = "tenant"
+
+	HeaderDeviceName   = "X-Device-Name"
+	HeaderAdminToken   = "X-Admin-Token"
+	HeaderTenant       = "X-Tenant-ID"
+	MaxUserAgentLength = 512
+	MaxDeviceNameLen   = 128
+	MaxDescriptionLen  = 512
+	MaxEmailLength     = 255
+	MaxPhoneLength     = 32
+	MaxNameLength      = 100
+
+	ContactChangeMaxAttempts    = 5
+	VerificationCodeMaxAttempts = 5
+	ReauthMaxAttempts           = 5
+
+	RoleAdmin             = "admin"
+	PermissionUsersRead   = "users:read"
+	PermissionUsersManage = "users:manage"
+	PermissionRolesManage = "roles:manage"
+	PermissionAuditRead   = "audit:read"
+	PermissionMailsManage = "mails:manage"
 
 	DateFormat        = "2006-01-02"
 	EmailRegexp       = `^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`
+	LocaleRegexp      = `^[a-z]{2,3}(-[a-z0-9]{2,8})*$`
+	RoleNameRegexp    = `^[a-z][a-z0-9_\-]{1,63}$`
+	PermissionRegexp  = `^[a-z][a-z0-9_\-]*(:[a-z][a-z0-9_\-]*)+$`
 	PhoneNumberRegexp = `^((8|\+7)[\- ]?)?(\(?\d{3}\)?[\- ]?)?[\d\- ]{7,10}$`
 )
@@ -0,0 +1,31 @@
+package models
+
+import "strings"
+
+// UserDevice is a pair of ip and user agent from which user has ever signed in
+type UserDevice struct {
+	ID          int64  `json:"-" db:"device_id"`
+	UserIDRef   int64  `json:"-" db:"user_idref"`
+	IP          string `json:"ip" db:"device_ip"`
+	UserAgent   string `json:"user_agent" db:"user_agent"`
+	FirstSeenAt int64  `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt  int64  `json:"last_seen_at" db:"last_seen_at"`
+}
+
+type Location struct {
+	City        string `json:"city"`
+	Country     string `json:"country"`
+	CountryCode string `json:"country_code"`
+}
+
+// String returns human-readable location, or empty string if it is unknown
+func (l *Location) String() string {
+	var parts []string
+	if l.City != "" {
+		parts = append(parts, l.City)
+	}
+	if l.Country != "" {
+		parts = append(parts, l.Country)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -22,22 +22,50 @@ var (
 // custom errors for special cases
 var (
 	ErrInvalidOAuthState  = errs.NewHttp(http.StatusBadRequest, "invalid oauth state")
+	ErrStateNotBound      = errs.NewHttp(http.StatusBadRequest, "oauth state was issued to another browser")
+	ErrOAuthCodeNotFound  = errs.NewHttp(http.StatusUnauthorized, "oauth code is expired or already used")
 	ErrInvalidRequest     = errs.NewHttp(http.StatusBadRequest, "invalid request params")
 	ErrInvalidEmail       = errs.NewHttp(http.StatusBadRequest, "invalid email")
 	ErrInvalidPassword    = errs.NewHttp(http.StatusBadRequest, "invalid password format")
 	ErrInvalidPhoneNumber = errs.NewHttp(http.StatusBadRequest, "invalid phone number")
+	ErrUnknownPermission  = errs.NewHttp(http.StatusBadRequest, "unknown permission")
+	ErrInvalidCode        = errs.NewHttp(http.StatusBadRequest, "invalid verification code")
+	ErrReauthRequired     = errs.NewHttp(http.StatusBadRequest, "password or verification code is required")
+	ErrEmailRequired      = errs.NewHttp(http.StatusBadRequest, "email is required, share it with the provider")
 
 	ErrInvalidToken      = errs.NewHttp(http.StatusUnauthorized, "invalid token")
 	ErrIncorrectPassword = errs.NewHttp(http.StatusUnauthorized, "incorrect password")
+	ErrInvalidIDToken    = errs.NewHttp(http.StatusUnauthorized, "invalid id token")
 
-	ErrStateNotFound   = errs.NewHttp(http.StatusNotFound, "state not found")
-	ErrSessionNotFound = errs.NewHttp(http.StatusNotFound, "session not found")
-	ErrUserNotFound    = errs.NewHttp(http.StatusNotFound, "user not found")
+	ErrStateNotFound    = errs.NewHttp(http.StatusNotFound, "state not found")
+	ErrSessionNotFound  = errs.NewHttp(http.StatusNotFound, "session not found")
+	ErrUserNotFound     = errs.NewHttp(http.StatusNotFound, "user not found")
+	ErrTenantNotFound   = errs.NewHttp(http.StatusNotFound, "tenant not found")
+	ErrRoleNotFound     = errs.NewHttp(http.StatusNotFound, "role not found")
+	ErrExportNotFound   = errs.NewHttp(http.StatusNotFound, "export not found")
+	ErrChangeNotFound   = errs.NewHttp(http.StatusNotFound, "contact change not found")
+	ErrIdentityNotFound = errs.NewHttp(http.StatusNotFound, "identity not found")
+
+	ErrUserDisabled     = errs.NewHttp(http.StatusForbidden, "user is disabled")
+	ErrSignUpDisabled   = errs.NewHttp(http.StatusForbidden, "sign up is disabled")
+	ErrAccessDenied     = errs.NewHttp(http.StatusForbidden, "access denied")
+	ErrGoogleDisabled   = errs.NewHttp(http.StatusForbidden, "google sign in is disabled")
+	ErrProviderDisabled = errs.NewHttp(http.StatusForbidden, "sign in with the provider is disabled")
 
 	ErrUserMustAuthWGoogle = errs.NewHttp(http.StatusConflict, "user must proceed with google")
 	ErrUserAlreadyExist    = errs.NewHttp(http.StatusConflict, "user already exist")
+	ErrUserAlreadyVerified = errs.NewHttp(http.StatusConflict, "user is already verified")
 	ErrSessionExpired      = errs.NewHttp(http.StatusConflict, "session is expired")
 	ErrStateCollision      = errs.NewHttp(http.StatusConflict, "such oauth state already exist")
+	ErrExportInProgress    = errs.NewHttp(http.StatusConflict, "data export is already in progress")
+	ErrIdentityLinked      = errs.NewHttp(http.StatusConflict, "identity is linked to another user")
+	ErrProviderLinked      = errs.NewHttp(http.StatusConflict, "provider is already linked")
+	ErrIdentityNotLinked   = errs.NewHttp(http.StatusConflict, "user with this email exists, sign in and link the provider")
+	ErrLastLoginMethod     = errs.NewHttp(http.StatusConflict, "can not remove the last login method")
+
+	ErrTooManyAttempts = errs.NewHttp(http.StatusTooManyRequests, "too many attempts, try again later")
+
+	ErrQueueUnsupported = errs.NewHttp(http.StatusNotImplemented, "not supported by the queue driver")
 
 	ErrInvalidOAuthProvider = errs.New("invalid oauth provider")
 )
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventUserCreated    EventType = "user.created"
+	EventUserVerified   EventType = "user.verified"
+	EventUserDeleted    EventType = "user.deleted"
+	EventUserUpdated    EventType = "user.updated"
+	EventUserDisabled   EventType = "user.disabled"
+	EventUserEnabled    EventType = "user.enabled"
+	EventSessionStarted EventType = "session.started"
+	EventSessionEnded   EventType = "session.ended"
+)
+
+// EventVersion is a version of the envelope and payloads schema,
+// must be increased on every breaking change
+const EventVersion = 1
+
+// Event is an envelope of the domain event published to other services,
+// event type is used as a routing key
+type Event struct {
+	ID         string          `json:"id"`
+	Type       EventType       `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+func NewEvent(eventType EventType, payload interface{}) (*Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Version:    EventVersion,
+		OccurredAt: time.Now().UTC(),
+		Payload:    raw,
+	}, nil
+}
+
+type UserEventPayload struct {
+	UserID        string        `json:"user_id"`
+	Email         string        `json:"email"`
+	OAuthProvider OAuthProvider `json:"oauth_provider,omitempty"`
+}
+
+// UserDeletedPayload carries only the id, the user is already erased when it is published
+type UserDeletedPayload struct {
+	UserID string `json:"user_id"`
+}
+
+type SessionEventPayload struct {
+	UserID     string `json:"user_id"`
+	SessionID  int64  `json:"session_id"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	DeviceName string `json:"device_name,omitempty"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatZIP  ExportFormat = "zip"
+)
+
+func (f ExportFormat) IsValid() bool {
+	return f == ExportFormatJSON || f == ExportFormatZIP
+}
+
+type ExportStatus string
+
+const (
+	ExportStatusPending ExportStatus = "pending"
+	ExportStatusReady   ExportStatus = "ready"
+	ExportStatusFailed  ExportStatus = "failed"
+)
+
+// DataExport is a job collecting personal data of the user, content is kept until expires_at
+type DataExport struct {
+	ID        string       `db:"export_id"`
+	UserIDRef int64        `db:"user_idref"`
+	Format    ExportFormat `db:"format"`
+	Status    ExportStatus `db:"status"`
+	Locale    string       `db:"locale"`
+	Content   []byte       `db:"content"`
+	LastError string       `db:"last_error"`
+	CreatedAt time.Time    `db:"created_at"`
+	ReadyAt   *time.Time   `db:"ready_at"`
+	ExpiresAt *time.Time   `db:"expires_at"`
+}
+
+// IsDownloadable ...
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == ExportStatusReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// FileName ...
+func (e *DataExport) FileName() string {
+	return fmt.Sprintf("personal-data-%s.%s", e.ID, e.Format)
+}
+
+// ContentType ...
+func (e *DataExport) ContentType() string {
+	if e.Format == ExportFormatZIP {
+		return "application/zip"
+	}
+	return "application/json"
+}
+
+// ToDataExportRes ...
+func (e *DataExport) ToDataExportRes() *DataExportRes {
+	return &DataExportRes{
+		ID:        e.ID,
+		Format:    e.Format,
+		Status:    e.Status,
+		CreatedAt: e.CreatedAt,
+		ExpiresAt: e.ExpiresAt,
+	}
+}
+
+type DataExportRes struct {
+	ID          string       `json:"id"`
+	Format      ExportFormat `json:"format"`
+	Status      ExportStatus `json:"status"`
+	DownloadURL string       `json:"download_url,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   *time.Time   `json:"expires_at"`
+}
+
+// DataExportBundle is everything the service stores about the user
+type DataExportBundle struct {
+	ExportedAt      time.Time        `json:"exported_at"`
+	User            AdminUser        `json:"user"`
+	LinkedProviders []LinkedIdentity `json:"linked_providers"`
+	Roles           []string         `json:"roles"`
+	Sessions        []AdminSession   `json:"sessions"`
+	Devices         []UserDevice     `json:"devices"`
+	AuthEvents      []AuthEvent      `json:"auth_events"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// OAuthState is stored by the id of the state until the provider calls back, the return path
+// of the client is sealed in the state itself. LinkUserID is set when the signed in user links
+// the provider to the account. Verifier is the PKCE code verifier and Nonce is expected in the
+// id token, both are never sent to the client
+type OAuthState struct {
+	CreatedAt  int64  `json:"created_at"`
+	LinkUserID int64  `json:"link_user_id,omitempty"`
+	Verifier   string `json:"verifier,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+}
+
+// UserIdentity is an account of the user at the external provider
+type UserIdentity struct {
+	ID            int64         `db:"identity_id"`
+	UserIDRef     int64         `db:"user_idref"`
+	TenantID      int64         `db:"tenant_id"`
+	Provider      OAuthProvider `db:"provider"`
+	Subject       string        `db:"subject"`
+	Email         string        `db:"email"`
+	EmailVerified bool          `db:"email_verified"`
+	CreatedAt     time.Time     `db:"created_at"`
+	LastUsedAt    time.Time     `db:"last_used_at"`
+}
+
+// ToLinkedIdentity ...
+func (i *UserIdentity) ToLinkedIdentity() LinkedIdentity {
+	return LinkedIdentity{
+		Provider:   i.Provider,
+		Email:      i.Email,
+		LinkedAt:   i.CreatedAt,
+		LastUsedAt: i.LastUsedAt,
+	}
+}
+
+// LinkedIdentity is an identity as shown to the user, subject is internal to the provider
+type LinkedIdentity struct {
+	Provider   OAuthProvider `json:"provider"`
+	Email      string        `json:"email"`
+	LinkedAt   time.Time     `json:"linked_at"`
+	LastUsedAt time.Time     `json:"last_used_at"`
+}
+
+// ExternalProfile is the user as the provider describes them
+type ExternalProfile struct {
+	Provider      OAuthProvider
+	Subject       string
+	Email         string
+	EmailVerified bool
+	DisplayName   string
+	FirstName     string
+	LastName      string
+	PictureURL    string
+	Locale        string
+}
+
+// ToIdentity ...
+func (p *ExternalProfile) ToIdentity() *UserIdentity {
+	return &UserIdentity{
+		Provider:      p.Provider,
+		Subject:       p.Subject,
+		Email:         p.Email,
+		EmailVerified: p.EmailVerified,
+	}
+}
+
+// ToUserDTO ...
+func (p *ExternalProfile) ToUserDTO() *UserDTO {
+	return &UserDTO{
+		Email:         p.Email,
+		Activated:     p.EmailVerified,
+		OAuthProvider: p.Provider,
+		FirstName:     p.FirstName,
+		LastName:      p.LastName,
+		Locale:        p.Locale,
+		DisplayName:   p.DisplayName,
+		PictureURL:    p.PictureURL,
+	}
+}
+
+// ApplyTo refreshes the provider managed fields of the user, names and locale are
+// only filled when empty since the user may have changed them
+func (p *ExternalProfile) ApplyTo(user *User) (changed bool) {
+	set := func(target *string, value string, overwrite bool) {
+		if value != "" && *target != value && (overwrite || *target == "") {
+			*target = value
+			changed = true
+		}
+	}
+	set(&user.DisplayName, p.DisplayName, true)
+	set(&user.PictureURL, p.PictureURL, true)
+	set(&user.FirstName, p.FirstName, false)
+	set(&user.LastName, p.LastName, false)
+	set(&user.Locale, p.Locale, false)
+	return changed
+}
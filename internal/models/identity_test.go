@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExternalProfile_ApplyTo(t *testing.T) {
+	profile := &ExternalProfile{
+		DisplayName: "Ann Lee",
+		PictureURL:  "https://example.com/ann.png",
+		FirstName:   "Ann",
+		LastName:    "Lee",
+		Locale:      "en",
+	}
+
+	testCases := []struct {
+		name     string
+		user     User
+		expected User
+		changed  bool
+	}{
+		{
+			name: "empty user is filled",
+			user: User{},
+			expected: User{DisplayName: "Ann Lee", PictureURL: "https://example.com/ann.png",
+				FirstName: "Ann", LastName: "Lee", Locale: "en"},
+			changed: true,
+		},
+		{
+			name: "provider fields are overwritten, names and locale are kept",
+			user: User{DisplayName: "Old", PictureURL: "https://example.com/old.png",
+				FirstName: "Anna", LastName: "Smith", Locale: "ru"},
+			expected: User{DisplayName: "Ann Lee", PictureURL: "https://example.com/ann.png",
+				FirstName: "Anna", LastName: "Smith", Locale: "ru"},
+			changed: true,
+		},
+		{
+			name: "same values",
+			user: User{DisplayName: "Ann Lee", PictureURL: "https://example.com/ann.png",
+				FirstName: "Anna", LastName: "Lee", Locale: "ru"},
+			expected: User{DisplayName: "Ann Lee", PictureURL: "https://example.com/ann.png",
+				FirstName: "Anna", LastName: "Lee", Locale: "ru"},
+			changed: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			user := testCase.user
+			require.Equal(t, testCase.changed, profile.ApplyTo(&user))
+			require.Equal(t, testCase.expected, user)
+		})
+	}
+
+	// empty values of the provider never clear the user
+	user := User{DisplayName: "Ann Lee", FirstName: "Ann"}
+	require.False(t, (&ExternalProfile{}).ApplyTo(&user))
+	require.Equal(t, User{DisplayName: "Ann Lee", FirstName: "Ann"}, user)
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+// MailCommandVersion is a version of the mail command format and template variables,
+// must be increased on every breaking change. Schema: api/schemas/mail_command.v1.json
+const MailCommandVersion = 1
+
+var ErrInvalidMailCommand = errs.New("invalid mail command")
+
+type MailTemplate string
+
+const (
+	MailTemplateVerificationCode MailTemplate = "verification_code"
+	MailTemplateNewDeviceSignIn  MailTemplate = "new_device_sign_in"
+	MailTemplateDataExportReady  MailTemplate = "data_export_ready"
+	MailTemplateEmailChangeCode  MailTemplate = "email_change_code"
+	MailTemplateEmailChangeReq   MailTemplate = "email_change_requested"
+)
+
+type MailVariableType string
+
+const (
+	MailVarString   MailVariableType = "string"
+	MailVarNumber   MailVariableType = "number"
+	MailVarDateTime MailVariableType = "datetime"
+	MailVarURL      MailVariableType = "url"
+)
+
+// mailTemplateVariables lists variables of every template, all of them are required
+var mailTemplateVariables = map[MailTemplate]map[string]MailVariableType{
+	MailTemplateVerificationCode: {
+		"code":               MailVarString,
+		"expires_in_minutes": MailVarNumber,
+	},
+	MailTemplateNewDeviceSignIn: {
+		"device":       MailVarString,
+		"ip":           MailVarString,
+		"location":     MailVarString,
+		"signed_in_at": MailVarDateTime,
+		"revoke_url":   MailVarURL,
+	},
+	MailTemplateDataExportReady: {
+		"download_url": MailVarURL,
+		"expires_at":   MailVarDateTime,
+	},
+	MailTemplateEmailChangeCode: {
+		"code":               MailVarString,
+		"expires_in_minutes": MailVarNumber,
+	},
+	MailTemplateEmailChangeReq: {
+		"ip":           MailVarString,
+		"requested_at": MailVarDateTime,
+	},
+}
+
+func (t MailTemplate) IsValid() bool {
+	_, ok := mailTemplateVariables[t]
+	return ok
+}
+
+// MailVariable is a template variable, datetime is formatted as RFC 3339 in UTC
+type MailVariable struct {
+	Type  MailVariableType `json:"type"`
+	Value interface{}      `json:"value"`
+}
+
+type MailVariables map[string]MailVariable
+
+func StringVar(value string) MailVariable {
+	return MailVariable{Type: MailVarString, Value: value}
+}
+
+func NumberVar(value float64) MailVariable {
+	return MailVariable{Type: MailVarNumber, Value: value}
+}
+
+func DateTimeVar(value time.Time) MailVariable {
+	return MailVariable{Type: MailVarDateTime, Value: value.UTC().Format(time.RFC3339)}
+}
+
+func URLVar(value string) MailVariable {
+	return MailVariable{Type: MailVarURL, Value: value}
+}
+
+type MailRecipient struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// MailCommand asks the mail service to render the template in the locale and send it to the recipient
+type MailCommand struct {
+	ID         string        `json:"id"`
+	Version    int           `json:"version"`
+	TemplateID MailTemplate  `json:"template_id"`
+	Locale     string        `json:"locale"`
+	Recipient  MailRecipient `json:"recipient"`
+	Variables  MailVariables `json:"variables"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func NewMailCommand(
+	templateID MailTemplate,
+	locale string,
+	recipient MailRecipient,
+	variables MailVariables) *MailCommand {
+	return &MailCommand{
+		Version:    MailCommandVersion,
+		TemplateID: templateID,
+		Locale:     locale,
+		Recipient:  recipient,
+		Variables:  variables,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// Validate checks the command against its template variables
+func (c *MailCommand) Validate() error {
+	if c.Version != MailCommandVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidMailCommand, c.Version)
+	}
+	if c.Recipient.Email == "" || c.Locale == "" {
+		return fmt.Errorf("%w: empty recipient or locale", ErrInvalidMailCommand)
+	}
+
+	variables, ok := mailTemplateVariables[c.TemplateID]
+	if !ok {
+		return fmt.Errorf("%w: unknown template %q", ErrInvalidMailCommand, c.TemplateID)
+	}
+	if err := c.Variables.validate(variables); err != nil {
+		return fmt.Errorf("%w: template %q: %s", ErrInvalidMailCommand, c.TemplateID, err)
+	}
+	return nil
+}
+
+// validate checks that there are exactly the expected variables of the expected types
+func (v MailVariables) validate(expected map[string]MailVariableType) error {
+	if len(v) != len(expected) {
+		return fmt.Errorf("expects %d variables", len(expected))
+	}
+	for name, varType := range expected {
+		variable, ok := v[name]
+		if !ok || variable.Type != varType || !variable.hasValidValue() {
+			return fmt.Errorf("variable %q must be of type %s", name, varType)
+		}
+	}
+	return nil
+}
+
+func (v MailVariable) hasValidValue() bool {
+	switch value := v.Value.(type) {
+	case float64, int, int64:
+		return v.Type == MailVarNumber
+	case string:
+		if v.Type == MailVarDateTime {
+			_, err := time.Parse(time.RFC3339, value)
+			return err == nil
+		}
+		return v.Type == MailVarString || v.Type == MailVarURL
+	}
+	return false
+}
+
+// DeadLetteredMail is a mail command that exhausted its delivery attempts
+type DeadLetteredMail struct {
+	ID         string       `json:"id"`
+	TemplateID MailTemplate `json:"template_id"`
+	SendTo     string       `json:"send_to"`
+	Attempts   int          `json:"attempts"`
+	Reason     string       `json:"reason"`
+}
+
+type DeadLetteredMailsRes struct {
+	Items []DeadLetteredMail `json:"items"`
+}
+
+type RequeueDeadLettersRes struct {
+	Requeued int `json:"requeued"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMailCommand_Validate(t *testing.T) {
+	recipient := MailRecipient{Email: "user@example.com"}
+	codeVars := func() MailVariables {
+		return MailVariables{
+			"code":               StringVar("123456"),
+			"expires_in_minutes": NumberVar(5),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		command *MailCommand
+		valid   bool
+	}{
+		{
+			name:    "valid",
+			command: NewMailCommand(MailTemplateVerificationCode, "en", recipient, codeVars()),
+			valid:   true,
+		},
+		{
+			name: "valid datetime and url",
+			command: NewMailCommand(MailTemplateDataExportReady, "en", recipient, MailVariables{
+				"download_url": URLVar("https://example.com/export"),
+				"expires_at":   DateTimeVar(time.Now()),
+			}),
+			valid: true,
+		},
+		{
+			name: "unsupported version",
+			command: func() *MailCommand {
+				command := NewMailCommand(MailTemplateVerificationCode, "en", recipient, codeVars())
+				command.Version = MailCommandVersion + 1
+				return command
+			}(),
+		},
+		{
+			name:    "empty recipient",
+			command: NewMailCommand(MailTemplateVerificationCode, "en", MailRecipient{}, codeVars()),
+		},
+		{
+			name:    "empty locale",
+			command: NewMailCommand(MailTemplateVerificationCode, "", recipient, codeVars()),
+		},
+		{
+			name:    "unknown template",
+			command: NewMailCommand("unknown", "en", recipient, codeVars()),
+		},
+		{
+			name: "missing variable",
+			command: NewMailCommand(MailTemplateVerificationCode, "en", recipient, MailVariables{
+				"code": StringVar("123456"),
+			}),
+		},
+		{
+			name: "unexpected variable",
+			command: NewMailCommand(MailTemplateVerificationCode, "en", recipient, MailVariables{
+				"code": StringVar("123456"),
+				"url":  URLVar("https://example.com"),
+			}),
+		},
+		{
+			name: "wrong variable type",
+			command: NewMailCommand(MailTemplateVerificationCode, "en", recipient, MailVariables{
+				"code":               NumberVar(123456),
+				"expires_in_minutes": NumberVar(5),
+			}),
+		},
+		{
+			name: "invalid datetime",
+			command: NewMailCommand(MailTemplateDataExportReady, "en", recipient, MailVariables{
+				"download_url": URLVar("https://example.com/export"),
+				"expires_at":   {Type: MailVarDateTime, Value: "tomorrow"},
+			}),
+		},
+	}
+	for _, test := range tests {
+		err := test.command.Validate()
+		if test.valid {
+			require.NoError(t, err, test.name)
+			continue
+		}
+		require.True(t, errors.Is(err, ErrInvalidMailCommand), test.name)
+	}
+}
+
+func TestUser_MailRecipient(t *testing.T) {
+	user := &User{Email: "user@example.com"}
+	require.Equal(t, MailRecipient{Email: "new@example.com"}, user.MailRecipient("new@example.com"))
+
+	user.FirstName, user.LastName = "Aigerim", "Sadykova"
+	require.Equal(t, MailRecipient{Email: user.Email, Name: "Aigerim Sadykova"}, user.MailRecipient(user.Email))
+
+	user.DisplayName = "aigerim"
+	require.Equal(t, MailRecipient{Email: user.Email, Name: "aigerim"}, user.MailRecipient(user.Email))
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type OutboxTopic string
+
+const (
+	OutboxTopicMails  OutboxTopic = "mails"
+	OutboxTopicEvents OutboxTopic = "events"
+	OutboxTopicSms    OutboxTopic = "sms"
+)
+
+// OutboxMessage is a message stored in the same transaction with the domain change
+// and published to the queue later by the relay. MessageID is used for deduplication
+type OutboxMessage struct {
+	ID            int64       `db:"outbox_id"`
+	MessageID     string      `db:"message_id"`
+	Topic         OutboxTopic `db:"topic"`
+	Payload       []byte      `db:"payload"`
+	UserIDRef     *int64      `db:"user_idref"`
+	Attempts      int         `db:"attempts"`
+	LastError     string      `db:"last_error"`
+	CreatedAt     time.Time   `db:"created_at"`
+	NextAttemptAt time.Time   `db:"next_attempt_at"`
+	PublishedAt   *time.Time  `db:"published_at"`
+	DeadAt        *time.Time  `db:"dead_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+type ContactKind string
+
+const (
+	ContactEmail ContactKind = "email"
+	ContactPhone ContactKind = "phone"
+)
+
+func (k ContactKind) IsValid() bool {
+	return k == ContactEmail || k == ContactPhone
+}
+
+// ContactChange is a new email or phone waiting to be confirmed by the code sent to it
+type ContactChange struct {
+	Kind  ContactKind `json:"kind"`
+	Value string      `json:"value"`
+	Code  string      `json:"code"`
+}
+
+type ProfileUpdateRes struct {
+	User UserDTO `json:"user"`
+	// Pending contacts are changed after confirmation by POST /v1/auth/user/me/confirm-contact
+	Pending []ContactKind `json:"pending"`
+}
@@ -1,6 +1,36 @@
 package models
 
-type MailsProducerMsg struct {
-	SendTo           string `json:"send_to"`
-	VerificationCode string `json:"verification_code"`
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidQueueMessage marks messages that could never be handled,
+// they are dead-lettered without retries
+var ErrInvalidQueueMessage = errors.New("invalid queue message")
+
+// ErrQueueUnavailable marks messages that were not published because the broker is unreachable,
+// they are retried without counting the attempt
+var ErrQueueUnavailable = errors.New("queue is unavailable")
+
+type QueueMessage struct {
+	ID          string
+	Body        []byte
+	Redelivered bool
+	// Attempts is a number of delayed retries made by consumers
+	Attempts int
+	// Reason is why message was dead-lettered: rejected, expired, ...
+	Reason string
+}
+
+// QueueHeaders are broker-neutral message headers, values must be strings or numbers
+type QueueHeaders map[string]interface{}
+
+// QueueTopology describes companions declared together with the queue:
+// dead-letter exchange "<queue>.dlx" bound to the queue "<queue>.dlq" and
+// delayed retry queues "<queue>.retry.<n>" that return messages to the queue
+// after n-th delay expires
+type QueueTopology struct {
+	DeadLetter  bool
+	RetryDelays []time.Duration
 }
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// Role is a named set of permissions assigned to users of the tenant
+type Role struct {
+	ID          int64          `json:"-" db:"role_id"`
+	TenantID    int64          `json:"-" db:"tenant_id"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	CreatedAt   *time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Permission is an action that downstream services check in access token claims,
+// named as "<resource>:<action>"
+type Permission struct {
+	ID          int64      `json:"-" db:"permission_id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	CreatedAt   *time.Time `json:"created_at" db:"created_at"`
+}
+
+type RolesRes struct {
+	Items []Role `json:"items"`
+}
+
+type PermissionsRes struct {
+	Items []Permission `json:"items"`
+}
+
+type UserRolesRes struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
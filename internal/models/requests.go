@@ -1,7 +1,10 @@
 package models
 
 import (
+	"auth-api/internal/models/consts"
 	"auth-api/internal/pkg/tools"
+	"time"
+	"unicode/utf8"
 )
 
 type SignInReq struct {
@@ -64,3 +67,321 @@ func (r VerifyEmailReq) Validate() error {
 	}
 	return nil
 }
+
+type PaginationReq struct {
+	Limit  uint64 `form:"limit"`
+	Offset uint64 `form:"offset"`
+}
+
+func (r PaginationReq) Validate() error {
+	if r.Limit > consts.MaxPageLimit {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// GetLimit returns requested limit or the default one
+func (r PaginationReq) GetLimit() uint64 {
+	if r.Limit == 0 {
+		return consts.DefaultPageLimit
+	}
+	return r.Limit
+}
+
+type AuthEventsReq struct {
+	PaginationReq
+	UserIDCode string          `form:"user_id"`
+	Actor      string          `form:"actor"`
+	Type       AuthEventType   `form:"type"`
+	Result     AuthEventResult `form:"result"`
+	IP         string          `form:"ip"`
+	From       time.Time       `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time       `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (r AuthEventsReq) ToFilter() *AuthEventsFilter {
+	filter := &AuthEventsFilter{
+		UserIDCode: r.UserIDCode,
+		Actor:      r.Actor,
+		Type:       r.Type,
+		Result:     r.Result,
+		IP:         r.IP,
+		Limit:      r.GetLimit(),
+		Offset:     r.Offset,
+	}
+	if !r.From.IsZero() {
+		filter.From = &r.From
+	}
+	if !r.To.IsZero() {
+		filter.To = &r.To
+	}
+	return filter
+}
+
+func (r AuthEventsReq) Validate() error {
+	if err := r.PaginationReq.Validate(); err != nil {
+		return err
+	}
+	if r.UserIDCode != "" && !tools.IsUUID(r.UserIDCode) {
+		return ErrInvalidRequest
+	}
+	if r.Type != "" && !r.Type.IsValid() {
+		return ErrInvalidRequest
+	}
+	if r.Result != "" && !r.Result.IsValid() {
+		return ErrInvalidRequest
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+type DeadLettersReq struct {
+	Limit int `form:"limit"`
+}
+
+func (r DeadLettersReq) Validate() error {
+	if r.Limit < 0 || r.Limit > consts.MaxPageLimit {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// GetLimit returns requested limit or the default one
+func (r DeadLettersReq) GetLimit() int {
+	if r.Limit == 0 {
+		return consts.DefaultPageLimit
+	}
+	return r.Limit
+}
+
+type RequeueDeadLettersReq struct {
+	IDs []string `json:"ids"`
+}
+
+func (r RequeueDeadLettersReq) Validate() error {
+	if len(r.IDs) == 0 || len(r.IDs) > consts.MaxPageLimit {
+		return ErrInvalidRequest
+	}
+	for _, id := range r.IDs {
+		if id == "" {
+			return ErrInvalidRequest
+		}
+	}
+	return nil
+}
+
+type SaveRoleReq struct {
+	Name        string   `json:"-"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r SaveRoleReq) Validate() error {
+	if !tools.IsValidRoleName(r.Name) || len(r.Description) > consts.MaxDescriptionLen {
+		return ErrInvalidRequest
+	}
+	if len(r.Permissions) > consts.MaxPageLimit {
+		return ErrInvalidRequest
+	}
+	for _, permission := range r.Permissions {
+		if !tools.IsValidPermission(permission) {
+			return ErrInvalidRequest
+		}
+	}
+	return nil
+}
+
+type SavePermissionReq struct {
+	Name        string `json:"-"`
+	Description string `json:"description"`
+}
+
+func (r SavePermissionReq) Validate() error {
+	if !tools.IsValidPermission(r.Name) || len(r.Description) > consts.MaxDescriptionLen {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+func (r SavePermissionReq) ToPermission() *Permission {
+	return &Permission{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+}
+
+type UsersReq struct {
+	PaginationReq
+	Email         string        `form:"email"`
+	PhoneNumber   string        `form:"phone"`
+	OAuthProvider OAuthProvider `form:"provider"`
+	Status        UserStatus    `form:"status"`
+}
+
+func (r UsersReq) Validate() error {
+	if err := r.PaginationReq.Validate(); err != nil {
+		return err
+	}
+	if len(r.Email) > consts.MaxEmailLength || len(r.PhoneNumber) > consts.MaxPhoneLength {
+		return ErrInvalidRequest
+	}
+	if r.OAuthProvider != "" && !r.OAuthProvider.IsValid() {
+		return ErrInvalidRequest
+	}
+	if r.Status != "" && !r.Status.IsValid() {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+func (r UsersReq) ToFilter() *UsersFilter {
+	return &UsersFilter{
+		Email:         r.Email,
+		PhoneNumber:   r.PhoneNumber,
+		OAuthProvider: r.OAuthProvider,
+		Status:        r.Status,
+		Limit:         r.GetLimit(),
+		Offset:        r.Offset,
+	}
+}
+
+type UserActionReq struct {
+	Reason string `json:"reason"`
+}
+
+func (r UserActionReq) Validate() error {
+	if len(r.Reason) > consts.MaxDescriptionLen {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+type ExportReq struct {
+	Format ExportFormat `json:"format"`
+}
+
+func (r ExportReq) Validate() error {
+	if r.Format != "" && !r.Format.IsValid() {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+func (r ExportReq) GetFormat() ExportFormat {
+	if r.Format == "" {
+		return ExportFormatJSON
+	}
+	return r.Format
+}
+
+// UpdateProfileReq changes only the given fields, email and phone are changed after confirmation
+type UpdateProfileReq struct {
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	Locale      *string `json:"locale"`
+	Email       *string `json:"email"`
+	PhoneNumber *string `json:"phone_number"`
+}
+
+func (r UpdateProfileReq) Validate() error {
+	if r.FirstName != nil && utf8.RuneCountInString(*r.FirstName) > consts.MaxNameLength {
+		return ErrInvalidRequest
+	}
+	if r.LastName != nil && utf8.RuneCountInString(*r.LastName) > consts.MaxNameLength {
+		return ErrInvalidRequest
+	}
+	if r.Locale != nil && *r.Locale != "" && !tools.IsValidLocale(*r.Locale) {
+		return ErrInvalidRequest
+	}
+	if r.Email != nil && !tools.IsValidEmail(*r.Email) {
+		return ErrInvalidEmail
+	}
+	if r.PhoneNumber != nil && !tools.IsValidPhoneNumber(*r.PhoneNumber) {
+		return ErrInvalidPhoneNumber
+	}
+	return nil
+}
+
+type ConfirmContactReq struct {
+	Kind ContactKind `json:"kind"`
+	Code string      `json:"code"`
+}
+
+func (r ConfirmContactReq) Validate() error {
+	if !r.Kind.IsValid() || r.Code == "" || !tools.IsValidVerificationCode(r.Code) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// ReauthReq confirms sensitive changes: the password, or the code sent by /v1/auth/user/send-verify-code
+// to the email of the user without password
+type ReauthReq struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (r ReauthReq) Validate() error {
+	if r.Password == "" && r.Code == "" {
+		return ErrReauthRequired
+	}
+	if r.Code != "" && !tools.IsValidVerificationCode(r.Code) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+type SetPasswordReq struct {
+	Password        string `json:"password"`
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+func (r SetPasswordReq) Validate() error {
+	if !tools.IsValidPassword(r.Password) {
+		return ErrInvalidPassword
+	}
+	return r.ToReauthReq().Validate()
+}
+
+func (r SetPasswordReq) ToReauthReq() *ReauthReq {
+	return &ReauthReq{Password: r.CurrentPassword, Code: r.Code}
+}
+
+// OAuthRedirectReq starts sign in with the provider, ReturnTo is a path of the client
+type OAuthRedirectReq struct {
+	ReturnTo string `form:"return_to"`
+}
+
+func (r OAuthRedirectReq) Validate() error {
+	if r.ReturnTo != "" && !tools.IsValidReturnTo(r.ReturnTo) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// RevokeSessionReq carries the token of the "this wasn't me" link, the confirming page posts it as a form
+type RevokeSessionReq struct {
+	Token string `form:"token"`
+}
+
+func (r RevokeSessionReq) Validate() error {
+	if r.Token == "" {
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// OAuthExchangeReq exchanges the code of the provider call back for the session
+type OAuthExchangeReq struct {
+	Code string `json:"code"`
+}
+
+func (r OAuthExchangeReq) Validate() error {
+	if !tools.IsUUID(r.Code) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
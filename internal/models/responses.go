@@ -1,10 +1,32 @@
 package models
 
+import "time"
+
 type AuthResponse struct {
 	UserDTO `json:"user"`
 	Tokens  *Tokens `json:"tokens"`
 }
 
-type GoogleRedirectRes struct {
+// OAuthRedirectRes is the url of the provider, the state is bound to the browser by the cookie
+type OAuthRedirectRes struct {
 	RedirectURL string `json:"redirect_url"`
+	State       string `json:"-"`
+}
+
+// OAuthCallBackRes is a result of the provider call back: a one-time code of the new session,
+// exchanged by the client for AuthResponse, or the provider linked to the account of the user
+// who started linking
+type OAuthCallBackRes struct {
+	Code     string
+	Linked   bool
+	ReturnTo string
+}
+
+type IdentitiesRes struct {
+	HasPassword bool             `json:"has_password"`
+	Identities  []LinkedIdentity `json:"identities"`
+}
+
+type DeletionRes struct {
+	EraseAt time.Time `json:"erase_at"`
 }
@@ -6,8 +6,23 @@ package models
 type Session struct {
 	ID           int64  `json:"session_id" db:"session_id"`
 	IP           string `json:"session_ip" db:"session_ip"`
+	UserAgent    string `json:"user_agent" db:"user_agent"`
+	DeviceName   string `json:"device_name" db:"device_name"`
 	UserIDRef    int64  `json:"user_idref" db:"user_idref"`
+	TenantID     int64  `json:"tenant_id" db:"tenant_id"`
 	RefreshToken string `json:"refresh_token" db:"refresh_token"`
 	StartedAt    *int64 `json:"started_at" db:"started_at"`
 	EndedAt      *int64 `json:"ended_at" db:"ended_at"`
 }
+
+// ToAdminSession ...
+func (s *Session) ToAdminSession() AdminSession {
+	return AdminSession{
+		ID:         s.ID,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		DeviceName: s.DeviceName,
+		StartedAt:  s.StartedAt,
+		EndedAt:    s.EndedAt,
+	}
+}
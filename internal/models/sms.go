@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+// SmsCommandVersion is a version of the sms command format and template variables,
+// must be increased on every breaking change. Schema: api/schemas/sms_command.v1.json
+const SmsCommandVersion = 1
+
+var ErrInvalidSmsCommand = errs.New("invalid sms command")
+
+type SmsTemplate string
+
+const (
+	SmsTemplatePhoneChangeCode      SmsTemplate = "phone_change_code"
+	SmsTemplatePhoneChangeRequested SmsTemplate = "phone_change_requested"
+)
+
+// smsTemplateVariables lists variables of every template, all of them are required
+var smsTemplateVariables = map[SmsTemplate]map[string]MailVariableType{
+	SmsTemplatePhoneChangeCode: {
+		"code":               MailVarString,
+		"expires_in_minutes": MailVarNumber,
+	},
+	SmsTemplatePhoneChangeRequested: {
+		"requested_at": MailVarDateTime,
+	},
+}
+
+type SmsRecipient struct {
+	PhoneNumber string `json:"phone_number"`
+}
+
+// SmsCommand asks the sms service to render the template in the locale and send it to the recipient,
+// variables have the same format as the mail ones
+type SmsCommand struct {
+	ID         string        `json:"id"`
+	Version    int           `json:"version"`
+	TemplateID SmsTemplate   `json:"template_id"`
+	Locale     string        `json:"locale"`
+	Recipient  SmsRecipient  `json:"recipient"`
+	Variables  MailVariables `json:"variables"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func NewSmsCommand(
+	templateID SmsTemplate,
+	locale string,
+	recipient SmsRecipient,
+	variables MailVariables) *SmsCommand {
+	return &SmsCommand{
+		Version:    SmsCommandVersion,
+		TemplateID: templateID,
+		Locale:     locale,
+		Recipient:  recipient,
+		Variables:  variables,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// Validate checks the command against its template variables
+func (c *SmsCommand) Validate() error {
+	if c.Version != SmsCommandVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSmsCommand, c.Version)
+	}
+	if c.Recipient.PhoneNumber == "" || c.Locale == "" {
+		return fmt.Errorf("%w: empty recipient or locale", ErrInvalidSmsCommand)
+	}
+
+	variables, ok := smsTemplateVariables[c.TemplateID]
+	if !ok {
+		return fmt.Errorf("%w: unknown template %q", ErrInvalidSmsCommand, c.TemplateID)
+	}
+	if err := c.Variables.validate(variables); err != nil {
+		return fmt.Errorf("%w: template %q: %s", ErrInvalidSmsCommand, c.TemplateID, err)
+	}
+	return nil
+}
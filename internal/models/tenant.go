@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// Tenant is a realm with its own users, signing keys, oauth client and policies.
+// Empty secrets and oauth settings are taken from the app config
+type Tenant struct {
+	ID                      int64          `db:"tenant_id"`
+	Code                    string         `db:"tenant_code"`
+	Name                    string         `db:"name"`
+	Hosts                   pq.StringArray `db:"hosts"`
+	AccessSecret            string         `db:"access_secret"`
+	RefreshSecret           string         `db:"refresh_secret"`
+	GoogleClientID          string         `db:"google_client_id"`
+	GoogleClientSecret      string         `db:"google_client_secret"`
+	GoogleServerCallBackURI string         `db:"google_server_callback_uri"`
+	GoogleClientCallBackURI string         `db:"google_client_callback_uri"`
+	AllowSignUp             bool           `db:"allow_sign_up"`
+	AllowGoogle             bool           `db:"allow_google"`
+	CreatedAt               *time.Time     `db:"created_at"`
+}
+
+// WithDefaults fills settings that are not overridden by the tenant from config
+func (t *Tenant) WithDefaults(config *Config) *Tenant {
+	t.AccessSecret = withDefault(t.AccessSecret, config.Token.AccessSecret)
+	t.RefreshSecret = withDefault(t.RefreshSecret, config.Token.RefreshSecret)
+	t.GoogleClientID = withDefault(t.GoogleClientID, config.OAuth.GoogleAPI.ClientID)
+	t.GoogleClientSecret = withDefault(t.GoogleClientSecret, config.OAuth.GoogleAPI.ClientSecret)
+	t.GoogleServerCallBackURI = withDefault(t.GoogleServerCallBackURI, config.OAuth.ServerCallBackURI)
+	t.GoogleClientCallBackURI = withDefault(t.GoogleClientCallBackURI, config.OAuth.ClientCallBackURI)
+	return t
+}
+
+func withDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -5,10 +5,22 @@ type (
 )
 
 var (
-	DefaultOAuth OAuthProvider = "default"
-	GoogleOAuth  OAuthProvider = "google"
+	DefaultOAuth   OAuthProvider = "default"
+	GoogleOAuth    OAuthProvider = "google"
+	GitHubOAuth    OAuthProvider = "github"
+	GitLabOAuth    OAuthProvider = "gitlab"
+	MicrosoftOAuth OAuthProvider = "microsoft"
+	YandexOAuth    OAuthProvider = "yandex"
+
+	// SocialProviders are providers users may sign in with
+	SocialProviders = []OAuthProvider{GoogleOAuth, GitHubOAuth, GitLabOAuth, MicrosoftOAuth, YandexOAuth}
 )
 
 func (p OAuthProvider) IsValid() bool {
-	return p == GoogleOAuth
+	for _, provider := range SocialProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
 }
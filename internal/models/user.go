@@ -1,19 +1,32 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // User ...
 type User struct {
-	ID            int64         `db:"user_id"`
-	IDCode        string        `db:"user_idcode"`
-	Email         string        `db:"email"`
-	PhoneNumber   string        `db:"phone_number"`
-	Activated     bool          `db:"activated"`
-	Password      string        `db:"password"`
-	OAuthProvider OAuthProvider `db:"oauth_provider"`
-	CreatedAt     *time.Time    `db:"created_at"`
-	UpdatedAt     *time.Time    `db:"updated_at"`
-	DeletedAt     *time.Time    `db:"deleted_at"`
+	ID             int64         `db:"user_id"`
+	IDCode         string        `db:"user_idcode"`
+	TenantID       int64         `db:"tenant_id"`
+	Email          string        `db:"email"`
+	PhoneNumber    string        `db:"phone_number"`
+	Activated      bool          `db:"activated"`
+	Password       string        `db:"password"`
+	OAuthProvider  OAuthProvider `db:"oauth_provider"`
+	FirstName      string        `db:"first_name"`
+	LastName       string        `db:"last_name"`
+	Locale         string        `db:"locale"`
+	DisplayName    string        `db:"display_name"`
+	PictureURL     string        `db:"picture_url"`
+	DisabledAt     *time.Time    `db:"disabled_at"`
+	EmailBouncedAt *time.Time    `db:"email_bounced_at"`
+	EraseAt        *time.Time    `db:"erase_at"`
+	ErasedAt       *time.Time    `db:"erased_at"`
+	CreatedAt      *time.Time    `db:"created_at"`
+	UpdatedAt      *time.Time    `db:"updated_at"`
+	DeletedAt      *time.Time    `db:"deleted_at"`
 }
 
 // ToUserDTO ...
@@ -24,12 +37,63 @@ func (u *User) ToUserDTO() UserDTO {
 		PhoneNumber: u.PhoneNumber,
 		Activated:   u.Activated,
 		Password:    u.Password,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		Locale:      u.Locale,
+		DisplayName: u.DisplayName,
+		PictureURL:  u.PictureURL,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
 		DeletedAt:   u.DeletedAt,
 	}
 }
 
+// Status ...
+func (u *User) Status() UserStatus {
+	switch {
+	case u.DeletedAt != nil:
+		return UserStatusDeleted
+	case u.DisabledAt != nil:
+		return UserStatusDisabled
+	case !u.Activated:
+		return UserStatusUnverified
+	default:
+		return UserStatusActive
+	}
+}
+
+// MailRecipient addresses the mail to the email by the display name or the full name of the user
+func (u *User) MailRecipient(email string) MailRecipient {
+	name := u.DisplayName
+	if name == "" {
+		name = strings.TrimSpace(u.FirstName + " " + u.LastName)
+	}
+	return MailRecipient{Email: email, Name: name}
+}
+
+// ToAdminUser ...
+func (u *User) ToAdminUser() AdminUser {
+	return AdminUser{
+		IDCode:         u.IDCode,
+		Email:          u.Email,
+		PhoneNumber:    u.PhoneNumber,
+		Activated:      u.Activated,
+		OAuthProvider:  u.OAuthProvider,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Locale:         u.Locale,
+		DisplayName:    u.DisplayName,
+		PictureURL:     u.PictureURL,
+		Status:         u.Status(),
+		DisabledAt:     u.DisabledAt,
+		EmailBouncedAt: u.EmailBouncedAt,
+		EraseAt:        u.EraseAt,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		DeletedAt:      u.DeletedAt,
+	}
+}
+
 // UserDTO ...
 type UserDTO struct {
 	IDCode        string        `json:"id"`
@@ -38,6 +102,11 @@ type UserDTO struct {
 	Activated     bool          `json:"activated"`
 	Password      string        `json:"-"`
 	OAuthProvider OAuthProvider `json:"oauth_provider"`
+	FirstName     string        `json:"first_name"`
+	LastName      string        `json:"last_name"`
+	Locale        string        `json:"locale"`
+	DisplayName   string        `json:"display_name"`
+	PictureURL    string        `json:"picture_url"`
 	CreatedAt     *time.Time    `json:"created_at"`
 	UpdatedAt     *time.Time    `json:"updated_at"`
 	DeletedAt     *time.Time    `json:"deleted_at"`
@@ -52,6 +121,11 @@ func (u *UserDTO) ToUser() *User {
 		Activated:     u.Activated,
 		Password:      u.Password,
 		OAuthProvider: u.OAuthProvider,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Locale:        u.Locale,
+		DisplayName:   u.DisplayName,
+		PictureURL:    u.PictureURL,
 		CreatedAt:     u.CreatedAt,
 		UpdatedAt:     u.UpdatedAt,
 		DeletedAt:     u.DeletedAt,
@@ -61,9 +135,34 @@ func (u *UserDTO) ToUser() *User {
 type UserSession struct {
 	UserID        int64  `json:"-" db:"user_id"`
 	UserIDCode    string `json:"user_id" db:"user_idcode"`
+	TenantID      int64  `json:"tenant_id" db:"tenant_id"`
 	UserEmail     string `json:"email" db:"email"`
 	UserActivated bool   `json:"activated" db:"activated"`
 	StartedAt     int64  `json:"session_started_at" db:"started_at"`
+
+	// Roles and Permissions are top-level claims of the access token
+	Roles       []string `json:"-" db:"-"`
+	Permissions []string `json:"-" db:"-"`
+}
+
+// HasRole ...
+func (us *UserSession) HasRole(role string) bool {
+	for _, r := range us.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission ...
+func (us *UserSession) HasPermission(permission string) bool {
+	for _, p := range us.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
 
 // ToUser ...
@@ -71,6 +170,7 @@ func (us *UserSession) ToUser() *User {
 	return &User{
 		ID:        us.UserID,
 		IDCode:    us.UserIDCode,
+		TenantID:  us.TenantID,
 		Email:     us.UserEmail,
 		Activated: us.UserActivated,
 	}
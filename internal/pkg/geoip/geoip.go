@@ -0,0 +1,67 @@
+package geoip
+
+import (
+	"auth-api/internal/models"
+	"errors"
+	"fmt"
+	"github.com/oschwald/geoip2-golang"
+	"go.uber.org/zap"
+	"net"
+	"os"
+)
+
+var ErrDisabled = errors.New("geoip database is not loaded")
+
+type Reader struct {
+	db *geoip2.Reader
+}
+
+// InitReader opens local MaxMind database file. Missing file is not fatal:
+// service keeps working, but locations are not resolved
+func InitReader(config *models.Config, log *zap.Logger) *Reader {
+	log = log.Named("[GEOIP]")
+	path := config.GeoIP.DatabasePath
+
+	if _, err := os.Stat(path); err != nil {
+		log.Warn(fmt.Sprintf("database is not available, lookups disabled: %s", err))
+		return &Reader{}
+	}
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("open: %s", err))
+	}
+
+	return &Reader{
+		db: db,
+	}
+}
+
+func (r *Reader) Lookup(ip string) (*models.Location, error) {
+	if r.db == nil {
+		return nil, ErrDisabled
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid ip: %q", ip)
+	}
+
+	city, err := r.db.City(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Location{
+		City:        city.City.Names["en"],
+		Country:     city.Country.Names["en"],
+		CountryCode: city.Country.IsoCode,
+	}, nil
+}
+
+func (r *Reader) Close() error {
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"auth-api/internal/models/consts"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type item struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (i item) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+// Cache is an in-memory cache provider for development and tests,
+// behaves like Redis: missing and expired keys are returned as empty values
+type Cache struct {
+	mu    sync.RWMutex
+	items map[string]item
+
+	// now is replaced in tests
+	now func() time.Time
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewCache() *Cache {
+	c := &Cache{
+		items: map[string]item{},
+		now:   time.Now,
+		done:  make(chan struct{}),
+	}
+
+	go c.sweep()
+	return c
+}
+
+func (c *Cache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.RLock()
+	i, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || i.expired(c.now()) {
+		return []byte{}, nil
+	}
+	return append([]byte{}, i.value...), nil
+}
+
+func (c *Cache) GetDel(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	i, ok := c.items[key]
+	delete(c.items, key)
+	c.mu.Unlock()
+
+	if !ok || i.expired(c.now()) {
+		return []byte{}, nil
+	}
+	return i.value, nil
+}
+
+// Incr increments the counter and keeps its expiry, missing key is not created
+func (c *Cache) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i, ok := c.items[key]
+	if !ok || i.expired(c.now()) {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(string(i.value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value is not an integer: %w", err)
+	}
+	value++
+	i.value = []byte(strconv.FormatInt(value, 10))
+	c.items[key] = i
+	return value, nil
+}
+
+// IncrWithTTL increments the counter, the new counter expires after ttl
+func (c *Cache) IncrWithTTL(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	i, ok := c.items[key]
+	if !ok || i.expired(now) {
+		i = item{value: []byte("0")}
+		if ttl > 0 {
+			i.expiresAt = now.Add(ttl)
+		}
+	}
+	value, err := strconv.ParseInt(string(i.value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value is not an integer: %w", err)
+	}
+	value++
+	i.value = []byte(strconv.FormatInt(value, 10))
+	c.items[key] = i
+	return value, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores value, zero ttl means no expiry
+func (c *Cache) SetWithTTL(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	i := item{value: append([]byte{}, value...)}
+	if ttl > 0 {
+		i.expiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	c.items[key] = i
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) FlushAll(_ context.Context) error {
+	c.mu.Lock()
+	c.items = map[string]item{}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return nil
+}
+
+// sweep removes expired items, so keys that are never read again do not leak
+func (c *Cache) sweep() {
+	ticker := time.NewTicker(consts.MemoryCacheSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.removeExpired()
+		}
+	}
+}
+
+func (c *Cache) removeExpired() {
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, i := range c.items {
+		if i.expired(now) {
+			delete(c.items, key)
+		}
+	}
+}
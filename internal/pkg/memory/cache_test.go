@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) (*Cache, *time.Time) {
+	t.Helper()
+
+	now := time.Now()
+	cache := NewCache()
+	cache.now = func() time.Time { return now }
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache, &now
+}
+
+func TestCache_SetWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, now := newTestCache(t)
+
+	require.NoError(t, cache.SetWithTTL(ctx, "key", []byte("value"), time.Minute))
+
+	value, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	*now = now.Add(time.Minute)
+	value, err = cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	cache.removeExpired()
+	assert.Empty(t, cache.items)
+}
+
+func TestCache_GetDel(t *testing.T) {
+	ctx := context.Background()
+	cache, now := newTestCache(t)
+
+	require.NoError(t, cache.SetWithTTL(ctx, "key", []byte("value"), time.Minute))
+
+	value, err := cache.GetDel(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	value, err = cache.GetDel(ctx, "key")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	require.NoError(t, cache.SetWithTTL(ctx, "key", []byte("value"), time.Minute))
+	*now = now.Add(time.Minute)
+	value, err = cache.GetDel(ctx, "key")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+	assert.Empty(t, cache.items)
+}
+
+func TestCache_Set(t *testing.T) {
+	ctx := context.Background()
+	cache, now := newTestCache(t)
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value")))
+
+	*now = now.Add(365 * 24 * time.Hour)
+	value, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestCache_Incr(t *testing.T) {
+	ctx := context.Background()
+	cache, now := newTestCache(t)
+
+	require.NoError(t, cache.SetWithTTL(ctx, "attempts", []byte("0"), time.Minute))
+	*now = now.Add(30 * time.Second)
+
+	value, err := cache.Incr(ctx, "attempts")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	// the counter keeps its expiry
+	*now = now.Add(30 * time.Second)
+	value, err = cache.Incr(ctx, "attempts")
+	require.NoError(t, err)
+	assert.Zero(t, value)
+
+	value, err = cache.Incr(ctx, "missing")
+	require.NoError(t, err)
+	assert.Zero(t, value)
+	raw, err := cache.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, raw)
+}
+
+func TestCache_IncrWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, now := newTestCache(t)
+
+	for expected := int64(1); expected <= 3; expected++ {
+		value, err := cache.IncrWithTTL(ctx, "window", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+		*now = now.Add(15 * time.Second)
+	}
+
+	// increments do not move the window
+	*now = now.Add(15 * time.Second)
+	value, err := cache.IncrWithTTL(ctx, "window", time.Minute)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+}
+
+func TestCache_Delete(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+
+	require.NoError(t, cache.Set(ctx, "first", []byte("1")))
+	require.NoError(t, cache.Set(ctx, "second", []byte("2")))
+
+	require.NoError(t, cache.Delete(ctx, "first"))
+	value, err := cache.Get(ctx, "first")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	require.NoError(t, cache.FlushAll(ctx))
+	value, err = cache.Get(ctx, "second")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
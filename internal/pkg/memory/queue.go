@@ -0,0 +1,211 @@
+package memory
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrQueueFull = errors.New("memory: queue is full")
+
+// Record is a message sent or published through the in-memory queue
+type Record struct {
+	Queue      string
+	Exchange   string
+	RoutingKey string
+	MessageID  string
+	Body       []byte
+	Headers    models.QueueHeaders
+}
+
+// Queue is an in-memory queue provider for development and tests. Every sent and published
+// message is recorded. Messages sent to the queue that is being consumed are delivered
+// to its consumer, failed messages are retried once and then dead-lettered like in RabbitMQ
+type Queue struct {
+	mu          sync.Mutex
+	records     []Record
+	consumed    map[string]chan *models.QueueMessage
+	deadLetters map[string][]models.QueueMessage
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		consumed:    map[string]chan *models.QueueMessage{},
+		deadLetters: map[string][]models.QueueMessage{},
+	}
+}
+
+// SetQueueTopology does nothing, every consumed queue has the dead-letter queue
+func (q *Queue) SetQueueTopology(string, *models.QueueTopology) {}
+
+// DeclareTopology does nothing, queues are created on the first Send
+func (q *Queue) DeclareTopology(context.Context) error {
+	return nil
+}
+
+func (q *Queue) Send(_ context.Context, qName, messageID string, message []byte, headers models.QueueHeaders) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.record(Record{
+		Queue:     qName,
+		MessageID: messageID,
+		Body:      append([]byte{}, message...),
+		Headers:   headers,
+	})
+
+	ch, ok := q.consumed[qName]
+	if !ok {
+		return nil
+	}
+	select {
+	case ch <- &models.QueueMessage{ID: messageID, Body: message}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) Publish(_ context.Context, exchange, routingKey, messageID string, message []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.record(Record{
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		MessageID:  messageID,
+		Body:       append([]byte{}, message...),
+	})
+	return nil
+}
+
+// Records returns recorded messages from the oldest to the newest
+func (q *Queue) Records() []Record {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Record{}, q.records...)
+}
+
+// Consume handles messages sent to the queue until ctx is done
+func (q *Queue) Consume(ctx context.Context, qName string, handler interfaces.QueueMessageHandler) error {
+	q.mu.Lock()
+	ch, ok := q.consumed[qName]
+	if !ok {
+		ch = make(chan *models.QueueMessage, consts.MemoryQueueSize)
+		q.consumed[qName] = ch
+	}
+	q.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message := <-ch:
+			q.handle(qName, ch, message, handler)
+		}
+	}
+}
+
+func (q *Queue) PeekDeadLetters(
+	_ context.Context,
+	qName string,
+	limit int,
+	match interfaces.QueueMessageFilter) ([]models.QueueMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var result []models.QueueMessage
+	for i := range q.deadLetters[qName] {
+		if len(result) >= limit {
+			break
+		}
+		if match(&q.deadLetters[qName][i]) {
+			result = append(result, q.deadLetters[qName][i])
+		}
+	}
+	return result, nil
+}
+
+func (q *Queue) RequeueDeadLetters(
+	_ context.Context,
+	qName string,
+	limit int,
+	match interfaces.QueueMessageFilter) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var (
+		kept     []models.QueueMessage
+		requeued []models.QueueMessage
+	)
+	for _, message := range q.deadLetters[qName] {
+		if len(requeued) < limit && match(&message) {
+			requeued = append(requeued, message)
+			continue
+		}
+		kept = append(kept, message)
+	}
+	q.deadLetters[qName] = kept
+
+	for i, message := range requeued {
+		if ch, ok := q.consumed[qName]; ok {
+			select {
+			case ch <- &models.QueueMessage{ID: message.ID, Body: message.Body}:
+			default:
+				q.deadLetters[qName] = append(q.deadLetters[qName], requeued[i:]...)
+				return i, ErrQueueFull
+			}
+		}
+		q.record(Record{
+			Queue:     qName,
+			MessageID: message.ID,
+			Body:      message.Body,
+		})
+	}
+	return len(requeued), nil
+}
+
+func (q *Queue) Close() error {
+	return nil
+}
+
+func (q *Queue) handle(
+	qName string,
+	ch chan *models.QueueMessage,
+	message *models.QueueMessage,
+	handler interfaces.QueueMessageHandler) {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.QueueHandleTimeout)
+	defer cancel()
+
+	err := handler(ctx, message)
+	if err == nil {
+		return
+	}
+
+	if !message.Redelivered && !errors.Is(err, models.ErrInvalidQueueMessage) {
+		redelivered := *message
+		redelivered.Redelivered = true
+		select {
+		case ch <- &redelivered:
+			return
+		default:
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	deadLetter := *message
+	deadLetter.Reason = "rejected"
+	q.deadLetters[qName] = append(q.deadLetters[qName], deadLetter)
+}
+
+// record keeps the latest messages only, so long-running dev instance does not leak memory
+func (q *Queue) record(record Record) {
+	if len(q.records) >= consts.MemoryQueueRecordsLimit {
+		q.records = q.records[1:]
+	}
+	q.records = append(q.records, record)
+}
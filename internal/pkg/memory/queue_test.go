@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func consume(t *testing.T, queue *Queue, qName string, handler func(message *models.QueueMessage) error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = queue.Consume(ctx, qName, func(_ context.Context, message *models.QueueMessage) error {
+			return handler(message)
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// wait for the consumer to register the queue
+	require.Eventually(t, func() bool {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		_, ok := queue.consumed[qName]
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestQueue_Records(t *testing.T) {
+	ctx := context.Background()
+	queue := NewQueue()
+
+	headers := models.QueueHeaders{"x-max-attempts": 5}
+	require.NoError(t, queue.Send(ctx, "mails", "id-1", []byte("mail"), headers))
+	require.NoError(t, queue.Publish(ctx, "events", "user.created", "id-2", []byte("event")))
+
+	assert.Equal(t, []Record{
+		{Queue: "mails", MessageID: "id-1", Body: []byte("mail"), Headers: headers},
+		{Exchange: "events", RoutingKey: "user.created", MessageID: "id-2", Body: []byte("event")},
+	}, queue.Records())
+}
+
+func TestQueue_Consume(t *testing.T) {
+	ctx := context.Background()
+	queue := NewQueue()
+
+	var handled atomic.Int32
+	consume(t, queue, "commands", func(message *models.QueueMessage) error {
+		handled.Add(1)
+		return nil
+	})
+
+	require.NoError(t, queue.Send(ctx, "commands", "id-1", []byte("{}"), nil))
+	require.Eventually(t, func() bool { return handled.Load() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestQueue_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	queue := NewQueue()
+
+	var attempts atomic.Int32
+	consume(t, queue, "commands", func(message *models.QueueMessage) error {
+		if message.ID == "invalid" {
+			return models.ErrInvalidQueueMessage
+		}
+		if attempts.Add(1) <= 2 {
+			return errors.New("temporary error")
+		}
+		return nil
+	})
+
+	all := func(*models.QueueMessage) bool { return true }
+	deadLetters := func() []models.QueueMessage {
+		messages, err := queue.PeekDeadLetters(ctx, "commands", 10, all)
+		require.NoError(t, err)
+		return messages
+	}
+
+	// invalid message is dead-lettered without retry
+	require.NoError(t, queue.Send(ctx, "commands", "invalid", []byte("{}"), nil))
+	require.Eventually(t, func() bool { return len(deadLetters()) == 1 }, time.Second, time.Millisecond)
+
+	// failed message is retried once and then dead-lettered
+	require.NoError(t, queue.Send(ctx, "commands", "failed", []byte("{}"), nil))
+	require.Eventually(t, func() bool { return len(deadLetters()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	requeued, err := queue.RequeueDeadLetters(ctx, "commands", 10, func(message *models.QueueMessage) bool {
+		return message.ID == "failed"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+
+	require.Eventually(t, func() bool { return attempts.Load() == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, "invalid", deadLetters()[0].ID)
+	assert.Len(t, deadLetters(), 1)
+}
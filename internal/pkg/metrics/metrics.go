@@ -12,9 +12,22 @@ type APIMetrics struct {
 
 	SuccessSessionVerification prometheus.Counter
 	ErrorSessionVerification   prometheus.Counter
+	PermissionDenied           prometheus.Counter
 
 	// user
-	VerifyEmailRequests prometheus.Counter
+	VerifyEmailRequests      prometheus.Counter
+	UserActivityRequests     prometheus.Counter
+	DeleteMeRequests         prometheus.Counter
+	CancelMyDeletionRequests prometheus.Counter
+	RequestExportRequests    prometheus.Counter
+	UpdateMeRequests         prometheus.Counter
+	ConfirmContactRequests   prometheus.Counter
+	GetMyExportRequests      prometheus.Counter
+	DownloadExportRequests   prometheus.Counter
+	IdentitiesRequests       prometheus.Counter
+	LinkIdentityRequests     prometheus.Counter
+	UnlinkIdentityRequests   prometheus.Counter
+	SetPasswordRequests      prometheus.Counter
 
 	// auth
 	SignInRequests       prometheus.Counter
@@ -22,11 +35,36 @@ type APIMetrics struct {
 	RefreshRequest       prometheus.Counter
 	LogoutRequest        prometheus.Counter
 	VerifySessionRequest prometheus.Counter
+	RevokeSessionRequest prometheus.Counter
 
 	// oauth
 
 	GoogleRedirectRequest prometheus.Counter
 	GoogleCallBackRequest prometheus.Counter
+	OAuthRedirectRequests prometheus.Counter
+	OAuthCallBackRequests prometheus.Counter
+	OAuthExchangeRequests prometheus.Counter
+
+	// admin
+	AdminAuthEventsRequests         prometheus.Counter
+	AdminDeadLetterMailsRequests    prometheus.Counter
+	AdminRequeueDeadLettersRequest  prometheus.Counter
+	AdminRolesRequests              prometheus.Counter
+	AdminSaveRoleRequests           prometheus.Counter
+	AdminDeleteRoleRequests         prometheus.Counter
+	AdminPermissionsRequests        prometheus.Counter
+	AdminSavePermissionRequests     prometheus.Counter
+	AdminUserRolesRequests          prometheus.Counter
+	AdminAssignRoleRequests         prometheus.Counter
+	AdminUnassignRoleRequests       prometheus.Counter
+	AdminUsersRequests              prometheus.Counter
+	AdminUserRequests               prometheus.Counter
+	AdminDisableUserRequests        prometheus.Counter
+	AdminEnableUserRequests         prometheus.Counter
+	AdminLogoutUserRequests         prometheus.Counter
+	AdminResendVerificationRequests prometheus.Counter
+	AdminDeleteUserRequests         prometheus.Counter
+	AdminCancelDeletionRequests     prometheus.Counter
 }
 
 // NewAPIMetrics creates a new instance of APIMetrics with Prometheus counters initialized.
@@ -50,10 +88,62 @@ func NewAPIMetrics() *APIMetrics {
 			Name: fmt.Sprintf("%s_error_session_verification_requests", serviceName),
 			Help: "The total number of unsuccessful session verification http requests",
 		}),
+		PermissionDenied: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_permission_denied_requests", serviceName),
+			Help: "The total number of http requests denied for the lack of permission",
+		}),
 		VerifyEmailRequests: promauto.NewCounter(prometheus.CounterOpts{
 			Name: fmt.Sprintf("%s_verify_email_requests", serviceName),
 			Help: "The total number of verify email http requests",
 		}),
+		UserActivityRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_user_activity_requests", serviceName),
+			Help: "The total number of user security activity http requests",
+		}),
+		DeleteMeRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_delete_me_requests", serviceName),
+			Help: "The total number of user deletion http requests",
+		}),
+		CancelMyDeletionRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_cancel_my_deletion_requests", serviceName),
+			Help: "The total number of user deletion cancel http requests",
+		}),
+		UpdateMeRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_update_me_requests", serviceName),
+			Help: "The total number of profile update http requests",
+		}),
+		ConfirmContactRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_confirm_contact_requests", serviceName),
+			Help: "The total number of email or phone change confirm http requests",
+		}),
+		RequestExportRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_request_export_requests", serviceName),
+			Help: "The total number of personal data export http requests",
+		}),
+		GetMyExportRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_get_my_export_requests", serviceName),
+			Help: "The total number of personal data export status http requests",
+		}),
+		DownloadExportRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_download_export_requests", serviceName),
+			Help: "The total number of personal data export download http requests",
+		}),
+		IdentitiesRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_identities_requests", serviceName),
+			Help: "The total number of linked identities http requests",
+		}),
+		LinkIdentityRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_link_identity_requests", serviceName),
+			Help: "The total number of identity link http requests",
+		}),
+		UnlinkIdentityRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_unlink_identity_requests", serviceName),
+			Help: "The total number of identity unlink http requests",
+		}),
+		SetPasswordRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_set_password_requests", serviceName),
+			Help: "The total number of password set http requests",
+		}),
 		SignInRequests: promauto.NewCounter(prometheus.CounterOpts{
 			Name: fmt.Sprintf("%s_sign_in_requests", serviceName),
 			Help: "The total number of sign in http requests",
@@ -74,6 +164,10 @@ func NewAPIMetrics() *APIMetrics {
 			Name: fmt.Sprintf("%s_verify_session_requests", serviceName),
 			Help: "The total number of verify session http requests",
 		}),
+		RevokeSessionRequest: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_revoke_session_requests", serviceName),
+			Help: "The total number of revoke session http requests",
+		}),
 		GoogleRedirectRequest: promauto.NewCounter(prometheus.CounterOpts{
 			Name: fmt.Sprintf("%s_google_redirect_requests", serviceName),
 			Help: "The total number of Google redirect http requests",
@@ -82,5 +176,93 @@ func NewAPIMetrics() *APIMetrics {
 			Name: fmt.Sprintf("%s_google_callback_requests", serviceName),
 			Help: "The total number of Google callback http requests",
 		}),
+		OAuthRedirectRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_oauth_redirect_requests", serviceName),
+			Help: "The total number of social provider redirect http requests",
+		}),
+		OAuthCallBackRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_oauth_callback_requests", serviceName),
+			Help: "The total number of social provider callback http requests",
+		}),
+		OAuthExchangeRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_oauth_exchange_requests", serviceName),
+			Help: "The total number of oauth code exchange http requests",
+		}),
+		AdminAuthEventsRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_auth_events_requests", serviceName),
+			Help: "The total number of admin auth events http requests",
+		}),
+		AdminDeadLetterMailsRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_dead_letter_mails_requests", serviceName),
+			Help: "The total number of admin dead-lettered mails http requests",
+		}),
+		AdminRequeueDeadLettersRequest: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_requeue_dead_letters_requests", serviceName),
+			Help: "The total number of admin requeue dead-lettered mails http requests",
+		}),
+		AdminRolesRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_roles_requests", serviceName),
+			Help: "The total number of admin roles http requests",
+		}),
+		AdminSaveRoleRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_save_role_requests", serviceName),
+			Help: "The total number of admin save role http requests",
+		}),
+		AdminDeleteRoleRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_delete_role_requests", serviceName),
+			Help: "The total number of admin delete role http requests",
+		}),
+		AdminPermissionsRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_permissions_requests", serviceName),
+			Help: "The total number of admin permissions http requests",
+		}),
+		AdminSavePermissionRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_save_permission_requests", serviceName),
+			Help: "The total number of admin save permission http requests",
+		}),
+		AdminUserRolesRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_user_roles_requests", serviceName),
+			Help: "The total number of admin user roles http requests",
+		}),
+		AdminAssignRoleRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_assign_role_requests", serviceName),
+			Help: "The total number of admin assign role http requests",
+		}),
+		AdminUnassignRoleRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_unassign_role_requests", serviceName),
+			Help: "The total number of admin unassign role http requests",
+		}),
+		AdminUsersRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_users_requests", serviceName),
+			Help: "The total number of admin users search http requests",
+		}),
+		AdminUserRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_user_requests", serviceName),
+			Help: "The total number of admin user detail http requests",
+		}),
+		AdminDisableUserRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_disable_user_requests", serviceName),
+			Help: "The total number of admin disable user http requests",
+		}),
+		AdminEnableUserRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_enable_user_requests", serviceName),
+			Help: "The total number of admin enable user http requests",
+		}),
+		AdminLogoutUserRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_logout_user_requests", serviceName),
+			Help: "The total number of admin force logout http requests",
+		}),
+		AdminResendVerificationRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_resend_verification_requests", serviceName),
+			Help: "The total number of admin resend verification http requests",
+		}),
+		AdminDeleteUserRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_delete_user_requests", serviceName),
+			Help: "The total number of admin delete user http requests",
+		}),
+		AdminCancelDeletionRequests: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_admin_cancel_deletion_requests", serviceName),
+			Help: "The total number of admin cancel user deletion http requests",
+		}),
 	}
 }
@@ -0,0 +1,149 @@
+package nats
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+	"strings"
+	"sync"
+)
+
+// ProducerClient publishes messages to NATS JetStream. Queue is a work-queue stream
+// with the single subject "<queue>", topic exchange is a stream with subjects "<exchange>.>".
+// Streams are created on the first publish, message id is used for deduplication.
+// Connection is restored by the nats client, failed publishes are retried by the caller
+type ProducerClient struct {
+	log  *zap.Logger
+	conn *nats.Conn
+	js   jetstream.JetStream
+
+	mu      sync.Mutex
+	streams map[string]struct{}
+}
+
+func NewProducerClient(config *models.Config, log *zap.Logger) (*ProducerClient, error) {
+	log = log.Named("[NATS]")
+
+	conn, err := nats.Connect(config.NATS.ServerURL,
+		nats.Name("auth-api"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(consts.NATSReconnectWait),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Error(fmt.Sprintf("disconnected: %v", err))
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			log.Info("reconnected")
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &ProducerClient{
+		log:     log,
+		conn:    conn,
+		js:      js,
+		streams: map[string]struct{}{},
+	}, nil
+}
+
+// SetQueueTopology does nothing: in JetStream retries and dead letters are
+// configured by consumers with MaxDeliver and BackOff
+func (pc *ProducerClient) SetQueueTopology(string, *models.QueueTopology) {}
+
+// DeclareTopology does nothing, streams are created on the first Send
+func (pc *ProducerClient) DeclareTopology(context.Context) error {
+	return nil
+}
+
+func (pc *ProducerClient) Send(ctx context.Context, qName, messageID string, message []byte, headers models.QueueHeaders) (err error) {
+	stream := jetstream.StreamConfig{
+		Name:       streamName(qName),
+		Subjects:   []string{qName},
+		Retention:  jetstream.WorkQueuePolicy,
+		Storage:    jetstream.FileStorage,
+		Duplicates: consts.NATSDuplicatesWindow,
+	}
+	return pc.publish(ctx, stream, qName, messageID, message, headers)
+}
+
+// Publish sends message to the subject "<exchange>.<routingKey>"
+func (pc *ProducerClient) Publish(ctx context.Context, exchange, routingKey, messageID string, message []byte) (err error) {
+	stream := jetstream.StreamConfig{
+		Name:       streamName(exchange),
+		Subjects:   []string{exchange + ".>"},
+		Retention:  jetstream.LimitsPolicy,
+		Storage:    jetstream.FileStorage,
+		Duplicates: consts.NATSDuplicatesWindow,
+		MaxAge:     consts.NATSTopicMaxAge,
+	}
+	return pc.publish(ctx, stream, exchange+"."+routingKey, messageID, message, nil)
+}
+
+func (pc *ProducerClient) Close() error {
+	return pc.conn.Drain()
+}
+
+func (pc *ProducerClient) publish(
+	ctx context.Context,
+	stream jetstream.StreamConfig,
+	subject, messageID string,
+	message []byte,
+	headers models.QueueHeaders) error {
+	if err := pc.ensureStream(ctx, stream); err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = message
+	for k, v := range headers {
+		msg.Header.Set(k, fmt.Sprint(v))
+	}
+
+	if _, err := pc.js.PublishMsg(ctx, msg, jetstream.WithMsgID(messageID)); err != nil {
+		// stream might have been deleted, it is created again on the next publish
+		pc.forgetStream(stream.Name)
+		return err
+	}
+	return nil
+}
+
+func (pc *ProducerClient) ensureStream(ctx context.Context, stream jetstream.StreamConfig) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if _, ok := pc.streams[stream.Name]; ok {
+		return nil
+	}
+	if _, err := pc.js.CreateOrUpdateStream(ctx, stream); err != nil {
+		return err
+	}
+	pc.streams[stream.Name] = struct{}{}
+	return nil
+}
+
+func (pc *ProducerClient) forgetStream(name string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	delete(pc.streams, name)
+}
+
+// streamName converts queue or exchange name into a valid stream name
+func streamName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(
+		".", "_",
+		"*", "_",
+		">", "_",
+		" ", "_",
+	).Replace(name))
+}
@@ -0,0 +1,110 @@
+package nats
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+func newClient(t *testing.T, srv *server.Server) *ProducerClient {
+	t.Helper()
+
+	config := &models.Config{NATS: models.NATS{ServerURL: srv.ClientURL()}}
+	client, err := NewProducerClient(config, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestProducerClient_Send(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newClient(t, runServer(t))
+	headers := models.QueueHeaders{"x-max-attempts": int32(5)}
+
+	require.NoError(t, client.Send(ctx, "mails", "id-1", []byte(`{"n":1}`), headers))
+	// the same id is deduplicated by the stream
+	require.NoError(t, client.Send(ctx, "mails", "id-1", []byte(`{"n":1}`), headers))
+	require.NoError(t, client.Send(ctx, "mails", "id-2", []byte(`{"n":2}`), headers))
+
+	stream, err := client.js.Stream(ctx, "MAILS")
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, jetstream.WorkQueuePolicy, info.Config.Retention)
+	assert.Equal(t, uint64(2), info.State.Msgs)
+
+	msg, err := stream.GetMsg(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "mails", msg.Subject)
+	assert.Equal(t, `{"n":1}`, string(msg.Data))
+	assert.Equal(t, "5", msg.Header.Get("x-max-attempts"))
+	assert.Equal(t, "id-1", msg.Header.Get(jetstream.MsgIDHeader))
+}
+
+func TestProducerClient_Publish(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newClient(t, runServer(t))
+
+	require.NoError(t, client.Publish(ctx, "auth-api.events", "user.created", "id-1", []byte(`{}`)))
+	require.NoError(t, client.Publish(ctx, "auth-api.events", "session.started", "id-2", []byte(`{}`)))
+
+	stream, err := client.js.Stream(ctx, "AUTH-API_EVENTS")
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), info.State.Msgs)
+
+	msg, err := stream.GetMsg(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "auth-api.events.session.started", msg.Subject)
+}
+
+func TestProducerClient_RecreatesDeletedStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newClient(t, runServer(t))
+
+	require.NoError(t, client.Send(ctx, "mails", "id-1", []byte(`{}`), nil))
+	require.NoError(t, client.js.DeleteStream(ctx, "MAILS"))
+
+	// first publish fails and forgets the stream, the next one creates it again
+	assert.Error(t, client.Send(ctx, "mails", "id-2", []byte(`{}`), nil))
+	require.NoError(t, client.Send(ctx, "mails", "id-2", []byte(`{}`), nil))
+}
+
+func TestStreamName(t *testing.T) {
+	assert.Equal(t, "MAILS-CONSUMER", streamName("mails-consumer"))
+	assert.Equal(t, "AUTH-API_EVENTS", streamName("auth-api.events"))
+}
@@ -0,0 +1,285 @@
+package rabbitmq
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"errors"
+	"fmt"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// ConsumerClient consumes queues with manual acknowledgements. Every consumed queue
+// gets dead-letter exchange "<queue>.dlx" and dead-letter queue "<queue>.dlq"
+type ConsumerClient struct {
+	log *zap.Logger
+	url string
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewConsumerClient(config *models.Config, log *zap.Logger) *ConsumerClient {
+	return &ConsumerClient{
+		log:  log.Named("[RabbitMQ-Consumer]"),
+		url:  config.RabbitMQ.ServerURL,
+		done: make(chan struct{}),
+	}
+}
+
+// Consume handles messages of the queue one by one until ctx is done or client is closed,
+// connection is restored with backoff. Message that is being handled is always finished
+func (cc *ConsumerClient) Consume(ctx context.Context, qName string, handler interfaces.QueueMessageHandler) error {
+	log := cc.log.With(zap.String("queue", qName))
+	backoff := consts.RabbitMQMinBackoff
+
+	for {
+		startedAt := time.Now()
+		err := cc.consume(ctx, qName, handler, log)
+		if ctx.Err() != nil || cc.isClosed() {
+			return nil
+		}
+		// connection that has been working for a while is not a flapping one
+		if time.Since(startedAt) > consts.RabbitMQMaxBackoff {
+			backoff = consts.RabbitMQMinBackoff
+		}
+
+		log.Error(fmt.Sprintf("consume: %v, retry in %s", err, backoff))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cc.done:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, consts.RabbitMQMaxBackoff)
+	}
+}
+
+// PeekDeadLetters returns up to limit matching messages of the queue's dead-letter queue,
+// messages stay in the dead-letter queue and move to its tail
+func (cc *ConsumerClient) PeekDeadLetters(
+	ctx context.Context,
+	qName string,
+	limit int,
+	match interfaces.QueueMessageFilter) (result []models.QueueMessage, err error) {
+	err = cc.withChannel(func(ch *amqp.Channel) error {
+		return scanDeadLetters(ctx, ch, qName, func(delivery *amqp.Delivery) (bool, bool, error) {
+			message := toQueueMessage(delivery)
+			if match(message) {
+				result = append(result, *message)
+			}
+			return false, len(result) >= limit, nil
+		})
+	})
+	return result, err
+}
+
+// RequeueDeadLetters moves up to limit matching messages of the queue's dead-letter queue
+// back to the queue with reset retry counter
+func (cc *ConsumerClient) RequeueDeadLetters(
+	ctx context.Context,
+	qName string,
+	limit int,
+	match interfaces.QueueMessageFilter) (requeued int, err error) {
+	err = cc.withChannel(func(ch *amqp.Channel) error {
+		return scanDeadLetters(ctx, ch, qName, func(delivery *amqp.Delivery) (bool, bool, error) {
+			if !match(toQueueMessage(delivery)) {
+				return false, false, nil
+			}
+
+			err := publishConfirmed(ctx, ch, qName, amqp.Publishing{
+				Headers:      requeueHeaders(delivery.Headers),
+				ContentType:  delivery.ContentType,
+				DeliveryMode: amqp.Persistent,
+				MessageId:    delivery.MessageId,
+				Body:         delivery.Body,
+			})
+			if err != nil {
+				return false, false, err
+			}
+
+			requeued++
+			return true, requeued >= limit, nil
+		})
+	})
+	return requeued, err
+}
+
+func (cc *ConsumerClient) Close() error {
+	cc.closeOnce.Do(func() {
+		close(cc.done)
+	})
+	return nil
+}
+
+func (cc *ConsumerClient) consume(
+	ctx context.Context,
+	qName string,
+	handler interfaces.QueueMessageHandler,
+	log *zap.Logger) error {
+	conn, err := amqp.Dial(cc.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err = ch.Qos(consts.RabbitMQPrefetchCount, 0, false); err != nil {
+		return err
+	}
+	if err = declareQueue(ch, qName, &models.QueueTopology{DeadLetter: true}); err != nil {
+		return err
+	}
+
+	deliveries, err := ch.Consume(qName, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Info("consuming")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cc.done:
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return errors.New("deliveries channel is closed")
+			}
+			cc.handle(delivery, handler, log)
+		}
+	}
+}
+
+// handle acks handled message, failed message is requeued once and dead-lettered after that
+func (cc *ConsumerClient) handle(delivery amqp.Delivery, handler interfaces.QueueMessageHandler, log *zap.Logger) {
+	log = log.With(zap.String("message_id", delivery.MessageId))
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.QueueHandleTimeout)
+	defer cancel()
+
+	err := handler(ctx, toQueueMessage(&delivery))
+	if err == nil {
+		if ackErr := delivery.Ack(false); ackErr != nil {
+			log.Error(fmt.Sprintf("ack: %s", ackErr))
+		}
+		return
+	}
+
+	requeue := !delivery.Redelivered && !errors.Is(err, models.ErrInvalidQueueMessage)
+	log.Error(fmt.Sprintf("handle: %s, requeue: %t", err, requeue))
+	if nackErr := delivery.Nack(false, requeue); nackErr != nil {
+		log.Error(fmt.Sprintf("nack: %s", nackErr))
+	}
+}
+
+func (cc *ConsumerClient) isClosed() bool {
+	select {
+	case <-cc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cc *ConsumerClient) withChannel(fn func(ch *amqp.Channel) error) error {
+	conn, err := amqp.Dial(cc.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return fn(ch)
+}
+
+// scanDeadLetters gets messages of the dead-letter queue one by one, so only one of them is
+// unacknowledged at a time. Messages that are not removed are published to the tail of the
+// dead-letter queue before they are acknowledged, the scan stops after messages the queue had
+// at the start were visited, so none of them is returned twice
+func scanDeadLetters(
+	ctx context.Context,
+	ch *amqp.Channel,
+	qName string,
+	visit func(delivery *amqp.Delivery) (remove, done bool, err error)) error {
+	dlq := deadLetterQueue(qName)
+	queue, err := ch.QueueDeclare(dlq, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	if err = ch.Confirm(false); err != nil {
+		return err
+	}
+
+	for i := 0; i < min(queue.Messages, consts.DeadLettersScanLimit); i++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		delivery, ok, err := ch.Get(dlq, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		remove, done, err := visit(&delivery)
+		if err != nil {
+			_ = delivery.Nack(false, true)
+			return err
+		}
+		if !remove {
+			err = publishConfirmed(ctx, ch, dlq, amqp.Publishing{
+				Headers:      delivery.Headers,
+				ContentType:  delivery.ContentType,
+				DeliveryMode: amqp.Persistent,
+				MessageId:    delivery.MessageId,
+				Body:         delivery.Body,
+			})
+			if err != nil {
+				_ = delivery.Nack(false, true)
+				return err
+			}
+		}
+		if err = delivery.Ack(false); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// publishConfirmed publishes the message to the queue and waits for the broker to confirm it
+func publishConfirmed(ctx context.Context, ch *amqp.Channel, qName string, publishing amqp.Publishing) error {
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", qName, false, false, publishing)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
+}
@@ -2,60 +2,336 @@ package rabbitmq
 
 import (
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"context"
+	"errors"
 	"fmt"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
+var (
+	ErrNotConnected     = fmt.Errorf("rabbitmq: not connected: %w", models.ErrQueueUnavailable)
+	ErrNotConfirmed     = errors.New("rabbitmq: message is not confirmed by broker")
+	ErrTopologyMismatch = errors.New("rabbitmq: queue is declared with other arguments")
+)
+
+type outgoing struct {
+	exchange   string
+	routingKey string
+	publishing amqp.Publishing
+}
+
+// ProducerClient keeps connection to the broker alive and publishes messages
+// with publisher confirms. Channels are pooled, so Send and Publish are safe
+// for concurrent use. While broker is unreachable Send and Publish return ErrNotConnected,
+// callers keep the message and retry, the outbox row stays pending
 type ProducerClient struct {
-	chanel *amqp.Channel
-	queues map[string]amqp.Queue
+	log *zap.Logger
+	url string
+
+	mu   sync.RWMutex
+	conn *amqp.Connection
+	pool chan *amqp.Channel
+
+	topologyMu sync.Mutex
+	topologies map[string]*models.QueueTopology
+	queues     map[string]struct{}
+	exchanges  map[string]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewProducerClient(config *models.Config, log *zap.Logger) *ProducerClient {
-	log = log.Named("[RabbitMQ]")
+	pc := &ProducerClient{
+		log:        log.Named("[RabbitMQ]"),
+		url:        config.RabbitMQ.ServerURL,
+		topologies: map[string]*models.QueueTopology{},
+		queues:     map[string]struct{}{},
+		exchanges:  map[string]struct{}{},
+		done:       make(chan struct{}),
+	}
+
+	go pc.keepConnected()
+	return pc
+}
+
+// SetQueueTopology sets topology declared with the queue before the first Send
+func (pc *ProducerClient) SetQueueTopology(qName string, topology *models.QueueTopology) {
+	pc.topologyMu.Lock()
+	defer pc.topologyMu.Unlock()
+
+	pc.topologies[qName] = topology
+	delete(pc.queues, qName)
+}
+
+// DeclareTopology declares queues with the topology set before once the broker is connected, queues
+// declared with other arguments fail the start instead of every Send. The broker that is not
+// reachable yet does not hold the start, messages wait in the outbox
+func (pc *ProducerClient) DeclareTopology(ctx context.Context) error {
+	conn, ch, err := pc.awaitChannel(ctx)
+	if err != nil {
+		pc.log.Error(fmt.Sprintf("declare topology: %s", err))
+		return nil
+	}
+	defer pc.release(conn, ch)
+
+	pc.topologyMu.Lock()
+	defer pc.topologyMu.Unlock()
+	for qName, topology := range pc.topologies {
+		if err = declareQueue(ch, qName, topology); err != nil {
+			return err
+		}
+		pc.queues[qName] = struct{}{}
+	}
+	return nil
+}
+
+func (pc *ProducerClient) Send(ctx context.Context, qName, messageID string, message []byte, headers models.QueueHeaders) (err error) {
+	return pc.publish(ctx, &outgoing{
+		routingKey: qName,
+		publishing: amqp.Publishing{
+			Headers:      amqp.Table(headers),
+			ContentType:  "text/plain",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Body:         message,
+		},
+	})
+}
+
+// Publish sends message to the durable topic exchange
+func (pc *ProducerClient) Publish(ctx context.Context, exchange, routingKey, messageID string, message []byte) (err error) {
+	return pc.publish(ctx, &outgoing{
+		exchange:   exchange,
+		routingKey: routingKey,
+		publishing: amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Body:         message,
+		},
+	})
+}
+
+// Close stops reconnecting and closes connection
+func (pc *ProducerClient) Close() error {
+	pc.closeOnce.Do(func() {
+		close(pc.done)
+	})
+
+	conn, _ := pc.setConnection(nil, nil)
+	if conn != nil && !conn.IsClosed() {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (pc *ProducerClient) publish(ctx context.Context, msg *outgoing) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.RabbitMQConfirmTimeout)
+	defer cancel()
 
-	conn, err := amqp.Dial(config.RabbitMQ.ServerURL)
+	conn, ch, err := pc.acquire(ctx)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("connect: %s", err))
+		return err
 	}
+	defer pc.release(conn, ch)
 
-	chanel, err := conn.Channel()
+	if err = pc.declare(ch, msg); err != nil {
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		msg.exchange,
+		msg.routingKey,
+		false,
+		false,
+		msg.publishing)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("open channel: %s", err))
+		return err
 	}
 
-	return &ProducerClient{
-		chanel: chanel,
-		queues: map[string]amqp.Queue{},
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
 	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
 }
 
-func (pc *ProducerClient) Send(ctx context.Context, qName string, message []byte, args ...amqp.Table) (err error) {
-	q, ok := pc.queues[qName]
-	if !ok {
-		var arg amqp.Table
-		if len(args) != 0 {
-			arg = args[0]
+func (pc *ProducerClient) keepConnected() {
+	backoff := consts.RabbitMQMinBackoff
+	for {
+		conn, pool, err := pc.connect()
+		if err != nil {
+			pc.log.Error(fmt.Sprintf("connect: %s, retry in %s", err, backoff))
+			select {
+			case <-pc.done:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, consts.RabbitMQMaxBackoff)
+			continue
+		}
+
+		backoff = consts.RabbitMQMinBackoff
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		pc.setConnection(conn, pool)
+		pc.log.Info("connected")
+
+		select {
+		case <-pc.done:
+			if !conn.IsClosed() {
+				_ = conn.Close()
+			}
+			return
+		case amqpErr := <-closed:
+			pc.log.Error(fmt.Sprintf("connection closed: %v", amqpErr))
+			pc.setConnection(nil, nil)
 		}
+	}
+}
+
+func (pc *ProducerClient) connect() (*amqp.Connection, chan *amqp.Channel, error) {
+	select {
+	case <-pc.done:
+		return nil, nil, errors.New("client is closed")
+	default:
+	}
+
+	conn, err := amqp.Dial(pc.url)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		q, err = pc.chanel.QueueDeclare(qName,
-			true, false, false, false, arg)
+	pool := make(chan *amqp.Channel, consts.RabbitMQChannelPoolSize)
+	for i := 0; i < consts.RabbitMQChannelPoolSize; i++ {
+		ch, err := openChannel(conn)
 		if err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+		pool <- ch
+	}
+	return conn, pool, nil
+}
+
+// setConnection replaces current connection and returns the previous one,
+// declared topology is forgotten because broker might have lost it
+func (pc *ProducerClient) setConnection(conn *amqp.Connection, pool chan *amqp.Channel) (*amqp.Connection, chan *amqp.Channel) {
+	pc.mu.Lock()
+	prevConn, prevPool := pc.conn, pc.pool
+	pc.conn, pc.pool = conn, pool
+	pc.mu.Unlock()
+
+	pc.topologyMu.Lock()
+	pc.queues = map[string]struct{}{}
+	pc.exchanges = map[string]struct{}{}
+	pc.topologyMu.Unlock()
+
+	return prevConn, prevPool
+}
+
+func (pc *ProducerClient) acquire(ctx context.Context) (*amqp.Connection, *amqp.Channel, error) {
+	pc.mu.RLock()
+	conn, pool := pc.conn, pc.pool
+	pc.mu.RUnlock()
+
+	if conn == nil || conn.IsClosed() {
+		return nil, nil, ErrNotConnected
+	}
+
+	select {
+	case ch := <-pool:
+		return conn, ch, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// awaitChannel waits for the connection until ctx is done
+func (pc *ProducerClient) awaitChannel(ctx context.Context) (*amqp.Connection, *amqp.Channel, error) {
+	ticker := time.NewTicker(consts.RabbitMQAwaitInterval)
+	defer ticker.Stop()
+	for {
+		conn, ch, err := pc.acquire(ctx)
+		if !errors.Is(err, ErrNotConnected) {
+			return conn, ch, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// release returns channel to the pool of its connection, closed channel is replaced with new one
+func (pc *ProducerClient) release(conn *amqp.Connection, ch *amqp.Channel) {
+	pc.mu.RLock()
+	pool := pc.pool
+	current := pc.conn == conn
+	pc.mu.RUnlock()
+
+	if !current || conn.IsClosed() {
+		return
+	}
+
+	if ch.IsClosed() {
+		var err error
+		if ch, err = openChannel(conn); err != nil {
+			pc.log.Error(fmt.Sprintf("reopen channel: %s", err))
+			return
+		}
+	}
+
+	select {
+	case pool <- ch:
+	default:
+		_ = ch.Close()
+	}
+}
+
+func (pc *ProducerClient) declare(ch *amqp.Channel, msg *outgoing) error {
+	pc.topologyMu.Lock()
+	defer pc.topologyMu.Unlock()
+
+	if msg.exchange == "" {
+		if _, ok := pc.queues[msg.routingKey]; ok {
+			return nil
+		}
+		if err := declareQueue(ch, msg.routingKey, pc.topologies[msg.routingKey]); err != nil {
 			return err
 		}
+		pc.queues[msg.routingKey] = struct{}{}
+		return nil
 	}
 
-	err = pc.chanel.PublishWithContext(ctx, "", q.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        message,
-		})
+	if _, ok := pc.exchanges[msg.exchange]; ok {
+		return nil
+	}
+	err := ch.ExchangeDeclare(msg.exchange, amqp.ExchangeTopic,
+		true, false, false, false, nil)
 	if err != nil {
 		return err
 	}
+	pc.exchanges[msg.exchange] = struct{}{}
 	return nil
 }
+
+func openChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err = ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, err
+	}
+	return ch, nil
+}
@@ -0,0 +1,126 @@
+package rabbitmq
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"errors"
+	"fmt"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func deadLetterExchange(qName string) string {
+	return qName + ".dlx"
+}
+
+func deadLetterQueue(qName string) string {
+	return qName + ".dlq"
+}
+
+func retryQueue(qName string, n int) string {
+	return fmt.Sprintf("%s.retry.%d", qName, n)
+}
+
+// declareQueue declares durable queue with its topology, nil topology means plain queue.
+// Arguments must be the same for everyone who declares the queue
+func declareQueue(ch *amqp.Channel, qName string, topology *models.QueueTopology) error {
+	var args amqp.Table
+	if topology != nil && topology.DeadLetter {
+		if err := declareDeadLetter(ch, qName); err != nil {
+			return err
+		}
+		args = amqp.Table{"x-dead-letter-exchange": deadLetterExchange(qName)}
+	}
+
+	if topology != nil {
+		for i, delay := range topology.RetryDelays {
+			// expired messages are dead-lettered through the default exchange back to the queue
+			_, err := ch.QueueDeclare(retryQueue(qName, i+1),
+				true, false, false, false, amqp.Table{
+					"x-message-ttl":             delay.Milliseconds(),
+					"x-dead-letter-exchange":    "",
+					"x-dead-letter-routing-key": qName,
+				})
+			if err != nil {
+				return declareError(retryQueue(qName, i+1), err)
+			}
+		}
+	}
+
+	_, err := ch.QueueDeclare(qName,
+		true, false, false, false, args)
+	return declareError(qName, err)
+}
+
+// declareError explains the queue declared before with other arguments, e.g. the mails queue
+// declared without the dead-letter exchange by older versions. The broker closes the channel
+func declareError(qName string, err error) error {
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed {
+		return fmt.Errorf("%w: delete queue %q, it is declared again on start: %s",
+			ErrTopologyMismatch, qName, amqpErr.Reason)
+	}
+	return err
+}
+
+func declareDeadLetter(ch *amqp.Channel, qName string) error {
+	if err := ch.ExchangeDeclare(deadLetterExchange(qName), amqp.ExchangeFanout,
+		true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(deadLetterQueue(qName),
+		true, false, false, false, nil); err != nil {
+		return err
+	}
+	return ch.QueueBind(deadLetterQueue(qName), "", deadLetterExchange(qName), false, nil)
+}
+
+func toQueueMessage(delivery *amqp.Delivery) *models.QueueMessage {
+	message := &models.QueueMessage{
+		ID:          delivery.MessageId,
+		Body:        delivery.Body,
+		Redelivered: delivery.Redelivered,
+		Attempts:    headerInt(delivery.Headers, consts.QueueHeaderAttempt),
+	}
+	if reason, ok := delivery.Headers["x-first-death-reason"].(string); ok {
+		message.Reason = reason
+	}
+	return message
+}
+
+func headerInt(headers amqp.Table, key string) int {
+	switch v := headers[key].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	}
+	return 0
+}
+
+// requeueHeaders drops dead-letter history and retry counter, so the message
+// gets the full number of attempts again
+func requeueHeaders(headers amqp.Table) amqp.Table {
+	result := amqp.Table{}
+	for k, v := range headers {
+		switch k {
+		case "x-death", "x-first-death-exchange", "x-first-death-queue", "x-first-death-reason",
+			"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason",
+			consts.QueueHeaderAttempt:
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -2,37 +2,97 @@ package redis
 
 import (
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/tools"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
+// incrExisting increments the counter only while it exists, so the key never loses its expiry
+var incrExisting = redis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 then
+	return 0
+end
+return redis.call('incr', KEYS[1])`)
+
+// incrWithTTL sets expiry of the counter created by the increment, so the window is fixed
+var incrWithTTL = redis.NewScript(`
+local value = redis.call('incr', KEYS[1])
+if value == 1 then
+	redis.call('pexpire', KEYS[1], ARGV[1])
+end
+return value`)
+
 type Conn struct {
-	client    *redis.Client
+	client    redis.UniversalClient
 	keyPrefix string
 }
 
 func InitConnection(cfg *models.Config, log *zap.Logger) *Conn {
 	log = log.Named("[REDIS]")
 	ctx := context.Background()
-	client := redis.NewClient(&redis.Options{
-		Addr: cfg.Redis.Host,
-		DB:   cfg.Redis.Database,
-	})
 
-	if err := client.Ping(ctx).Err(); err != nil {
+	client, err := NewClient(cfg.Redis)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("client: %v", err))
+	}
+
+	if err = client.Ping(ctx).Err(); err != nil {
 		log.Fatal(fmt.Sprintf("connection: %v", err))
 	}
 
 	return &Conn{
 		client:    client,
-		keyPrefix: "",
+		keyPrefix: tools.EnvString(cfg.Redis.KeyPrefix),
 	}
 }
 
+// NewClient creates client of the REDIS_MODE: single node, sentinel failover or cluster
+func NewClient(cfg models.Redis) (redis.UniversalClient, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            tools.SplitList(cfg.Host),
+		DB:               cfg.Database,
+		Username:         tools.EnvString(cfg.Username),
+		Password:         tools.EnvString(cfg.Password),
+		SentinelPassword: tools.EnvString(cfg.SentinelPassword),
+		MasterName:       tools.EnvString(cfg.MasterName),
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		MaxRetries:       cfg.MaxRetries,
+		DialTimeout:      time.Duration(cfg.DialTimeoutMs) * time.Millisecond,
+		ReadTimeout:      time.Duration(cfg.ReadTimeoutMs) * time.Millisecond,
+		WriteTimeout:     time.Duration(cfg.WriteTimeoutMs) * time.Millisecond,
+	}
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLSSkipVerify,
+		}
+	}
+	if len(opts.Addrs) == 0 {
+		return nil, errors.New("empty host")
+	}
+
+	switch cfg.Mode {
+	case consts.RedisModeSingle:
+		return redis.NewClient(opts.Simple()), nil
+	case consts.RedisModeSentinel:
+		if opts.MasterName == "" {
+			return nil, errors.New("sentinel mode requires master name")
+		}
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case consts.RedisModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	}
+	return nil, fmt.Errorf("unknown mode %q", cfg.Mode)
+}
+
 func (r *Conn) Get(ctx context.Context, key string) ([]byte, error) {
 	key = r.keyPrefix + key
 	result, err := r.client.Get(ctx, key).Bytes()
@@ -45,6 +105,28 @@ func (r *Conn) Get(ctx context.Context, key string) ([]byte, error) {
 	return result, nil
 }
 
+func (r *Conn) GetDel(ctx context.Context, key string) ([]byte, error) {
+	key = r.keyPrefix + key
+	result, err := r.client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return []byte{}, nil
+		}
+		return []byte{}, err
+	}
+	return result, nil
+}
+
+func (r *Conn) Incr(ctx context.Context, key string) (int64, error) {
+	key = r.keyPrefix + key
+	return incrExisting.Run(ctx, r.client, []string{key}).Int64()
+}
+
+func (r *Conn) IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = r.keyPrefix + key
+	return incrWithTTL.Run(ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
 func (r *Conn) Set(ctx context.Context, key string, value []byte) error {
 	key = r.keyPrefix + key
 	return r.client.Set(ctx, key, value, 0).Err()
@@ -60,10 +142,64 @@ func (r *Conn) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// FlushAll deletes keys of the namespace only, whole database is flushed without prefix
 func (r *Conn) FlushAll(ctx context.Context) error {
-	return r.client.FlushAll(ctx).Err()
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return r.flush(ctx, node)
+		})
+	}
+	return r.flush(ctx, r.client)
 }
 
 func (r *Conn) Close() error {
 	return r.client.Close()
 }
+
+func (r *Conn) flush(ctx context.Context, client redis.Cmdable) error {
+	if r.keyPrefix == "" {
+		return client.FlushAll(ctx).Err()
+	}
+
+	iter := client.Scan(ctx, 0, escapePattern(r.keyPrefix)+"*", consts.RedisScanCount).Iterator()
+	keys := make([]string, 0, consts.RedisScanCount)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) < consts.RedisScanCount {
+			continue
+		}
+		if err := unlink(ctx, client, keys); err != nil {
+			return err
+		}
+		keys = keys[:0]
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return unlink(ctx, client, keys)
+}
+
+// unlink deletes keys with single-key commands, keys of the batch might belong to different cluster slots
+func unlink(ctx context.Context, client redis.Cmdable, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		pipe.Unlink(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// escapePattern escapes glob special characters of the SCAN pattern
+func escapePattern(str string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`*`, `\*`,
+		`?`, `\?`,
+		`[`, `\[`,
+		`]`, `\]`,
+	).Replace(str)
+}
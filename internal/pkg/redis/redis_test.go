@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestConn(t *testing.T, srv *miniredis.Miniredis, keyPrefix string) *Conn {
+	t.Helper()
+
+	client, err := NewClient(models.Redis{
+		Host:     srv.Addr(),
+		Mode:     "single",
+		Username: `""`,
+		Password: `""`,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return &Conn{client: client, keyPrefix: keyPrefix}
+}
+
+func TestConn_KeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	conn := newTestConn(t, srv, "auth-api:prod:")
+
+	require.NoError(t, conn.SetWithTTL(ctx, "verify:user", []byte("123456"), time.Minute))
+	assert.True(t, srv.Exists("auth-api:prod:verify:user"))
+	assert.Equal(t, time.Minute, srv.TTL("auth-api:prod:verify:user"))
+
+	value, err := conn.Get(ctx, "verify:user")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("123456"), value)
+}
+
+func TestConn_GetDel(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	conn := newTestConn(t, srv, "auth-api:prod:")
+
+	require.NoError(t, conn.Set(ctx, "code", []byte("result")))
+
+	value, err := conn.GetDel(ctx, "code")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("result"), value)
+	assert.False(t, srv.Exists("auth-api:prod:code"))
+
+	value, err = conn.GetDel(ctx, "code")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
+
+func TestConn_Incr(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	conn := newTestConn(t, srv, "auth-api:prod:")
+
+	require.NoError(t, conn.SetWithTTL(ctx, "attempts", []byte("0"), time.Minute))
+	srv.FastForward(30 * time.Second)
+
+	value, err := conn.Incr(ctx, "attempts")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+	assert.Equal(t, 30*time.Second, srv.TTL("auth-api:prod:attempts"))
+
+	value, err = conn.Incr(ctx, "missing")
+	require.NoError(t, err)
+	assert.Zero(t, value)
+	assert.False(t, srv.Exists("auth-api:prod:missing"))
+}
+
+func TestConn_IncrWithTTL(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	conn := newTestConn(t, srv, "auth-api:prod:")
+
+	value, err := conn.IncrWithTTL(ctx, "window", time.Minute)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+	srv.FastForward(30 * time.Second)
+
+	value, err = conn.IncrWithTTL(ctx, "window", time.Minute)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, value)
+	assert.Equal(t, 30*time.Second, srv.TTL("auth-api:prod:window"))
+}
+
+func TestConn_FlushAll(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	prod := newTestConn(t, srv, "auth-api:prod:")
+	stage := newTestConn(t, srv, "auth-api:stage:")
+
+	for _, key := range []string{"ses:1", "ses:2", "verify:user"} {
+		require.NoError(t, prod.Set(ctx, key, []byte("prod")))
+		require.NoError(t, stage.Set(ctx, key, []byte("stage")))
+	}
+
+	require.NoError(t, stage.FlushAll(ctx))
+
+	assert.Len(t, srv.Keys(), 3)
+	value, err := prod.Get(ctx, "ses:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("prod"), value)
+}
+
+func TestNewClient(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     models.Redis
+		isValid bool
+	}{
+		{name: "single", cfg: models.Redis{Host: "localhost:6379", Mode: "single"}, isValid: true},
+		{name: "cluster", cfg: models.Redis{Host: "node1:6379,node2:6379", Mode: "cluster"}, isValid: true},
+		{name: "sentinel", cfg: models.Redis{Host: "s1:26379,s2:26379", Mode: "sentinel", MasterName: "mymaster"}, isValid: true},
+		{name: "sentinel without master", cfg: models.Redis{Host: "s1:26379", Mode: "sentinel", MasterName: `""`}},
+		{name: "unknown mode", cfg: models.Redis{Host: "localhost:6379", Mode: "unknown"}},
+		{name: "empty host", cfg: models.Redis{Host: "", Mode: "single"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, err := NewClient(testCase.cfg)
+			if !testCase.isValid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_ = client.Close()
+		})
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, `app\*:\[1\]:`, escapePattern("app*:[1]:"))
+}
@@ -1,11 +1,15 @@
 package tools
 
 import (
+	"archive/zip"
 	"auth-api/internal/models/consts"
+	"bytes"
 	"fmt"
 	"github.com/google/uuid"
 	"math/rand"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -13,6 +17,9 @@ import (
 var (
 	phoneNumberRegexpFn = regexp.MustCompile(consts.PhoneNumberRegexp)
 	emailRegexpFn       = regexp.MustCompile(consts.EmailRegexp)
+	localeRegexpFn      = regexp.MustCompile(consts.LocaleRegexp)
+	roleNameRegexpFn    = regexp.MustCompile(consts.RoleNameRegexp)
+	permissionRegexpFn  = regexp.MustCompile(consts.PermissionRegexp)
 )
 
 func CurrTimePtr() *time.Time {
@@ -69,3 +76,101 @@ func IsValidEmail(e string) bool {
 func IsValidPhoneNumber(e string) bool {
 	return phoneNumberRegexpFn.MatchString(e)
 }
+
+func IsValidRoleName(name string) bool {
+	return roleNameRegexpFn.MatchString(name)
+}
+
+func IsValidPermission(name string) bool {
+	return len(name) <= 128 && permissionRegexpFn.MatchString(name)
+}
+
+func IsValidLocale(locale string) bool {
+	return localeRegexpFn.MatchString(locale)
+}
+
+// IsValidReturnTo accepts paths of the client only, so redirects never leave it
+func IsValidReturnTo(path string) bool {
+	if len(path) > 2048 || !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n") {
+		return false
+	}
+	u, err := url.Parse(path)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// SplitList splits comma separated values and drops empty items
+func SplitList(str string) []string {
+	var result []string
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// EnvString returns value of the env variable that must be non-empty,
+// where "" stands for the empty string
+func EnvString(value string) string {
+	if value == `""` {
+		return ""
+	}
+	return value
+}
+
+// Truncate cuts string to the max length in runes
+func Truncate(str string, max int) string {
+	runes := []rune(str)
+	if len(runes) <= max {
+		return str
+	}
+	return string(runes[:max])
+}
+
+// ParseLocale returns the most preferred locale of the Accept-Language header
+// in lower case, empty string when there is no valid one
+func ParseLocale(acceptLanguage string) string {
+	locale, weight := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(params[0]))
+		if !localeRegexpFn.MatchString(tag) {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			value, ok := strings.CutPrefix(strings.TrimSpace(param), "q=")
+			if !ok {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			quality = parsed
+		}
+		if quality > weight {
+			locale, weight = tag, quality
+		}
+	}
+	return locale
+}
+
+// ZipFile packs data into a zip archive as a single file with the given name
+func ZipFile(name string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.Write(data); err != nil {
+		return nil, err
+	}
+	if err = archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
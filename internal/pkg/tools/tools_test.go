@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		locale         string
+	}{
+		{acceptLanguage: "", locale: ""},
+		{acceptLanguage: "ru", locale: "ru"},
+		{acceptLanguage: "en-US,en;q=0.9", locale: "en-us"},
+		{acceptLanguage: "kk;q=0.5, ru;q=0.8, en;q=0.1", locale: "ru"},
+		{acceptLanguage: "en;q=0.9, kk", locale: "kk"},
+		{acceptLanguage: "ru;q=0.9, en;q=0.9", locale: "ru"},
+		{acceptLanguage: "*, en;q=0.5", locale: "en"},
+		{acceptLanguage: "en;q=0, ru;q=0.1", locale: "ru"},
+		{acceptLanguage: "en;q=2", locale: ""},
+		{acceptLanguage: "not a locale", locale: ""},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.locale, ParseLocale(test.acceptLanguage), test.acceptLanguage)
+	}
+}
@@ -22,7 +22,7 @@ func NewCacheProcessor(provider interfaces.ICacheProvider, log *zap.Logger) *Cac
 }
 
 func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
-	log := c.log.With(zap.String("key", key), zap.String("value", string(value)))
+	log := c.log.With(zap.String("key", key))
 
 	err := c.provider.Set(ctx, key, value)
 	if err != nil {
@@ -34,9 +34,24 @@ func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
 	return nil
 }
 
+func (c *Cache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	log := c.log.With(
+		zap.String("key", key),
+		zap.Duration("ttl", ttl))
+
+	err := c.provider.SetWithTTL(ctx, key, value, ttl)
+	if err != nil {
+		log.Error(err.Error())
+		return errs.Wrap("cache.processor.SetWithTTL", err)
+	}
+
+	log.Info("setWithTTL")
+	return nil
+}
+
 func (c *Cache) SetJSON(ctx context.Context, key string, value interface{}) error {
 	raw, err := json.Marshal(value)
-	log := c.log.With(zap.String("key", key), zap.String("value", string(raw)))
+	log := c.log.With(zap.String("key", key))
 
 	if err != nil {
 		log.Error(err.Error())
@@ -57,7 +72,6 @@ func (c *Cache) SetJSONWithTTL(ctx context.Context, key string, value interface{
 	raw, err := json.Marshal(value)
 	log := c.log.With(
 		zap.String("key", key),
-		zap.String("value", string(raw)),
 		zap.Duration("ttl", ttl))
 
 	if err != nil {
@@ -107,6 +121,45 @@ func (c *Cache) GetJSON(ctx context.Context, key string, v interface{}) error {
 	return nil
 }
 
+func (c *Cache) GetDel(ctx context.Context, key string) ([]byte, error) {
+	log := c.log.With(zap.String("key", key))
+
+	raw, err := c.provider.GetDel(ctx, key)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, errs.Wrap("cache.processor.GetDel", err)
+	}
+
+	log.Info("getDel")
+	return raw, nil
+}
+
+func (c *Cache) Incr(ctx context.Context, key string) (int64, error) {
+	log := c.log.With(zap.String("key", key))
+
+	value, err := c.provider.Incr(ctx, key)
+	if err != nil {
+		log.Error(err.Error())
+		return 0, errs.Wrap("cache.processor.Incr", err)
+	}
+
+	log.Info("incr")
+	return value, nil
+}
+
+func (c *Cache) IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	log := c.log.With(zap.String("key", key))
+
+	value, err := c.provider.IncrWithTTL(ctx, key, ttl)
+	if err != nil {
+		log.Error(err.Error())
+		return 0, errs.Wrap("cache.processor.IncrWithTTL", err)
+	}
+
+	log.Info("incrWithTTL")
+	return value, nil
+}
+
 func (c *Cache) Delete(ctx context.Context, key string) error {
 	log := c.log.With(zap.String("key", key))
 
@@ -0,0 +1,29 @@
+package geoip
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"go.uber.org/zap"
+)
+
+type GeoIP struct {
+	provider interfaces.IGeoIPProvider
+	log      *zap.Logger
+}
+
+func NewGeoIPProcessor(provider interfaces.IGeoIPProvider, log *zap.Logger) *GeoIP {
+	return &GeoIP{
+		provider: provider,
+		log:      log,
+	}
+}
+
+// Locate never fails, unknown location is returned when lookup is not possible
+func (g *GeoIP) Locate(ip string) *models.Location {
+	location, err := g.provider.Lookup(ip)
+	if err != nil {
+		g.log.With(zap.String("ip", ip)).Debug(err.Error())
+		return &models.Location{}
+	}
+	return location
+}
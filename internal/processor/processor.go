@@ -4,6 +4,7 @@ import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
 	"auth-api/internal/processor/cache"
+	"auth-api/internal/processor/geoip"
 	"auth-api/internal/processor/queue"
 	"go.uber.org/zap"
 	"sync"
@@ -14,26 +15,35 @@ type Processor struct {
 	config                *models.Config
 	service               interfaces.IService
 	cacheProvider         interfaces.ICacheProvider
-	queueConsumerProvider interfaces.IQueueProducerProvider
+	queueProducerProvider interfaces.IQueueProducerProvider
+	queueConsumerProvider interfaces.IQueueConsumerProvider
+	geoIPProvider         interfaces.IGeoIPProvider
 
 	cacheProcessor       interfaces.ICacheProcessor
 	cacheProcessorRunner sync.Once
 
 	queueProcessor       interfaces.IQueueProcessor
 	queueProcessorRunner sync.Once
+
+	geoIPProcessor       interfaces.IGeoIPProcessor
+	geoIPProcessorRunner sync.Once
 }
 
 func InitProcessor(
 	log *zap.Logger,
 	config *models.Config,
 	service interfaces.IService,
-	queueConsumerProvider interfaces.IQueueProducerProvider,
-	cache interfaces.ICacheProvider) *Processor {
+	queueProducerProvider interfaces.IQueueProducerProvider,
+	queueConsumerProvider interfaces.IQueueConsumerProvider,
+	cache interfaces.ICacheProvider,
+	geoIPProvider interfaces.IGeoIPProvider) *Processor {
 	return &Processor{
 		log:                   log,
 		cacheProvider:         cache,
+		geoIPProvider:         geoIPProvider,
 		config:                config,
 		service:               service,
+		queueProducerProvider: queueProducerProvider,
 		queueConsumerProvider: queueConsumerProvider,
 	}
 }
@@ -47,7 +57,15 @@ func (p *Processor) Cache() interfaces.ICacheProcessor {
 
 func (p *Processor) Queue() interfaces.IQueueProcessor {
 	p.queueProcessorRunner.Do(func() {
-		p.queueProcessor = queue.NewQueueProcessor(p.config, p.queueConsumerProvider, p.log.Named("[QUEUE]"))
+		p.queueProcessor = queue.NewQueueProcessor(p.config, p.service,
+			p.queueProducerProvider, p.queueConsumerProvider, p.log.Named("[QUEUE]"))
 	})
 	return p.queueProcessor
 }
+
+func (p *Processor) GeoIP() interfaces.IGeoIPProcessor {
+	p.geoIPProcessorRunner.Do(func() {
+		p.geoIPProcessor = geoip.NewGeoIPProcessor(p.geoIPProvider, p.log.Named("[GEOIP]"))
+	})
+	return p.geoIPProcessor
+}
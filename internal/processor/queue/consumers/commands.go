@@ -0,0 +1,115 @@
+package consumers
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+)
+
+// CommandsConsumer dispatches inbound commands to the handlers registered by command type
+type CommandsConsumer struct {
+	log      *zap.Logger
+	config   *models.Config
+	service  interfaces.IService
+	provider interfaces.IQueueConsumerProvider
+
+	mu       sync.RWMutex
+	handlers map[models.CommandType]interfaces.CommandHandler
+}
+
+func NewCommandsConsumer(
+	config *models.Config,
+	service interfaces.IService,
+	provider interfaces.IQueueConsumerProvider,
+	log *zap.Logger) *CommandsConsumer {
+	cc := &CommandsConsumer{
+		log:      log,
+		config:   config,
+		service:  service,
+		provider: provider,
+		handlers: map[models.CommandType]interfaces.CommandHandler{},
+	}
+
+	cc.Register(models.CommandUserDeactivate, cc.deactivateUser)
+	cc.Register(models.CommandUserForceLogout, cc.forceLogout)
+	cc.Register(models.CommandEmailBounced, cc.emailBounced)
+	return cc
+}
+
+// Register sets handler of the command type, previous handler is replaced
+func (cc *CommandsConsumer) Register(commandType models.CommandType, handler interfaces.CommandHandler) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.handlers[commandType] = handler
+}
+
+// Run consumes commands queue until ctx is done
+func (cc *CommandsConsumer) Run(ctx context.Context) error {
+	return cc.provider.Consume(ctx, cc.config.CommandsQueue, cc.handle)
+}
+
+func (cc *CommandsConsumer) handle(ctx context.Context, message *models.QueueMessage) error {
+	var command models.Command
+	if err := json.Unmarshal(message.Body, &command); err != nil {
+		return fmt.Errorf("%w: %s", models.ErrInvalidQueueMessage, err)
+	}
+
+	cc.mu.RLock()
+	handler, ok := cc.handlers[command.Type]
+	cc.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: unknown command type %q", models.ErrInvalidQueueMessage, command.Type)
+	}
+
+	cc.log.Info("handle",
+		zap.String("id", command.ID),
+		zap.String("type", string(command.Type)))
+	return handler(ctx, command.Payload)
+}
+
+func (cc *CommandsConsumer) deactivateUser(ctx context.Context, payload []byte) error {
+	var p models.UserCommandPayload
+	if err := decodePayload(payload, &p); err != nil {
+		return err
+	}
+	return notFoundAsInvalid(cc.service.User().Deactivate(ctx, p.UserID, p.Reason))
+}
+
+func (cc *CommandsConsumer) forceLogout(ctx context.Context, payload []byte) error {
+	var p models.UserCommandPayload
+	if err := decodePayload(payload, &p); err != nil {
+		return err
+	}
+	return notFoundAsInvalid(cc.service.User().ForceLogout(ctx, p.UserID, p.Reason))
+}
+
+func (cc *CommandsConsumer) emailBounced(ctx context.Context, payload []byte) error {
+	var p models.EmailBouncedPayload
+	if err := decodePayload(payload, &p); err != nil {
+		return err
+	}
+	if p.Email == "" {
+		return fmt.Errorf("%w: empty email", models.ErrInvalidQueueMessage)
+	}
+	return cc.service.User().MarkEmailBounced(ctx, p.Email)
+}
+
+func decodePayload(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %s", models.ErrInvalidQueueMessage, err)
+	}
+	return nil
+}
+
+// notFoundAsInvalid prevents retries of the commands that target missing users
+func notFoundAsInvalid(err error) error {
+	if errors.Is(err, models.ErrUserNotFound) {
+		return fmt.Errorf("%w: %s", models.ErrInvalidQueueMessage, err)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package consumers_test
+
+import (
+	"auth-api/internal/manager"
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/processor/queue/consumers"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+)
+
+func TestCommandsConsumer_Dispatch(t *testing.T) {
+	cc, _ := newConsumer(t)
+	ctx := context.Background()
+
+	err := cc.Handle(ctx, &models.QueueMessage{ID: "broken", Body: []byte("{")})
+	require.ErrorIs(t, err, models.ErrInvalidQueueMessage)
+
+	err = cc.Handle(ctx, command(t, "user.unknown", struct{}{}))
+	require.ErrorIs(t, err, models.ErrInvalidQueueMessage)
+
+	var received []byte
+	handlerErr := errors.New("handler failed")
+	cc.Register("user.unknown", func(_ context.Context, payload []byte) error {
+		received = payload
+		return handlerErr
+	})
+	err = cc.Handle(ctx, command(t, "user.unknown", map[string]string{"user_id": "user"}))
+	require.ErrorIs(t, err, handlerErr)
+	require.JSONEq(t, `{"user_id": "user"}`, string(received))
+}
+
+func TestCommandsConsumer_Deactivate(t *testing.T) {
+	cc, m := newConsumer(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	session, err := m.Repository().Sessions().FindByToken(ctx, user.TenantID, response.Tokens.RefreshToken)
+	require.NoError(t, err)
+	require.NotNil(t, session)
+
+	payload := &models.UserCommandPayload{UserID: response.IDCode, Reason: "fraud"}
+	require.NoError(t, cc.Handle(ctx, command(t, models.CommandUserDeactivate, payload)))
+
+	user, err = m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.NotNil(t, user.DisabledAt)
+	requireSessionEnded(t, m, user.TenantID, response.Tokens.RefreshToken)
+	require.Len(t, managertest.PendingEvents(t, m, models.EventUserDisabled), 1)
+
+	// commands for missing users are not retried
+	payload.UserID = "missing"
+	err = cc.Handle(ctx, command(t, models.CommandUserDeactivate, payload))
+	require.ErrorIs(t, err, models.ErrInvalidQueueMessage)
+}
+
+func TestCommandsConsumer_ForceLogout(t *testing.T) {
+	cc, m := newConsumer(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	payload := &models.UserCommandPayload{UserID: response.IDCode, Reason: "stolen device"}
+	require.NoError(t, cc.Handle(ctx, command(t, models.CommandUserForceLogout, payload)))
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.Nil(t, user.DisabledAt)
+	requireSessionEnded(t, m, user.TenantID, response.Tokens.RefreshToken)
+
+	err = cc.Handle(ctx, command(t, models.CommandUserForceLogout, &models.UserCommandPayload{}))
+	require.ErrorIs(t, err, models.ErrInvalidQueueMessage)
+}
+
+func TestCommandsConsumer_EmailBounced(t *testing.T) {
+	cc, m := newConsumer(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	err := cc.Handle(ctx, command(t, models.CommandEmailBounced, &models.EmailBouncedPayload{}))
+	require.ErrorIs(t, err, models.ErrInvalidQueueMessage)
+
+	payload := &models.EmailBouncedPayload{Email: "user@example.com", Reason: "mailbox is full"}
+	require.NoError(t, cc.Handle(ctx, command(t, models.CommandEmailBounced, payload)))
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.NotNil(t, user.EmailBouncedAt)
+
+	events, err := m.Repository().AuthEvents().Find(ctx, &models.AuthEventsFilter{Type: models.AuthEventEmailBounced})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "user@example.com", events[0].Actor)
+	require.Equal(t, models.AuthResultSuccess, events[0].Result)
+}
+
+func newConsumer(t *testing.T) (*consumers.CommandsConsumer, *manager.Manager) {
+	m, queue := managertest.New(t)
+	return consumers.NewCommandsConsumer(&models.Config{}, m.Service(), queue, zap.NewNop()), m
+}
+
+func command(t *testing.T, commandType models.CommandType, payload interface{}) *models.QueueMessage {
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+	body, err := json.Marshal(&models.Command{ID: "command", Type: commandType, Payload: raw})
+	require.NoError(t, err)
+	return &models.QueueMessage{ID: "command", Body: body}
+}
+
+func requireSessionEnded(t *testing.T, m *manager.Manager, tenantID int64, refreshToken string) {
+	t.Helper()
+	session, err := m.Repository().Sessions().FindByToken(context.Background(), tenantID, refreshToken)
+	require.NoError(t, err)
+	require.Nil(t, session)
+}
@@ -0,0 +1,38 @@
+package consumers
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"go.uber.org/zap"
+	"sync"
+)
+
+type Consumers struct {
+	log              *zap.Logger
+	config           *models.Config
+	service          interfaces.IService
+	consumerProvider interfaces.IQueueConsumerProvider
+
+	commandsConsumer       interfaces.IQueueCommandsConsumerProcessor
+	commandsConsumerRunner sync.Once
+}
+
+func NewConsumersProcessor(
+	config *models.Config,
+	service interfaces.IService,
+	consumerProvider interfaces.IQueueConsumerProvider,
+	log *zap.Logger) *Consumers {
+	return &Consumers{
+		log:              log,
+		config:           config,
+		service:          service,
+		consumerProvider: consumerProvider,
+	}
+}
+
+func (c *Consumers) Commands() interfaces.IQueueCommandsConsumerProcessor {
+	c.commandsConsumerRunner.Do(func() {
+		c.commandsConsumer = NewCommandsConsumer(c.config, c.service, c.consumerProvider, c.log.Named("[COMMANDS]"))
+	})
+	return c.commandsConsumer
+}
@@ -0,0 +1,10 @@
+package consumers
+
+import (
+	"auth-api/internal/models"
+	"context"
+)
+
+func (cc *CommandsConsumer) Handle(ctx context.Context, message *models.QueueMessage) error {
+	return cc.handle(ctx, message)
+}
@@ -0,0 +1,85 @@
+package deadletters
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+)
+
+type DeadLetters struct {
+	log      *zap.Logger
+	config   *models.Config
+	provider interfaces.IQueueConsumerProvider
+}
+
+func NewDeadLettersProcessor(
+	config *models.Config,
+	provider interfaces.IQueueConsumerProvider,
+	log *zap.Logger) *DeadLetters {
+	return &DeadLetters{
+		log:      log,
+		config:   config,
+		provider: provider,
+	}
+}
+
+// Mails returns dead-lettered mails of the template
+func (dl *DeadLetters) Mails(ctx context.Context, templateID models.MailTemplate, limit int) ([]models.DeadLetteredMail, error) {
+	messages, err := dl.provider.PeekDeadLetters(ctx, dl.config.MailsQueue, limit,
+		func(message *models.QueueMessage) bool {
+			mail := decodeMail(message)
+			return mail != nil && mail.TemplateID == templateID
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.DeadLetteredMail, 0, len(messages))
+	for i := range messages {
+		mail := decodeMail(&messages[i])
+		result = append(result, models.DeadLetteredMail{
+			ID:         messages[i].ID,
+			TemplateID: mail.TemplateID,
+			SendTo:     mail.Recipient.Email,
+			Attempts:   messages[i].Attempts,
+			Reason:     messages[i].Reason,
+		})
+	}
+	return result, nil
+}
+
+// RequeueMails moves dead-lettered mails of the template with given ids back to the mails queue
+func (dl *DeadLetters) RequeueMails(ctx context.Context, templateID models.MailTemplate, ids []string) (int, error) {
+	requested := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		requested[id] = struct{}{}
+	}
+
+	requeued, err := dl.provider.RequeueDeadLetters(ctx, dl.config.MailsQueue, len(requested),
+		func(message *models.QueueMessage) bool {
+			if _, ok := requested[message.ID]; !ok {
+				return false
+			}
+			mail := decodeMail(message)
+			return mail != nil && mail.TemplateID == templateID
+		})
+	if err != nil {
+		return requeued, err
+	}
+
+	dl.log.Info("requeue mails",
+		zap.String("template_id", string(templateID)),
+		zap.Int("requested", len(requested)),
+		zap.Int("requeued", requeued))
+	return requeued, nil
+}
+
+func decodeMail(message *models.QueueMessage) *models.MailCommand {
+	mail := &models.MailCommand{}
+	if err := json.Unmarshal(message.Body, mail); err != nil {
+		return nil
+	}
+	return mail
+}
@@ -0,0 +1,45 @@
+package producers
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+)
+
+type EventsProducer struct {
+	log      *zap.Logger
+	config   *models.Config
+	provider interfaces.IQueueProducerProvider
+}
+
+func NewEventsProducer(
+	config *models.Config,
+	provider interfaces.IQueueProducerProvider,
+	log *zap.Logger) *EventsProducer {
+	return &EventsProducer{
+		log:      log,
+		config:   config,
+		provider: provider,
+	}
+}
+
+func (ep *EventsProducer) Publish(ctx context.Context, event *models.Event) error {
+	log := ep.log.With(
+		zap.String("id", event.ID),
+		zap.String("type", string(event.Type))).
+		Named("Publish")
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = ep.provider.Publish(ctx, ep.config.EventsExchange, string(event.Type), event.ID, body)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+	return nil
+}
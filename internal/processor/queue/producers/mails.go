@@ -3,6 +3,7 @@ package producers
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"context"
 	"encoding/json"
 	"go.uber.org/zap"
@@ -18,6 +19,11 @@ func NewMailsProducer(
 	config *models.Config,
 	provider interfaces.IQueueProducerProvider,
 	log *zap.Logger) *MailsProducer {
+	provider.SetQueueTopology(config.MailsQueue, &models.QueueTopology{
+		DeadLetter:  true,
+		RetryDelays: consts.MailsRetryDelays,
+	})
+
 	return &MailsProducer{
 		log:      log,
 		config:   config,
@@ -25,10 +31,12 @@ func NewMailsProducer(
 	}
 }
 
-func (mc *MailsProducer) Send(ctx context.Context, message *models.MailsProducerMsg) error {
+func (mc *MailsProducer) Send(ctx context.Context, message *models.MailCommand) error {
 	log := mc.log.With(
-		zap.String("send_to", message.SendTo),
-		zap.String("code", message.VerificationCode)).
+		zap.String("id", message.ID),
+		zap.String("template_id", string(message.TemplateID)),
+		zap.String("locale", message.Locale),
+		zap.String("send_to", message.Recipient.Email)).
 		Named("Send")
 
 	body, err := json.Marshal(message)
@@ -36,7 +44,10 @@ func (mc *MailsProducer) Send(ctx context.Context, message *models.MailsProducer
 		return err
 	}
 
-	if err = mc.provider.Send(ctx, mc.config.MailsQueue, body); err != nil {
+	headers := models.QueueHeaders{
+		consts.QueueHeaderMaxAttempts: int32(consts.MailsMaxAttempts),
+	}
+	if err = mc.provider.Send(ctx, mc.config.MailsQueue, message.ID, body, headers); err != nil {
 		log.Error(err.Error())
 		return err
 	}
@@ -3,6 +3,7 @@ package producers
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"context"
 	"go.uber.org/zap"
 	"sync"
 )
@@ -10,26 +11,52 @@ import (
 type Producers struct {
 	log              *zap.Logger
 	config           *models.Config
-	consumerProvider interfaces.IQueueProducerProvider
+	producerProvider interfaces.IQueueProducerProvider
 
 	mailsProducer       interfaces.IQueueProducerProcessor
 	mailsProducerRunner sync.Once
+
+	eventsProducer       interfaces.IQueueEventsProducerProcessor
+	eventsProducerRunner sync.Once
+
+	smsProducer       interfaces.IQueueSmsProducerProcessor
+	smsProducerRunner sync.Once
 }
 
 func NewProducersProcessor(
 	config *models.Config,
-	consumersProvider interfaces.IQueueProducerProvider,
+	producerProvider interfaces.IQueueProducerProvider,
 	log *zap.Logger) *Producers {
 	return &Producers{
 		log:              log,
 		config:           config,
-		consumerProvider: consumersProvider,
+		producerProvider: producerProvider,
 	}
 }
 
 func (p *Producers) Mails() interfaces.IQueueProducerProcessor {
 	p.mailsProducerRunner.Do(func() {
-		p.mailsProducer = NewMailsProducer(p.config, p.consumerProvider, p.log.Named("[MAILs]"))
+		p.mailsProducer = NewMailsProducer(p.config, p.producerProvider, p.log.Named("[MAILs]"))
 	})
 	return p.mailsProducer
 }
+
+func (p *Producers) Events() interfaces.IQueueEventsProducerProcessor {
+	p.eventsProducerRunner.Do(func() {
+		p.eventsProducer = NewEventsProducer(p.config, p.producerProvider, p.log.Named("[EVENTS]"))
+	})
+	return p.eventsProducer
+}
+
+func (p *Producers) Sms() interfaces.IQueueSmsProducerProcessor {
+	p.smsProducerRunner.Do(func() {
+		p.smsProducer = NewSmsProducer(p.config, p.producerProvider, p.log.Named("[SMS]"))
+	})
+	return p.smsProducer
+}
+
+// DeclareTopology declares queues of the producers created before, so the broker rejects
+// queues declared with other arguments on start
+func (p *Producers) DeclareTopology(ctx context.Context) error {
+	return p.producerProvider.DeclareTopology(ctx)
+}
@@ -0,0 +1,54 @@
+package producers
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+)
+
+type SmsProducer struct {
+	log      *zap.Logger
+	config   *models.Config
+	provider interfaces.IQueueProducerProvider
+}
+
+func NewSmsProducer(
+	config *models.Config,
+	provider interfaces.IQueueProducerProvider,
+	log *zap.Logger) *SmsProducer {
+	provider.SetQueueTopology(config.SmsQueue, &models.QueueTopology{
+		DeadLetter:  true,
+		RetryDelays: consts.SmsRetryDelays,
+	})
+
+	return &SmsProducer{
+		log:      log,
+		config:   config,
+		provider: provider,
+	}
+}
+
+func (sp *SmsProducer) Send(ctx context.Context, message *models.SmsCommand) error {
+	log := sp.log.With(
+		zap.String("id", message.ID),
+		zap.String("template_id", string(message.TemplateID)),
+		zap.String("locale", message.Locale)).
+		Named("Send")
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	headers := models.QueueHeaders{
+		consts.QueueHeaderMaxAttempts: int32(consts.SmsMaxAttempts),
+	}
+	if err = sp.provider.Send(ctx, sp.config.SmsQueue, message.ID, body, headers); err != nil {
+		log.Error(err.Error())
+		return err
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package queue
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"auth-api/internal/processor/queue/consumers"
+	"auth-api/internal/processor/queue/deadletters"
 	"auth-api/internal/processor/queue/producers"
 	"go.uber.org/zap"
 	"sync"
@@ -11,26 +13,52 @@ import (
 type Queue struct {
 	log              *zap.Logger
 	config           *models.Config
-	consumerProvider interfaces.IQueueProducerProvider
+	service          interfaces.IService
+	producerProvider interfaces.IQueueProducerProvider
+	consumerProvider interfaces.IQueueConsumerProvider
 
 	producersProcessor       interfaces.IQueueProducersProcessor
 	producersProcessorRunner sync.Once
+
+	consumersProcessor       interfaces.IQueueConsumersProcessor
+	consumersProcessorRunner sync.Once
+
+	deadLettersProcessor       interfaces.IQueueDeadLettersProcessor
+	deadLettersProcessorRunner sync.Once
 }
 
 func NewQueueProcessor(
 	config *models.Config,
-	consumersProvider interfaces.IQueueProducerProvider,
+	service interfaces.IService,
+	producerProvider interfaces.IQueueProducerProvider,
+	consumerProvider interfaces.IQueueConsumerProvider,
 	log *zap.Logger) *Queue {
 	return &Queue{
 		log:              log,
 		config:           config,
-		consumerProvider: consumersProvider,
+		service:          service,
+		producerProvider: producerProvider,
+		consumerProvider: consumerProvider,
 	}
 }
 
 func (q *Queue) Producers() interfaces.IQueueProducersProcessor {
 	q.producersProcessorRunner.Do(func() {
-		q.producersProcessor = producers.NewProducersProcessor(q.config, q.consumerProvider, q.log.Named("[PRODUCERS]"))
+		q.producersProcessor = producers.NewProducersProcessor(q.config, q.producerProvider, q.log.Named("[PRODUCERS]"))
 	})
 	return q.producersProcessor
 }
+
+func (q *Queue) Consumers() interfaces.IQueueConsumersProcessor {
+	q.consumersProcessorRunner.Do(func() {
+		q.consumersProcessor = consumers.NewConsumersProcessor(q.config, q.service, q.consumerProvider, q.log.Named("[CONSUMERS]"))
+	})
+	return q.consumersProcessor
+}
+
+func (q *Queue) DeadLetters() interfaces.IQueueDeadLettersProcessor {
+	q.deadLettersProcessorRunner.Do(func() {
+		q.deadLettersProcessor = deadletters.NewDeadLettersProcessor(q.config, q.consumerProvider, q.log.Named("[DEAD-LETTERS]"))
+	})
+	return q.deadLettersProcessor
+}
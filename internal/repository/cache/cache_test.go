@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/memory"
+	"auth-api/internal/processor/cache"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTTL = 50 * time.Millisecond
+
+func newTestCache(t *testing.T) *cache.Cache {
+	t.Helper()
+
+	provider := memory.NewCache()
+	t.Cleanup(func() { _ = provider.Close() })
+	return cache.NewCacheProcessor(provider, zap.NewNop())
+}
+
+func TestSessionCacheRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := InitSessionCacheRepository(newTestCache(t))
+	repo.ttl = testTTL
+
+	require.NoError(t, repo.Set(ctx, 1, "user", 1700000000))
+
+	startedAt, err := repo.Get(ctx, 1, "user")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), startedAt)
+
+	assert.Eventually(t, func() bool {
+		startedAt, err := repo.Get(ctx, 1, "user")
+		return err == nil && startedAt == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOAuthCacheRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := InitOAuthCacheRepository(models.GoogleOAuth, newTestCache(t))
+	repo.ttl = testTTL
+
+	state := &models.OAuthState{CreatedAt: 1700000000, LinkUserID: 7}
+	require.NoError(t, repo.Set(ctx, 1, "state", state))
+
+	found, err := repo.Get(ctx, 1, "state")
+	require.NoError(t, err)
+	assert.Equal(t, state, found)
+
+	assert.Eventually(t, func() bool {
+		found, err := repo.Get(ctx, 1, "state")
+		return err == nil && found == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOAuthCacheRepository_ProviderScoped(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+	google := InitOAuthCacheRepository(models.GoogleOAuth, c)
+	github := InitOAuthCacheRepository(models.GitHubOAuth, c)
+
+	require.NoError(t, google.Set(ctx, 1, "state", &models.OAuthState{CreatedAt: 1700000000}))
+
+	found, err := github.Get(ctx, 1, "state")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	found, err = google.Get(ctx, 1, "state")
+	require.NoError(t, err)
+	assert.NotNil(t, found)
+
+	// every provider uses the same key format
+	assert.Equal(t, "oauth2:google:1:state", google.constructKey(1, "state"))
+	assert.Equal(t, "oauth2:github:1:state", github.constructKey(1, "state"))
+}
+
+func TestVerificationCodesRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := InitVerificationCodesRepository(newTestCache(t))
+	repo.ttl = testTTL
+
+	require.NoError(t, repo.Set(ctx, 1, "user@example.com", "123456"))
+
+	code, err := repo.Get(ctx, 1, "user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "123456", code)
+
+	assert.Eventually(t, func() bool {
+		code, err := repo.Get(ctx, 1, "user@example.com")
+		return err == nil && code == ""
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRepositories_DefaultTTL(t *testing.T) {
+	c := newTestCache(t)
+
+	assert.NotZero(t, InitSessionCacheRepository(c).ttl)
+	assert.NotZero(t, InitOAuthCacheRepository(models.GoogleOAuth, c).ttl)
+	assert.NotZero(t, InitVerificationCodesRepository(c).ttl)
+	assert.NotZero(t, InitContactChangesRepository(c).ttl)
+	assert.NotZero(t, InitOAuthResultsRepository(c).ttl)
+}
+
+func TestVerificationCodesRepository_TenantScoped(t *testing.T) {
+	ctx := context.Background()
+	repo := InitVerificationCodesRepository(newTestCache(t))
+
+	require.NoError(t, repo.Set(ctx, 1, "user@example.com", "123456"))
+
+	code, err := repo.Get(ctx, 2, "user@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, code)
+
+	code, err = repo.Get(ctx, 1, "user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "123456", code)
+}
+
+func TestContactChangesRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := InitContactChangesRepository(newTestCache(t))
+	repo.ttl = testTTL
+
+	change := &models.ContactChange{Kind: models.ContactEmail, Value: "new@example.com", Code: "123456"}
+	require.NoError(t, repo.Set(ctx, 1, "user", change))
+
+	found, err := repo.Get(ctx, 1, "user", models.ContactEmail)
+	require.NoError(t, err)
+	assert.Equal(t, change, found)
+
+	found, err = repo.Get(ctx, 1, "user", models.ContactPhone)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	assert.Eventually(t, func() bool {
+		found, err := repo.Get(ctx, 1, "user", models.ContactEmail)
+		return err == nil && found == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestContactChangesRepository_Attempt(t *testing.T) {
+	ctx := context.Background()
+	repo := InitContactChangesRepository(newTestCache(t))
+	repo.ttl = testTTL
+
+	change := &models.ContactChange{Kind: models.ContactEmail, Value: "new@example.com", Code: "123456"}
+	require.NoError(t, repo.Set(ctx, 1, "user", change))
+
+	attempts, err := repo.Attempt(ctx, 1, "user", models.ContactEmail)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, attempts)
+
+	// wrong codes do not extend the change
+	assert.Eventually(t, func() bool {
+		_, err := repo.Attempt(ctx, 1, "user", models.ContactEmail)
+		found, getErr := repo.Get(ctx, 1, "user", models.ContactEmail)
+		return err == nil && getErr == nil && found == nil
+	}, time.Second, 10*time.Millisecond)
+
+	attempts, err = repo.Attempt(ctx, 1, "user", models.ContactEmail)
+	require.NoError(t, err)
+	assert.Zero(t, attempts)
+
+	// the new change starts the new counter
+	require.NoError(t, repo.Set(ctx, 1, "user", change))
+	attempts, err = repo.Attempt(ctx, 1, "user", models.ContactEmail)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, attempts)
+}
+
+func TestOAuthResultsRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := InitOAuthResultsRepository(newTestCache(t))
+	repo.ttl = testTTL
+
+	result := &models.AuthResponse{
+		UserDTO: models.UserDTO{Email: "user@example.com"},
+		Tokens:  &models.Tokens{AccessToken: "access", RefreshToken: "refresh"},
+	}
+	require.NoError(t, repo.Set(ctx, 1, "code", result))
+
+	found, err := repo.GetDel(ctx, 2, "code")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	assert.Eventually(t, func() bool {
+		found, err := repo.GetDel(ctx, 1, "code")
+		return err == nil && found == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOAuthResultsRepository_GetDelOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := InitOAuthResultsRepository(newTestCache(t))
+
+	result := &models.AuthResponse{
+		UserDTO: models.UserDTO{Email: "user@example.com"},
+		Tokens:  &models.Tokens{AccessToken: "access", RefreshToken: "refresh"},
+	}
+	require.NoError(t, repo.Set(ctx, 1, "code", result))
+
+	var (
+		wg    sync.WaitGroup
+		taken atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := repo.GetDel(ctx, 1, "code")
+			if err == nil && found != nil {
+				assert.Equal(t, result, found)
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), taken.Load())
+
+	found, err := repo.GetDel(ctx, 1, "code")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
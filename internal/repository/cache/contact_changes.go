@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+type ContactChangesRepository struct {
+	cache interfaces.ICacheProcessor
+	ttl   time.Duration
+}
+
+func InitContactChangesRepository(cache interfaces.ICacheProcessor) *ContactChangesRepository {
+	return &ContactChangesRepository{
+		cache: cache,
+		ttl:   consts.ContactChangeTTL,
+	}
+}
+
+// Get returns nil when there is no pending change
+func (c *ContactChangesRepository) Get(
+	ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) (*models.ContactChange, error) {
+	key := c.constructKey(tenantID, userIDCode, kind)
+
+	raw, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return nil, errs.Wrap("contact_changes.Get", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	change := &models.ContactChange{}
+	if err = json.Unmarshal(raw, change); err != nil {
+		return nil, errs.Wrap("contact_changes.Get: unmarshal", err)
+	}
+	return change, nil
+}
+
+// Set stores the change with the new attempts counter, the counter is stored first,
+// so the change never lives without it
+func (c *ContactChangesRepository) Set(
+	ctx context.Context, tenantID int64, userIDCode string, change *models.ContactChange) error {
+	key := c.constructKey(tenantID, userIDCode, change.Kind)
+
+	err := c.cache.SetWithTTL(ctx, attemptsKey(key), []byte("0"), c.ttl)
+	if err != nil {
+		return errs.Wrap("contact_changes.Set: attempts", err)
+	}
+	err = c.cache.SetJSONWithTTL(ctx, key, change, c.ttl)
+	if err != nil {
+		return errs.Wrap("contact_changes.Set", err)
+	}
+	return nil
+}
+
+// Attempt counts the wrong code atomically and returns the number of wrong codes,
+// the change keeps its expiry. Zero is returned when the counter has expired
+func (c *ContactChangesRepository) Attempt(
+	ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) (int64, error) {
+	key := c.constructKey(tenantID, userIDCode, kind)
+
+	attempts, err := c.cache.Incr(ctx, attemptsKey(key))
+	if err != nil {
+		return 0, errs.Wrap("contact_changes.Attempt", err)
+	}
+	return attempts, nil
+}
+
+func (c *ContactChangesRepository) Delete(
+	ctx context.Context, tenantID int64, userIDCode string, kind models.ContactKind) error {
+	key := c.constructKey(tenantID, userIDCode, kind)
+
+	err := c.cache.Delete(ctx, key)
+	if err != nil {
+		return errs.Wrap("contact_changes.Delete", err)
+	}
+	err = c.cache.Delete(ctx, attemptsKey(key))
+	if err != nil {
+		return errs.Wrap("contact_changes.Delete: attempts", err)
+	}
+	return nil
+}
+
+func (c *ContactChangesRepository) constructKey(tenantID int64, userIDCode string, kind models.ContactKind) string {
+	return fmt.Sprintf("%s:%d:%s:%s", consts.CacheContactPrefix, tenantID, userIDCode, kind)
+}
+
+func attemptsKey(key string) string {
+	return key + ":attempts"
+}
@@ -5,10 +5,10 @@ import (
 	"auth-api/internal/models"
 	"auth-api/internal/models/consts"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/doxanocap/pkg/errs"
 	"log"
-	"strconv"
 	"time"
 )
 
@@ -30,36 +30,35 @@ func InitOAuthCacheRepository(
 	}
 }
 
-func (c *OAuthCacheRepository) Get(ctx context.Context, code string) (int64, error) {
-	key := c.constructKey(code)
+// Get returns nil when there is no such state
+func (c *OAuthCacheRepository) Get(ctx context.Context, tenantID int64, code string) (*models.OAuthState, error) {
+	key := c.constructKey(tenantID, code)
 	raw, err := c.cache.Get(ctx, key)
 	if err != nil {
-		return 0, errs.Wrap("oauth_cache.Get", err)
+		return nil, errs.Wrap("oauth_cache.Get", err)
 	}
-	rawStr := string(raw)
-	if rawStr == "" {
-		return 0, nil
+	if len(raw) == 0 {
+		return nil, nil
 	}
 
-	value, err := strconv.Atoi(rawStr)
-	if err != nil {
-		return 0, errs.Wrap("oauth_cache.Get: convert", err)
+	state := &models.OAuthState{}
+	if err = json.Unmarshal(raw, state); err != nil {
+		return nil, errs.Wrap("oauth_cache.Get: unmarshal", err)
 	}
-	return int64(value), nil
+	return state, nil
 }
 
-func (c *OAuthCacheRepository) Set(ctx context.Context, code string, value int64) error {
-	key := c.constructKey(code)
-	rawValue := strconv.Itoa(int(value))
-	err := c.cache.Set(ctx, key, []byte(rawValue))
+func (c *OAuthCacheRepository) Set(ctx context.Context, tenantID int64, code string, state *models.OAuthState) error {
+	key := c.constructKey(tenantID, code)
+	err := c.cache.SetJSONWithTTL(ctx, key, state, c.ttl)
 	if err != nil {
 		return errs.Wrap("oauth_cache.Set", err)
 	}
 	return nil
 }
 
-func (c *OAuthCacheRepository) Delete(ctx context.Context, code string) error {
-	key := c.constructKey(code)
+func (c *OAuthCacheRepository) Delete(ctx context.Context, tenantID int64, code string) error {
+	key := c.constructKey(tenantID, code)
 	err := c.cache.Delete(ctx, key)
 	if err != nil {
 		return errs.Wrap("oauth_cache.Delete", err)
@@ -67,11 +66,6 @@ func (c *OAuthCacheRepository) Delete(ctx context.Context, code string) error {
 	return nil
 }
 
-func (c *OAuthCacheRepository) constructKey(code string) string {
-	switch c.provider {
-	case models.GoogleOAuth:
-		return fmt.Sprintf("%s:%s", consts.CacheGoogleOAuthPrefix, code)
-	default:
-		return ""
-	}
+func (c *OAuthCacheRepository) constructKey(tenantID int64, code string) string {
+	return fmt.Sprintf("%s:%s:%d:%s", consts.CacheOAuthPrefix, c.provider, tenantID, code)
 }
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+// OAuthResultsRepository keeps sessions of the provider call backs until the client exchanges their codes
+type OAuthResultsRepository struct {
+	cache interfaces.ICacheProcessor
+	ttl   time.Duration
+}
+
+func InitOAuthResultsRepository(cache interfaces.ICacheProcessor) *OAuthResultsRepository {
+	return &OAuthResultsRepository{
+		cache: cache,
+		ttl:   consts.OAuthResultTTL,
+	}
+}
+
+// GetDel returns the result and deletes its code atomically, so the code is taken only once.
+// Returns nil when there is no such code
+func (c *OAuthResultsRepository) GetDel(ctx context.Context, tenantID int64, code string) (*models.AuthResponse, error) {
+	key := c.constructKey(tenantID, code)
+	raw, err := c.cache.GetDel(ctx, key)
+	if err != nil {
+		return nil, errs.Wrap("oauth_results.GetDel", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	result := &models.AuthResponse{}
+	if err = json.Unmarshal(raw, result); err != nil {
+		return nil, errs.Wrap("oauth_results.GetDel: unmarshal", err)
+	}
+	return result, nil
+}
+
+func (c *OAuthResultsRepository) Set(ctx context.Context, tenantID int64, code string, result *models.AuthResponse) error {
+	key := c.constructKey(tenantID, code)
+	err := c.cache.SetJSONWithTTL(ctx, key, result, c.ttl)
+	if err != nil {
+		return errs.Wrap("oauth_results.Set", err)
+	}
+	return nil
+}
+
+func (c *OAuthResultsRepository) constructKey(tenantID int64, code string) string {
+	return fmt.Sprintf("%s:%d:%s", consts.CacheOAuthResultPrefix, tenantID, code)
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models/consts"
+	"context"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+type ReauthAttemptsRepository struct {
+	cache  interfaces.ICacheProcessor
+	window time.Duration
+}
+
+func InitReauthAttemptsRepository(cache interfaces.ICacheProcessor) *ReauthAttemptsRepository {
+	return &ReauthAttemptsRepository{
+		cache:  cache,
+		window: consts.ReauthAttemptsWindow,
+	}
+}
+
+// Incr counts the password check of the user and returns the number of checks in the window
+func (c *ReauthAttemptsRepository) Incr(ctx context.Context, tenantID int64, userIDCode string) (int64, error) {
+	attempts, err := c.cache.IncrWithTTL(ctx, c.constructKey(tenantID, userIDCode), c.window)
+	if err != nil {
+		return 0, errs.Wrap("reauth_attempts.Incr", err)
+	}
+	return attempts, nil
+}
+
+// Delete resets the window after the successful check
+func (c *ReauthAttemptsRepository) Delete(ctx context.Context, tenantID int64, userIDCode string) error {
+	err := c.cache.Delete(ctx, c.constructKey(tenantID, userIDCode))
+	if err != nil {
+		return errs.Wrap("reauth_attempts.Delete", err)
+	}
+	return nil
+}
+
+func (c *ReauthAttemptsRepository) constructKey(tenantID int64, userIDCode string) string {
+	return fmt.Sprintf("%s:%d:%s", consts.CacheReauthPrefix, tenantID, userIDCode)
+}
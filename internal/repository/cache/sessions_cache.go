@@ -22,14 +22,14 @@ func InitSessionCacheRepository(cache interfaces.ICacheProcessor) *SessionCacheR
 	}
 }
 
-func (c *SessionCacheRepository) Get(ctx context.Context, userIDCode string) (int64, error) {
-	key := c.constructKey(userIDCode)
+func (c *SessionCacheRepository) Get(ctx context.Context, tenantID int64, userIDCode string) (int64, error) {
+	key := c.constructKey(tenantID, userIDCode)
 
 	raw, err := c.cache.Get(ctx, key)
 	if err != nil {
 		return 0, errs.Wrap("sessions_cache.Get", err)
 	}
-	if raw == nil {
+	if len(raw) == 0 {
 		return 0, nil
 	}
 
@@ -41,17 +41,27 @@ func (c *SessionCacheRepository) Get(ctx context.Context, userIDCode string) (in
 	return int64(value), nil
 }
 
-func (c *SessionCacheRepository) Set(ctx context.Context, userIDCode string, startedAt int64) error {
-	key := c.constructKey(userIDCode)
+func (c *SessionCacheRepository) Set(ctx context.Context, tenantID int64, userIDCode string, startedAt int64) error {
+	key := c.constructKey(tenantID, userIDCode)
 
 	value := strconv.Itoa(int(startedAt))
-	err := c.cache.Set(ctx, key, []byte(value))
+	err := c.cache.SetWithTTL(ctx, key, []byte(value), c.ttl)
 	if err != nil {
 		return errs.Wrap("sessions_cache.Set", err)
 	}
 	return nil
 }
 
-func (c *SessionCacheRepository) constructKey(userIDCode string) string {
-	return fmt.Sprintf("%s:%s", consts.CacheSessionsPrefix, userIDCode)
+func (c *SessionCacheRepository) Delete(ctx context.Context, tenantID int64, userIDCode string) error {
+	key := c.constructKey(tenantID, userIDCode)
+
+	err := c.cache.Delete(ctx, key)
+	if err != nil {
+		return errs.Wrap("sessions_cache.Delete", err)
+	}
+	return nil
+}
+
+func (c *SessionCacheRepository) constructKey(tenantID int64, userIDCode string) string {
+	return fmt.Sprintf("%s:%d:%s", consts.CacheSessionsPrefix, tenantID, userIDCode)
 }
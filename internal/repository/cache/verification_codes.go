@@ -21,8 +21,8 @@ func InitVerificationCodesRepository(cache interfaces.ICacheProcessor) *Verifica
 	}
 }
 
-func (c *VerificationCodesRepository) Get(ctx context.Context, email string) (string, error) {
-	key := c.constructKey(email)
+func (c *VerificationCodesRepository) Get(ctx context.Context, tenantID int64, email string) (string, error) {
+	key := c.constructKey(tenantID, email)
 
 	raw, err := c.cache.Get(ctx, key)
 	if err != nil {
@@ -31,10 +31,16 @@ func (c *VerificationCodesRepository) Get(ctx context.Context, email string) (st
 	return string(raw), nil
 }
 
-func (c *VerificationCodesRepository) Set(ctx context.Context, email, code string) error {
-	key := c.constructKey(email)
+// Set stores the code with the new attempts counter, the counter is stored first,
+// so the code never lives without it
+func (c *VerificationCodesRepository) Set(ctx context.Context, tenantID int64, email, code string) error {
+	key := c.constructKey(tenantID, email)
 
-	err := c.cache.Set(ctx, key, []byte(code))
+	err := c.cache.SetWithTTL(ctx, attemptsKey(key), []byte("0"), c.ttl)
+	if err != nil {
+		return errs.Wrap("verification_codes.Set: attempts", err)
+	}
+	err = c.cache.SetWithTTL(ctx, key, []byte(code), c.ttl)
 	if err != nil {
 		return errs.Wrap("verification_codes.Set", err)
 	}
@@ -42,17 +48,33 @@ func (c *VerificationCodesRepository) Set(ctx context.Context, email, code strin
 	return nil
 }
 
-func (c *VerificationCodesRepository) Delete(ctx context.Context, email string) error {
-	key := c.constructKey(email)
+// Attempt counts the check of the code atomically and returns the number of checks,
+// the code keeps its expiry. Zero is returned when the counter has expired
+func (c *VerificationCodesRepository) Attempt(ctx context.Context, tenantID int64, email string) (int64, error) {
+	key := c.constructKey(tenantID, email)
+
+	attempts, err := c.cache.Incr(ctx, attemptsKey(key))
+	if err != nil {
+		return 0, errs.Wrap("verification_codes.Attempt", err)
+	}
+	return attempts, nil
+}
+
+func (c *VerificationCodesRepository) Delete(ctx context.Context, tenantID int64, email string) error {
+	key := c.constructKey(tenantID, email)
 
 	err := c.cache.Delete(ctx, key)
 	if err != nil {
 		return errs.Wrap("verification_codes.Delete", err)
 	}
+	err = c.cache.Delete(ctx, attemptsKey(key))
+	if err != nil {
+		return errs.Wrap("verification_codes.Delete: attempts", err)
+	}
 
 	return nil
 }
 
-func (c *VerificationCodesRepository) constructKey(key string) string {
-	return fmt.Sprintf("%s:%s", consts.CacheVerifyCodePrefix, key)
+func (c *VerificationCodesRepository) constructKey(tenantID int64, key string) string {
+	return fmt.Sprintf("%s:%d:%s", consts.CacheVerifyCodePrefix, tenantID, key)
 }
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"sort"
+)
+
+type AuthEventsRepository struct {
+	store *Store
+}
+
+func InitAuthEventsRepository(store *Store) *AuthEventsRepository {
+	return &AuthEventsRepository{
+		store: store,
+	}
+}
+
+// Create ...
+func (repo *AuthEventsRepository) Create(_ context.Context, event *models.AuthEvent) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	event.ID = repo.store.nextID()
+	repo.store.authEvents[event.ID] = *event
+	return nil
+}
+
+// EraseByUserID removes personal data from the events of the user
+func (repo *AuthEventsRepository) EraseByUserID(_ context.Context, userID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, event := range repo.store.authEvents {
+		if event.UserIDRef != nil && *event.UserIDRef == userID {
+			event.Actor, event.IP, event.UserAgent = "", "", ""
+			repo.store.authEvents[id] = event
+		}
+	}
+	return nil
+}
+
+// Find returns events matching the filter, the newest first
+func (repo *AuthEventsRepository) Find(_ context.Context, filter *models.AuthEventsFilter) ([]models.AuthEvent, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	events := repo.filter(filter)
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+	return page(events, filter.Limit, filter.Offset), nil
+}
+
+// Count ...
+func (repo *AuthEventsRepository) Count(_ context.Context, filter *models.AuthEventsFilter) (int64, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return int64(len(repo.filter(filter))), nil
+}
+
+func (repo *AuthEventsRepository) filter(filter *models.AuthEventsFilter) []models.AuthEvent {
+	events := []models.AuthEvent{}
+	for _, id := range sortedIDs(repo.store.authEvents) {
+		event := repo.store.authEvents[id]
+		if !repo.matches(&event, filter) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func (repo *AuthEventsRepository) matches(event *models.AuthEvent, filter *models.AuthEventsFilter) bool {
+	var user *models.User
+	if event.UserIDRef != nil {
+		if found, ok := repo.store.users[*event.UserIDRef]; ok {
+			user = &found
+		}
+	}
+
+	switch {
+	case filter.TenantID != 0 && event.TenantID != filter.TenantID:
+		return false
+	case filter.UserIDRef != nil && (event.UserIDRef == nil || *event.UserIDRef != *filter.UserIDRef):
+		return false
+	case filter.UserIDCode != "" && (user == nil || user.IDCode != filter.UserIDCode):
+		return false
+	case filter.Actor != "" && event.Actor != filter.Actor:
+		return false
+	case filter.Type != "" && event.Type != filter.Type:
+		return false
+	case filter.Result != "" && event.Result != filter.Result:
+		return false
+	case filter.IP != "" && event.IP != filter.IP:
+		return false
+	case filter.From != nil && event.CreatedAt.Before(*filter.From):
+		return false
+	case filter.To != nil && !event.CreatedAt.Before(*filter.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"time"
+)
+
+type DataExportsRepository struct {
+	store *Store
+}
+
+func InitDataExportsRepository(store *Store) *DataExportsRepository {
+	return &DataExportsRepository{
+		store: store,
+	}
+}
+
+// Create stores pending export, nothing is stored when the user already has a pending one
+func (repo *DataExportsRepository) Create(_ context.Context, export *models.DataExport) (bool, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, stored := range repo.store.dataExports {
+		if stored.UserIDRef == export.UserIDRef && stored.Status == models.ExportStatusPending {
+			return false, nil
+		}
+	}
+	export.Status = models.ExportStatusPending
+	export.CreatedAt = time.Now()
+	repo.store.dataExports[export.ID] = *export
+	return true, nil
+}
+
+// FindByID ...
+func (repo *DataExportsRepository) FindByID(_ context.Context, exportID string) (*models.DataExport, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	export, ok := repo.store.dataExports[exportID]
+	if !ok {
+		return nil, nil
+	}
+	return &export, nil
+}
+
+// FetchPending returns the oldest pending export
+func (repo *DataExportsRepository) FetchPending(_ context.Context) (*models.DataExport, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	var oldest *models.DataExport
+	for _, export := range repo.store.dataExports {
+		if export.Status != models.ExportStatusPending {
+			continue
+		}
+		if oldest == nil || export.CreatedAt.Before(oldest.CreatedAt) {
+			found := export
+			oldest = &found
+		}
+	}
+	return oldest, nil
+}
+
+// MarkReady ...
+func (repo *DataExportsRepository) MarkReady(_ context.Context, exportID string, content []byte, expiresAt time.Time) error {
+	return repo.update(exportID, func(export *models.DataExport) {
+		export.Status = models.ExportStatusReady
+		export.Content = content
+		export.ReadyAt = timePtr(time.Now())
+		export.ExpiresAt = &expiresAt
+	})
+}
+
+// MarkFailed ...
+func (repo *DataExportsRepository) MarkFailed(_ context.Context, exportID string, reason string) error {
+	return repo.update(exportID, func(export *models.DataExport) {
+		export.Status = models.ExportStatusFailed
+		export.LastError = reason
+	})
+}
+
+// DeleteExpired removes exports which could not be downloaded anymore
+func (repo *DataExportsRepository) DeleteExpired(_ context.Context, now time.Time) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, export := range repo.store.dataExports {
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(now) {
+			delete(repo.store.dataExports, id)
+		}
+	}
+	return nil
+}
+
+func (repo *DataExportsRepository) DeleteByUserID(_ context.Context, userID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, export := range repo.store.dataExports {
+		if export.UserIDRef == userID {
+			delete(repo.store.dataExports, id)
+		}
+	}
+	return nil
+}
+
+func (repo *DataExportsRepository) update(exportID string, fn func(export *models.DataExport)) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	export, ok := repo.store.dataExports[exportID]
+	if !ok {
+		return nil
+	}
+	fn(&export)
+	repo.store.dataExports[exportID] = export
+	return nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"time"
+)
+
+type OutboxRepository struct {
+	store *Store
+}
+
+func InitOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{
+		store: store,
+	}
+}
+
+// Create stores message, already stored message with the same message_id is kept as is
+func (repo *OutboxRepository) Create(_ context.Context, message *models.OutboxMessage) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, stored := range repo.store.outbox {
+		if stored.MessageID == message.MessageID {
+			return nil
+		}
+	}
+	now := time.Now()
+	repo.store.outbox[repo.store.nextID()] = models.OutboxMessage{
+		MessageID:     message.MessageID,
+		Topic:         message.Topic,
+		Payload:       append([]byte{}, message.Payload...),
+		UserIDRef:     message.UserIDRef,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+	return nil
+}
+
+// Claim returns messages ready to be published and moves their next attempt by the lease
+func (repo *OutboxRepository) Claim(_ context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	now := time.Now()
+	var messages []models.OutboxMessage
+	for _, id := range sortedIDs(repo.store.outbox) {
+		message := repo.store.outbox[id]
+		if message.PublishedAt != nil || message.DeadAt != nil || message.NextAttemptAt.After(now) {
+			continue
+		}
+		message.ID = id
+		message.NextAttemptAt = now.Add(lease)
+		repo.store.outbox[id] = message
+		messages = append(messages, message)
+		if len(messages) == limit {
+			break
+		}
+	}
+	return messages, nil
+}
+
+// MarkPublished marks the message as published and drops payloads of mails and sms
+func (repo *OutboxRepository) MarkPublished(_ context.Context, id int64) error {
+	return repo.update(id, func(message *models.OutboxMessage) {
+		message.Attempts++
+		message.PublishedAt = timePtr(time.Now())
+		redact(message)
+	})
+}
+
+// MarkFailed ...
+func (repo *OutboxRepository) MarkFailed(_ context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	return repo.update(id, func(message *models.OutboxMessage) {
+		message.Attempts++
+		message.LastError = reason
+		message.NextAttemptAt = nextAttemptAt
+	})
+}
+
+// MarkDead stops retries of the message and drops payloads of mails and sms
+func (repo *OutboxRepository) MarkDead(_ context.Context, id int64, reason string) error {
+	return repo.update(id, func(message *models.OutboxMessage) {
+		message.Attempts++
+		message.LastError = reason
+		message.DeadAt = timePtr(time.Now())
+		redact(message)
+	})
+}
+
+// Release makes the claimed message ready to be published again without counting the attempt
+func (repo *OutboxRepository) Release(_ context.Context, id int64) error {
+	return repo.update(id, func(message *models.OutboxMessage) {
+		message.NextAttemptAt = time.Now()
+	})
+}
+
+// DeletePublished removes messages published before the given time
+func (repo *OutboxRepository) DeletePublished(_ context.Context, before time.Time) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, message := range repo.store.outbox {
+		if message.PublishedAt != nil && message.PublishedAt.Before(before) {
+			delete(repo.store.outbox, id)
+		}
+	}
+	return nil
+}
+
+// DeleteByUserID removes messages of the user
+func (repo *OutboxRepository) DeleteByUserID(_ context.Context, userID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, message := range repo.store.outbox {
+		if message.UserIDRef != nil && *message.UserIDRef == userID {
+			delete(repo.store.outbox, id)
+		}
+	}
+	return nil
+}
+
+func (repo *OutboxRepository) update(id int64, fn func(message *models.OutboxMessage)) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	message, ok := repo.store.outbox[id]
+	if !ok {
+		return nil
+	}
+	fn(&message)
+	repo.store.outbox[id] = message
+	return nil
+}
+
+func redact(message *models.OutboxMessage) {
+	if message.Topic == models.OutboxTopicMails || message.Topic == models.OutboxTopicSms {
+		message.Payload = []byte("{}")
+	}
+}
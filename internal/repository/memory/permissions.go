@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+type PermissionsRepository struct {
+	store *Store
+}
+
+func InitPermissionsRepository(store *Store) *PermissionsRepository {
+	return &PermissionsRepository{
+		store: store,
+	}
+}
+
+// FindAll returns permissions ordered by name
+func (repo *PermissionsRepository) FindAll(_ context.Context) ([]models.Permission, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	permissions := make([]models.Permission, 0, len(repo.store.permissions))
+	for _, permission := range repo.store.permissions {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].Name < permissions[j].Name })
+	return permissions, nil
+}
+
+// Save creates permission or updates description of the existing one
+func (repo *PermissionsRepository) Save(_ context.Context, permission *models.Permission) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, stored := range repo.store.permissions {
+		if stored.Name == permission.Name {
+			stored.Description = permission.Description
+			repo.store.permissions[id] = stored
+			permission.ID, permission.CreatedAt = stored.ID, stored.CreatedAt
+			return nil
+		}
+	}
+
+	permission.ID = repo.store.nextID()
+	permission.CreatedAt = timePtr(time.Now())
+	repo.store.permissions[permission.ID] = *permission
+	return nil
+}
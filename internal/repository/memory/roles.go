@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+type RolesRepository struct {
+	store *Store
+}
+
+func InitRolesRepository(store *Store) *RolesRepository {
+	return &RolesRepository{
+		store: store,
+	}
+}
+
+// FindAll returns roles of the tenant ordered by name
+func (repo *RolesRepository) FindAll(_ context.Context, tenantID int64) ([]models.Role, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.collect(func(role *models.Role) bool { return role.TenantID == tenantID }), nil
+}
+
+func (repo *RolesRepository) FindByName(_ context.Context, tenantID int64, name string) (*models.Role, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	roles := repo.collect(func(role *models.Role) bool { return role.TenantID == tenantID && role.Name == name })
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	return &roles[0], nil
+}
+
+// FindByUserID returns roles assigned to the user
+func (repo *RolesRepository) FindByUserID(_ context.Context, userID int64) ([]models.Role, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	assigned := repo.store.userRoles[userID]
+	return repo.collect(func(role *models.Role) bool { return assigned[role.ID] }), nil
+}
+
+// Save creates role or updates description of the existing one
+func (repo *RolesRepository) Save(_ context.Context, role *models.Role) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, stored := range repo.store.roles {
+		if stored.TenantID == role.TenantID && stored.Name == role.Name {
+			stored.Description = role.Description
+			repo.store.roles[id] = stored
+			role.ID, role.CreatedAt = stored.ID, stored.CreatedAt
+			return nil
+		}
+	}
+
+	role.ID = repo.store.nextID()
+	role.CreatedAt = timePtr(time.Now())
+	repo.store.roles[role.ID] = models.Role{
+		ID:          role.ID,
+		TenantID:    role.TenantID,
+		Name:        role.Name,
+		Description: role.Description,
+		CreatedAt:   role.CreatedAt,
+	}
+	repo.store.rolePermissions[role.ID] = map[int64]bool{}
+	return nil
+}
+
+// SetPermissions replaces permissions of the role, unknown permission names are skipped
+// and the number of granted permissions is returned
+func (repo *RolesRepository) SetPermissions(_ context.Context, roleID int64, permissions []string) (int64, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	names := map[string]bool{}
+	for _, name := range permissions {
+		names[name] = true
+	}
+	granted := map[int64]bool{}
+	for id, permission := range repo.store.permissions {
+		if names[permission.Name] {
+			granted[id] = true
+		}
+	}
+	repo.store.rolePermissions[roleID] = granted
+	return int64(len(granted)), nil
+}
+
+func (repo *RolesRepository) Delete(_ context.Context, roleID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.roles, roleID)
+	delete(repo.store.rolePermissions, roleID)
+	for _, assigned := range repo.store.userRoles {
+		delete(assigned, roleID)
+	}
+	return nil
+}
+
+func (repo *RolesRepository) Assign(_ context.Context, userID, roleID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	if repo.store.userRoles[userID] == nil {
+		repo.store.userRoles[userID] = map[int64]bool{}
+	}
+	repo.store.userRoles[userID][roleID] = true
+	return nil
+}
+
+func (repo *RolesRepository) Unassign(_ context.Context, userID, roleID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.userRoles[userID], roleID)
+	return nil
+}
+
+// collect returns matching roles with names of their permissions, ordered by name
+func (repo *RolesRepository) collect(match func(role *models.Role) bool) []models.Role {
+	roles := []models.Role{}
+	for _, id := range sortedIDs(repo.store.roles) {
+		role := repo.store.roles[id]
+		if !match(&role) {
+			continue
+		}
+		role.Permissions = []string{}
+		for permissionID := range repo.store.rolePermissions[role.ID] {
+			role.Permissions = append(role.Permissions, repo.store.permissions[permissionID].Name)
+		}
+		sort.Strings(role.Permissions)
+		roles = append(roles, role)
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"time"
+)
+
+type SessionsRepository struct {
+	store *Store
+}
+
+func InitSessionsRepository(store *Store) *SessionsRepository {
+	return &SessionsRepository{
+		store: store,
+	}
+}
+
+// Create ...
+func (repo *SessionsRepository) Create(_ context.Context, session *models.Session) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	session.ID = repo.store.nextID()
+	repo.store.sessions[session.ID] = *session
+	return nil
+}
+
+// FindByID ...
+func (repo *SessionsRepository) FindByID(_ context.Context, sessionID int64) (*models.Session, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	session, ok := repo.store.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// FindByToken ...
+func (repo *SessionsRepository) FindByToken(_ context.Context, tenantID int64, refreshToken string) (*models.Session, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, id := range sortedIDs(repo.store.sessions) {
+		session := repo.store.sessions[id]
+		if session.TenantID == tenantID && session.RefreshToken == refreshToken {
+			return &session, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindByUserID returns the latest sessions of the user
+func (repo *SessionsRepository) FindByUserID(_ context.Context, userID int64, limit uint64) ([]models.Session, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	sessions := []models.Session{}
+	ids := sortedIDs(repo.store.sessions)
+	for i := len(ids) - 1; i >= 0; i-- {
+		session := repo.store.sessions[ids[i]]
+		if session.UserIDRef == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return page(sessions, limit, 0), nil
+}
+
+// UpdateByID ...
+func (repo *SessionsRepository) UpdateByID(_ context.Context, session *models.Session) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	stored, ok := repo.store.sessions[session.ID]
+	if !ok {
+		return nil
+	}
+	repo.store.sessions[session.ID] = updated(stored, session)
+	return nil
+}
+
+// UpdateByUserID ...
+func (repo *SessionsRepository) UpdateByUserID(_ context.Context, session *models.Session) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, id := range sortedIDs(repo.store.sessions) {
+		stored := repo.store.sessions[id]
+		if stored.UserIDRef == session.UserIDRef {
+			repo.store.sessions[id] = updated(stored, session)
+			session.ID = id
+		}
+	}
+	return nil
+}
+
+// EndSession ...
+func (repo *SessionsRepository) EndSession(_ context.Context, sessionID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	if session, ok := repo.store.sessions[sessionID]; ok {
+		repo.store.sessions[sessionID] = ended(session)
+	}
+	return nil
+}
+
+// EndByUserID ends all active sessions of the user and returns their ids
+func (repo *SessionsRepository) EndByUserID(_ context.Context, userID int64) ([]int64, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	var sessionIDs []int64
+	for _, id := range sortedIDs(repo.store.sessions) {
+		session := repo.store.sessions[id]
+		if session.UserIDRef == userID && session.RefreshToken != "" {
+			repo.store.sessions[id] = ended(session)
+			sessionIDs = append(sessionIDs, id)
+		}
+	}
+	return sessionIDs, nil
+}
+
+// DeleteByID ...
+func (repo *SessionsRepository) DeleteByID(_ context.Context, sessionID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.sessions, sessionID)
+	return nil
+}
+
+// updated copies fields the postgres update statements set
+func updated(stored models.Session, session *models.Session) models.Session {
+	stored.IP = session.IP
+	stored.UserAgent = session.UserAgent
+	stored.DeviceName = session.DeviceName
+	stored.RefreshToken = session.RefreshToken
+	stored.StartedAt = session.StartedAt
+	stored.EndedAt = session.EndedAt
+	return stored
+}
+
+func ended(session models.Session) models.Session {
+	endedAt := time.Now().Unix()
+	session.IP = ""
+	session.UserAgent = ""
+	session.DeviceName = ""
+	session.RefreshToken = ""
+	session.EndedAt = &endedAt
+	return session
+}
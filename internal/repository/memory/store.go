@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store keeps rows of all repositories in memory for development and tests. It is seeded
+// like migrations seed postgres: the default tenant, permissions and the admin role.
+// Transactions are not isolated, changes made before a failure are not rolled back
+type Store struct {
+	mu  sync.Mutex
+	seq int64
+
+	tenants         map[int64]models.Tenant
+	users           map[int64]models.User
+	sessions        map[int64]models.Session
+	roles           map[int64]models.Role
+	permissions     map[int64]models.Permission
+	rolePermissions map[int64]map[int64]bool
+	userRoles       map[int64]map[int64]bool
+	devices         map[int64]models.UserDevice
+	identities      map[int64]models.UserIdentity
+	authEvents      map[int64]models.AuthEvent
+	dataExports     map[string]models.DataExport
+	outbox          map[int64]models.OutboxMessage
+}
+
+// defaultPermissions are seeded by the rbac migration
+var defaultPermissions = []models.Permission{
+	{Name: consts.PermissionUsersRead, Description: "View users, their sessions and activity"},
+	{Name: consts.PermissionUsersManage, Description: "Disable, enable, log out and delete users"},
+	{Name: consts.PermissionRolesManage, Description: "Manage roles, permissions and their assignments"},
+	{Name: consts.PermissionAuditRead, Description: "Search authentication audit log"},
+	{Name: consts.PermissionMailsManage, Description: "Inspect and requeue dead-lettered mails"},
+}
+
+func NewStore() *Store {
+	s := &Store{
+		tenants:         map[int64]models.Tenant{},
+		users:           map[int64]models.User{},
+		sessions:        map[int64]models.Session{},
+		roles:           map[int64]models.Role{},
+		permissions:     map[int64]models.Permission{},
+		rolePermissions: map[int64]map[int64]bool{},
+		userRoles:       map[int64]map[int64]bool{},
+		devices:         map[int64]models.UserDevice{},
+		identities:      map[int64]models.UserIdentity{},
+		authEvents:      map[int64]models.AuthEvent{},
+		dataExports:     map[string]models.DataExport{},
+		outbox:          map[int64]models.OutboxMessage{},
+	}
+
+	for _, permission := range defaultPermissions {
+		permission.ID = s.nextID()
+		permission.CreatedAt = timePtr(time.Now())
+		s.permissions[permission.ID] = permission
+	}
+	s.addTenant(models.Tenant{
+		Code:        consts.DefaultTenantCode,
+		Name:        "Default",
+		AllowSignUp: true,
+		AllowGoogle: true,
+	})
+	return s
+}
+
+// AddTenant stores the tenant with its admin role, like a tenant inserted into postgres
+func (s *Store) AddTenant(tenant *models.Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*tenant = s.addTenant(*tenant)
+}
+
+// Transaction runs fn, there is no isolation and nothing is rolled back on error
+func (s *Store) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (s *Store) addTenant(tenant models.Tenant) models.Tenant {
+	tenant.ID = s.nextID()
+	tenant.CreatedAt = timePtr(time.Now())
+	s.tenants[tenant.ID] = tenant
+
+	role := models.Role{
+		ID:          s.nextID(),
+		TenantID:    tenant.ID,
+		Name:        consts.RoleAdmin,
+		Description: "Full access to the admin api",
+		CreatedAt:   timePtr(time.Now()),
+	}
+	s.roles[role.ID] = role
+	s.rolePermissions[role.ID] = map[int64]bool{}
+	for id := range s.permissions {
+		s.rolePermissions[role.ID][id] = true
+	}
+	return tenant
+}
+
+func (s *Store) nextID() int64 {
+	s.seq++
+	return s.seq
+}
+
+// sortedIDs returns keys of the rows in ascending order
+func sortedIDs[T any](rows map[int64]T) []int64 {
+	ids := make([]int64, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// page cuts the rows by limit and offset, zero limit returns everything after offset
+func page[T any](rows []T, limit, offset uint64) []T {
+	if offset >= uint64(len(rows)) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < uint64(len(rows)) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
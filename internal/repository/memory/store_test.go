@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewStore_SeedsDefaultTenant(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	tenants, err := InitTenantsRepository(store).FindAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	require.Equal(t, consts.DefaultTenantCode, tenants[0].Code)
+
+	admin, err := InitRolesRepository(store).FindByName(ctx, tenants[0].ID, consts.RoleAdmin)
+	require.NoError(t, err)
+	require.NotNil(t, admin)
+	require.ElementsMatch(t, []string{
+		consts.PermissionAuditRead,
+		consts.PermissionMailsManage,
+		consts.PermissionRolesManage,
+		consts.PermissionUsersManage,
+		consts.PermissionUsersRead,
+	}, admin.Permissions)
+}
+
+func TestUsersRepository_EmailIsUniqueInTenant(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	tenant := &models.Tenant{Code: "shop", Name: "Shop"}
+	store.AddTenant(tenant)
+	users := InitUsersRepository(store)
+
+	require.NoError(t, users.Create(ctx, &models.User{TenantID: 1, Email: "user@example.com"}))
+	require.Error(t, users.Create(ctx, &models.User{TenantID: 1, Email: "user@example.com"}))
+	require.NoError(t, users.Create(ctx, &models.User{TenantID: tenant.ID, Email: "user@example.com"}))
+
+	found, err := users.FindByEmail(ctx, tenant.ID, "user@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.Equal(t, tenant.ID, found.TenantID)
+
+	found, err = users.FindByEmail(ctx, tenant.ID, "other@example.com")
+	require.NoError(t, err)
+	require.Nil(t, found)
+}
+
+func TestSessionsRepository_EndByUserID(t *testing.T) {
+	ctx := context.Background()
+	sessions := InitSessionsRepository(NewStore())
+
+	active := &models.Session{TenantID: 1, UserIDRef: 1, RefreshToken: "token"}
+	other := &models.Session{TenantID: 1, UserIDRef: 2, RefreshToken: "other"}
+	require.NoError(t, sessions.Create(ctx, active))
+	require.NoError(t, sessions.Create(ctx, other))
+
+	ended, err := sessions.EndByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []int64{active.ID}, ended)
+
+	found, err := sessions.FindByToken(ctx, 1, "token")
+	require.NoError(t, err)
+	require.Nil(t, found)
+
+	found, err = sessions.FindByID(ctx, active.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.EndedAt)
+
+	found, err = sessions.FindByToken(ctx, 1, "other")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+}
+
+func TestOutboxRepository_Claim(t *testing.T) {
+	ctx := context.Background()
+	outbox := InitOutboxRepository(NewStore())
+
+	for _, id := range []string{"published", "failed", "dead", "pending"} {
+		require.NoError(t, outbox.Create(ctx, &models.OutboxMessage{MessageID: id, Topic: models.OutboxTopicMails, Payload: []byte(`{"code":"123456"}`)}))
+	}
+	require.NoError(t, outbox.Create(ctx, &models.OutboxMessage{MessageID: "pending", Topic: models.OutboxTopicSms}))
+
+	messages, err := outbox.Claim(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	ids := map[string]int64{}
+	for _, message := range messages {
+		ids[message.MessageID] = message.ID
+	}
+
+	require.NoError(t, outbox.MarkPublished(ctx, ids["published"]))
+	require.NoError(t, outbox.MarkFailed(ctx, ids["failed"], "failed", time.Now().Add(time.Hour)))
+	require.NoError(t, outbox.MarkDead(ctx, ids["dead"], "failed"))
+
+	messages, err = outbox.Claim(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, "pending", messages[0].MessageID)
+	require.Equal(t, models.OutboxTopicMails, messages[0].Topic)
+
+	// claimed messages are skipped until the lease expires
+	messages, err = outbox.Claim(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+
+	for _, id := range []string{"published", "dead"} {
+		message := outbox.store.outbox[ids[id]]
+		require.JSONEq(t, `{}`, string(message.Payload), id)
+	}
+	require.NotNil(t, outbox.store.outbox[ids["dead"]].DeadAt)
+}
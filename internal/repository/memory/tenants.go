@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+)
+
+type TenantsRepository struct {
+	store *Store
+}
+
+func InitTenantsRepository(store *Store) *TenantsRepository {
+	return &TenantsRepository{
+		store: store,
+	}
+}
+
+// FindAll ...
+func (repo *TenantsRepository) FindAll(_ context.Context) ([]models.Tenant, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	tenants := make([]models.Tenant, 0, len(repo.store.tenants))
+	for _, id := range sortedIDs(repo.store.tenants) {
+		tenants = append(tenants, repo.store.tenants[id])
+	}
+	return tenants, nil
+}
+
+// SealSecrets does nothing, tenants are never written to disk
+func (repo *TenantsRepository) SealSecrets(_ context.Context) error {
+	return nil
+}
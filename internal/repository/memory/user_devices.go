@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+)
+
+type UserDevicesRepository struct {
+	store *Store
+}
+
+func InitUserDevicesRepository(store *Store) *UserDevicesRepository {
+	return &UserDevicesRepository{
+		store: store,
+	}
+}
+
+// FindByUserID ...
+func (repo *UserDevicesRepository) FindByUserID(_ context.Context, userID int64) ([]models.UserDevice, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	var devices []models.UserDevice
+	for _, id := range sortedIDs(repo.store.devices) {
+		if device := repo.store.devices[id]; device.UserIDRef == userID {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+// Save inserts new device or refreshes last_seen_at of the known one
+func (repo *UserDevicesRepository) Save(_ context.Context, device *models.UserDevice) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, stored := range repo.store.devices {
+		if stored.UserIDRef == device.UserIDRef && stored.IP == device.IP && stored.UserAgent == device.UserAgent {
+			stored.LastSeenAt = device.LastSeenAt
+			repo.store.devices[id] = stored
+			device.ID = id
+			return nil
+		}
+	}
+
+	device.ID = repo.store.nextID()
+	repo.store.devices[device.ID] = *device
+	return nil
+}
+
+func (repo *UserDevicesRepository) DeleteByUserID(_ context.Context, userID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, device := range repo.store.devices {
+		if device.UserIDRef == userID {
+			delete(repo.store.devices, id)
+		}
+	}
+	return nil
+}
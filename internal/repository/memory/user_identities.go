@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"time"
+)
+
+type UserIdentitiesRepository struct {
+	store *Store
+}
+
+func InitUserIdentitiesRepository(store *Store) *UserIdentitiesRepository {
+	return &UserIdentitiesRepository{
+		store: store,
+	}
+}
+
+// Create ...
+func (repo *UserIdentitiesRepository) Create(_ context.Context, identity *models.UserIdentity) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, stored := range repo.store.identities {
+		if stored.TenantID == identity.TenantID && stored.Provider == identity.Provider && stored.Subject == identity.Subject {
+			return errs.New("repo.user_identities.Create: identity already exists")
+		}
+	}
+
+	identity.ID = repo.store.nextID()
+	identity.CreatedAt = time.Now()
+	identity.LastUsedAt = identity.CreatedAt
+	repo.store.identities[identity.ID] = *identity
+	return nil
+}
+
+// FindBySubject ...
+func (repo *UserIdentitiesRepository) FindBySubject(
+	_ context.Context, tenantID int64, provider models.OAuthProvider, subject string) (*models.UserIdentity, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, identity := range repo.store.identities {
+		if identity.TenantID == tenantID && identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindByUserID ...
+func (repo *UserIdentitiesRepository) FindByUserID(_ context.Context, userID int64) ([]models.UserIdentity, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	identities := []models.UserIdentity{}
+	for _, id := range sortedIDs(repo.store.identities) {
+		if identity := repo.store.identities[id]; identity.UserIDRef == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+// Touch saves the email the provider gave on the last sign in
+func (repo *UserIdentitiesRepository) Touch(_ context.Context, identity *models.UserIdentity) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	stored, ok := repo.store.identities[identity.ID]
+	if !ok {
+		return nil
+	}
+	stored.Email = identity.Email
+	stored.EmailVerified = identity.EmailVerified
+	stored.LastUsedAt = time.Now()
+	repo.store.identities[identity.ID] = stored
+	return nil
+}
+
+// Delete unlinks the provider from the user, reports whether there was such identity
+func (repo *UserIdentitiesRepository) Delete(_ context.Context, userID int64, provider models.OAuthProvider) (bool, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	deleted := false
+	for id, identity := range repo.store.identities {
+		if identity.UserIDRef == userID && identity.Provider == provider {
+			delete(repo.store.identities, id)
+			deleted = true
+		}
+	}
+	return deleted, nil
+}
+
+func (repo *UserIdentitiesRepository) DeleteByUserID(_ context.Context, userID int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, identity := range repo.store.identities {
+		if identity.UserIDRef == userID {
+			delete(repo.store.identities, id)
+		}
+	}
+	return nil
+}
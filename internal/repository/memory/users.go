@@ -0,0 +1,240 @@
+package memory
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"strings"
+	"time"
+)
+
+type UsersRepository struct {
+	store *Store
+}
+
+func InitUsersRepository(store *Store) *UsersRepository {
+	return &UsersRepository{
+		store: store,
+	}
+}
+
+// Create ...
+func (repo *UsersRepository) Create(_ context.Context, user *models.User) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, u := range repo.store.users {
+		if u.TenantID == user.TenantID && u.Email == user.Email && u.DeletedAt == nil {
+			return errs.New("repo.user.Create: email is already taken")
+		}
+	}
+	user.ID = repo.store.nextID()
+	repo.store.users[user.ID] = *user
+	return nil
+}
+
+func (repo *UsersRepository) FindByID(_ context.Context, id int64) (*models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.find(func(u *models.User) bool { return u.ID == id }), nil
+}
+
+func (repo *UsersRepository) FindByUserIDCode(_ context.Context, tenantID int64, userIDCode string) (*models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.find(func(u *models.User) bool { return u.TenantID == tenantID && u.IDCode == userIDCode }), nil
+}
+
+// FindByUserIDCodeInAnyTenant is for commands of other services that address users by id only
+func (repo *UsersRepository) FindByUserIDCodeInAnyTenant(_ context.Context, userIDCode string) (*models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.find(func(u *models.User) bool { return u.IDCode == userIDCode }), nil
+}
+
+func (repo *UsersRepository) FindByEmail(_ context.Context, tenantID int64, email string) (*models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.find(func(u *models.User) bool {
+		return u.TenantID == tenantID && u.Email == email && u.DeletedAt == nil
+	}), nil
+}
+
+// FindWSessionByToken ...
+func (repo *UsersRepository) FindWSessionByToken(_ context.Context, tenantID int64, refreshToken string) (*models.UserSession, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, id := range sortedIDs(repo.store.sessions) {
+		session := repo.store.sessions[id]
+		if session.RefreshToken != refreshToken {
+			continue
+		}
+		user, ok := repo.store.users[session.UserIDRef]
+		if !ok || user.TenantID != tenantID {
+			return nil, nil
+		}
+		us := &models.UserSession{
+			UserID:        user.ID,
+			UserIDCode:    user.IDCode,
+			TenantID:      user.TenantID,
+			UserEmail:     user.Email,
+			UserActivated: user.Activated,
+		}
+		if session.StartedAt != nil {
+			us.StartedAt = *session.StartedAt
+		}
+		return us, nil
+	}
+	return nil, nil
+}
+
+func (repo *UsersRepository) Update(_ context.Context, user *models.User) error {
+	return repo.update(user.ID, func(u *models.User) {
+		u.Email = user.Email
+		u.PhoneNumber = user.PhoneNumber
+		u.Activated = user.Activated
+		u.EmailBouncedAt = user.EmailBouncedAt
+		u.FirstName = user.FirstName
+		u.LastName = user.LastName
+		u.Locale = user.Locale
+		u.DisplayName = user.DisplayName
+		u.PictureURL = user.PictureURL
+		user.UpdatedAt = u.UpdatedAt
+	})
+}
+
+func (repo *UsersRepository) SetPassword(_ context.Context, userID int64, hashedPassword string) error {
+	return repo.update(userID, func(u *models.User) { u.Password = hashedPassword })
+}
+
+func (repo *UsersRepository) SetDisabledAt(_ context.Context, userID int64, disabledAt *time.Time) error {
+	return repo.update(userID, func(u *models.User) { u.DisabledAt = disabledAt })
+}
+
+func (repo *UsersRepository) SetEmailBouncedAt(_ context.Context, tenantID int64, email string, bouncedAt *time.Time) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, u := range repo.store.users {
+		if u.TenantID == tenantID && u.Email == email {
+			u.EmailBouncedAt = bouncedAt
+			u.UpdatedAt = timePtr(time.Now())
+			repo.store.users[id] = u
+		}
+	}
+	return nil
+}
+
+func (repo *UsersRepository) SetEraseAt(_ context.Context, userID int64, eraseAt *time.Time) error {
+	return repo.update(userID, func(u *models.User) { u.EraseAt = eraseAt })
+}
+
+// FindDueForErasure returns the user whose grace period has passed
+func (repo *UsersRepository) FindDueForErasure(_ context.Context, now time.Time) (*models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	var due *models.User
+	for _, u := range repo.store.users {
+		if u.EraseAt == nil || u.ErasedAt != nil || u.EraseAt.After(now) {
+			continue
+		}
+		if due == nil || u.EraseAt.Before(*due.EraseAt) {
+			found := u
+			due = &found
+		}
+	}
+	return due, nil
+}
+
+// Erase removes personal data of the user, the row itself is kept for references
+func (repo *UsersRepository) Erase(_ context.Context, userID int64) error {
+	return repo.update(userID, func(u *models.User) {
+		u.Email = ""
+		u.PhoneNumber = ""
+		u.Password = ""
+		u.FirstName = ""
+		u.LastName = ""
+		u.DisplayName = ""
+		u.PictureURL = ""
+		u.EraseAt = nil
+		u.ErasedAt = u.UpdatedAt
+		if u.DeletedAt == nil {
+			u.DeletedAt = u.UpdatedAt
+		}
+	})
+}
+
+// Search returns users matching the filter, the newest first
+func (repo *UsersRepository) Search(_ context.Context, filter *models.UsersFilter) ([]models.User, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	users := repo.filter(filter)
+	for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+		users[i], users[j] = users[j], users[i]
+	}
+	return page(users, filter.Limit, filter.Offset), nil
+}
+
+// Count ...
+func (repo *UsersRepository) Count(_ context.Context, filter *models.UsersFilter) (int64, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return int64(len(repo.filter(filter))), nil
+}
+
+func (repo *UsersRepository) find(match func(u *models.User) bool) *models.User {
+	for _, id := range sortedIDs(repo.store.users) {
+		user := repo.store.users[id]
+		if match(&user) {
+			return &user
+		}
+	}
+	return nil
+}
+
+// update changes the stored user and its updated_at, unknown users are skipped like in postgres
+func (repo *UsersRepository) update(userID int64, fn func(u *models.User)) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	user, ok := repo.store.users[userID]
+	if !ok {
+		return nil
+	}
+	user.UpdatedAt = timePtr(time.Now())
+	fn(&user)
+	repo.store.users[userID] = user
+	return nil
+}
+
+func (repo *UsersRepository) filter(filter *models.UsersFilter) []models.User {
+	users := []models.User{}
+	for _, id := range sortedIDs(repo.store.users) {
+		u := repo.store.users[id]
+		if u.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.Email != "" && !strings.Contains(strings.ToLower(u.Email), strings.ToLower(filter.Email)) {
+			continue
+		}
+		if filter.PhoneNumber != "" && !strings.Contains(u.PhoneNumber, filter.PhoneNumber) {
+			continue
+		}
+		if filter.OAuthProvider != "" && u.OAuthProvider != filter.OAuthProvider {
+			continue
+		}
+		if filter.Status != "" && u.Status() != filter.Status {
+			continue
+		}
+		users = append(users, u)
+	}
+	return users
+}
@@ -0,0 +1,112 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuthEventsRepository struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func InitAuthEventsRepository(db *sqlx.DB) *AuthEventsRepository {
+	return &AuthEventsRepository{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Create ...
+func (repo *AuthEventsRepository) Create(ctx context.Context, event *models.AuthEvent) (err error) {
+	defer errs.WrapIfErr("repo.auth_events.Create", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into auth_events
+		(tenant_id, user_idref, actor, event_type, result, event_ip, user_agent, session_idref, reason, created_at)
+		values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		returning event_id`,
+		event.TenantID, event.UserIDRef, event.Actor, event.Type, event.Result, event.IP,
+		event.UserAgent, event.SessionIDRef, event.Reason, event.CreatedAt).
+		Scan(&event.ID)
+	return
+}
+
+// EraseByUserID removes personal data from the events of the user
+func (repo *AuthEventsRepository) EraseByUserID(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.auth_events.EraseByUserID", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update auth_events
+		set actor = '', event_ip = '', user_agent = ''
+		where user_idref = $1`, userID)
+	return
+}
+
+// Find returns events matching the filter, the newest first
+func (repo *AuthEventsRepository) Find(ctx context.Context, filter *models.AuthEventsFilter) (events []models.AuthEvent, err error) {
+	defer errs.WrapIfErr("repo.auth_events.Find", &err)
+
+	query, args, err := repo.applyFilter(repo.builder.Select("*").From("auth_events"), filter).
+		OrderBy("created_at desc", "event_id desc").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	events = []models.AuthEvent{}
+	err = conn(ctx, repo.db).SelectContext(ctx, &events, query, args...)
+	return
+}
+
+// Count ...
+func (repo *AuthEventsRepository) Count(ctx context.Context, filter *models.AuthEventsFilter) (total int64, err error) {
+	defer errs.WrapIfErr("repo.auth_events.Count", &err)
+
+	query, args, err := repo.applyFilter(repo.builder.Select("count(*)").From("auth_events"), filter).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	err = conn(ctx, repo.db).GetContext(ctx, &total, query, args...)
+	return
+}
+
+func (repo *AuthEventsRepository) applyFilter(builder sq.SelectBuilder, filter *models.AuthEventsFilter) sq.SelectBuilder {
+	if filter.TenantID != 0 {
+		builder = builder.Where(sq.Eq{"tenant_id": filter.TenantID})
+	}
+	if filter.UserIDRef != nil {
+		builder = builder.Where(sq.Eq{"user_idref": *filter.UserIDRef})
+	}
+	if filter.UserIDCode != "" {
+		builder = builder.Where(
+			"user_idref = (select user_id from users where tenant_id = ? and user_idcode = ?)",
+			filter.TenantID, filter.UserIDCode)
+	}
+	if filter.Actor != "" {
+		builder = builder.Where(sq.Eq{"actor": filter.Actor})
+	}
+	if filter.Type != "" {
+		builder = builder.Where(sq.Eq{"event_type": filter.Type})
+	}
+	if filter.Result != "" {
+		builder = builder.Where(sq.Eq{"result": filter.Result})
+	}
+	if filter.IP != "" {
+		builder = builder.Where(sq.Eq{"event_ip": filter.IP})
+	}
+	if filter.From != nil {
+		builder = builder.Where(sq.GtOrEq{"created_at": *filter.From})
+	}
+	if filter.To != nil {
+		builder = builder.Where(sq.Lt{"created_at": *filter.To})
+	}
+	return builder
+}
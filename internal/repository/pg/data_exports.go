@@ -0,0 +1,119 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type DataExportsRepository struct {
+	db *sqlx.DB
+}
+
+func InitDataExportsRepository(db *sqlx.DB) *DataExportsRepository {
+	return &DataExportsRepository{
+		db: db,
+	}
+}
+
+// Create stores pending export, nothing is stored when the user already has a pending one
+func (repo *DataExportsRepository) Create(ctx context.Context, export *models.DataExport) (created bool, err error) {
+	defer errs.WrapIfErr("repo.data_exports.Create", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into data_exports
+		(export_id, user_idref, format, status, locale, created_at)
+		values ($1,$2,$3,$4,$5,$6)
+		on conflict (user_idref) where status = 'pending' do nothing
+		returning created_at`,
+		export.ID, export.UserIDRef, export.Format, models.ExportStatusPending,
+		export.Locale, time.Now()).
+		Scan(&export.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FindByID ...
+func (repo *DataExportsRepository) FindByID(ctx context.Context, exportID string) (export *models.DataExport, err error) {
+	defer errs.WrapIfErr("repo.data_exports.FindByID", &err)
+
+	export = &models.DataExport{}
+	err = conn(ctx, repo.db).GetContext(ctx, export,
+		`select * from data_exports where export_id = $1`, exportID)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// FetchPending locks the oldest pending export, must be called inside transaction.
+// Rows locked by other workers are skipped
+func (repo *DataExportsRepository) FetchPending(ctx context.Context) (export *models.DataExport, err error) {
+	defer errs.WrapIfErr("repo.data_exports.FetchPending", &err)
+
+	export = &models.DataExport{}
+	err = conn(ctx, repo.db).GetContext(ctx, export, `
+		select * from data_exports
+		where status = $1
+		order by created_at
+		limit 1
+		for update skip locked`,
+		models.ExportStatusPending)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// MarkReady ...
+func (repo *DataExportsRepository) MarkReady(ctx context.Context, exportID string, content []byte, expiresAt time.Time) (err error) {
+	defer errs.WrapIfErr("repo.data_exports.MarkReady", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update data_exports
+		set
+			status = $1,
+			content = $2,
+			ready_at = $3,
+			expires_at = $4
+		where export_id = $5`,
+		models.ExportStatusReady, content, time.Now(), expiresAt, exportID)
+	return
+}
+
+// MarkFailed ...
+func (repo *DataExportsRepository) MarkFailed(ctx context.Context, exportID string, reason string) (err error) {
+	defer errs.WrapIfErr("repo.data_exports.MarkFailed", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update data_exports
+		set
+			status = $1,
+			last_error = $2
+		where export_id = $3`,
+		models.ExportStatusFailed, reason, exportID)
+	return
+}
+
+// DeleteExpired removes exports which could not be downloaded anymore
+func (repo *DataExportsRepository) DeleteExpired(ctx context.Context, now time.Time) (err error) {
+	defer errs.WrapIfErr("repo.data_exports.DeleteExpired", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from data_exports where expires_at < $1`, now)
+	return
+}
+
+func (repo *DataExportsRepository) DeleteByUserID(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.data_exports.DeleteByUserID", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from data_exports where user_idref = $1`, userID)
+	return
+}
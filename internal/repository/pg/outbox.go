@@ -0,0 +1,135 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+	"sort"
+	"time"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func InitOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// Create stores message, already stored message with the same message_id is kept as is
+func (repo *OutboxRepository) Create(ctx context.Context, message *models.OutboxMessage) (err error) {
+	defer errs.WrapIfErr("repo.outbox.Create", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		insert into outbox
+		(message_id, topic, payload, user_idref, created_at, next_attempt_at)
+		values ($1,$2,$3,$4,$5,$5)
+		on conflict (message_id) do nothing`,
+		message.MessageID, message.Topic, string(message.Payload), message.UserIDRef, time.Now())
+	return
+}
+
+// Claim takes messages ready to be published and moves their next attempt by the lease, so other
+// relays skip them while they are published outside of any transaction. Messages of the relay
+// that stopped before marking them are retried after the lease
+func (repo *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) (messages []models.OutboxMessage, err error) {
+	defer errs.WrapIfErr("repo.outbox.Claim", &err)
+
+	now := time.Now()
+	err = conn(ctx, repo.db).SelectContext(ctx, &messages, `
+		update outbox
+		set next_attempt_at = $1
+		where outbox_id in (
+			select outbox_id from outbox
+			where published_at is null
+				and dead_at is null
+				and next_attempt_at <= $2
+			order by outbox_id
+			limit $3
+			for update skip locked)
+		returning *`,
+		now.Add(lease), now, limit)
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return
+}
+
+// MarkPublished marks the message as published, payloads of mails and sms may carry codes
+// and links, so they are not kept after publishing
+func (repo *OutboxRepository) MarkPublished(ctx context.Context, id int64) (err error) {
+	defer errs.WrapIfErr("repo.outbox.MarkPublished", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update outbox
+		set
+			attempts = attempts + 1,
+			published_at = $1,
+			payload = case when topic in ($2, $3) then '{}'::jsonb else payload end
+		where outbox_id = $4`,
+		time.Now(), models.OutboxTopicMails, models.OutboxTopicSms, id)
+	return
+}
+
+// MarkFailed ...
+func (repo *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) (err error) {
+	defer errs.WrapIfErr("repo.outbox.MarkFailed", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update outbox
+		set
+			attempts = attempts + 1,
+			last_error = $1,
+			next_attempt_at = $2
+		where outbox_id = $3`,
+		reason, nextAttemptAt, id)
+	return
+}
+
+// MarkDead stops retries of the message that exhausted its attempts, the row is kept for inspection
+// without payloads of mails and sms
+func (repo *OutboxRepository) MarkDead(ctx context.Context, id int64, reason string) (err error) {
+	defer errs.WrapIfErr("repo.outbox.MarkDead", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update outbox
+		set
+			attempts = attempts + 1,
+			last_error = $1,
+			dead_at = $2,
+			payload = case when topic in ($3, $4) then '{}'::jsonb else payload end
+		where outbox_id = $5`,
+		reason, time.Now(), models.OutboxTopicMails, models.OutboxTopicSms, id)
+	return
+}
+
+// Release makes the claimed message ready to be published again without counting the attempt
+func (repo *OutboxRepository) Release(ctx context.Context, id int64) (err error) {
+	defer errs.WrapIfErr("repo.outbox.Release", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update outbox
+		set next_attempt_at = $1
+		where outbox_id = $2`,
+		time.Now(), id)
+	return
+}
+
+// DeletePublished removes messages published before the given time
+func (repo *OutboxRepository) DeletePublished(ctx context.Context, before time.Time) (err error) {
+	defer errs.WrapIfErr("repo.outbox.DeletePublished", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		delete from outbox where published_at < $1`, before)
+	return
+}
+
+// DeleteByUserID removes messages of the user
+func (repo *OutboxRepository) DeleteByUserID(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.outbox.DeleteByUserID", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		delete from outbox where user_idref = $1`, userID)
+	return
+}
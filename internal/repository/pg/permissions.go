@@ -0,0 +1,43 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type PermissionsRepository struct {
+	db *sqlx.DB
+}
+
+func InitPermissionsRepository(db *sqlx.DB) *PermissionsRepository {
+	return &PermissionsRepository{
+		db: db,
+	}
+}
+
+// FindAll returns permissions ordered by name
+func (repo *PermissionsRepository) FindAll(ctx context.Context) (permissions []models.Permission, err error) {
+	defer errs.WrapIfErr("repo.permissions.FindAll", &err)
+
+	err = conn(ctx, repo.db).SelectContext(ctx, &permissions,
+		`select * from permissions order by name`)
+	return
+}
+
+// Save creates permission or updates description of the existing one
+func (repo *PermissionsRepository) Save(ctx context.Context, permission *models.Permission) (err error) {
+	defer errs.WrapIfErr("repo.permissions.Save", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into permissions
+		(name, description)
+		values ($1,$2)
+		on conflict (name)
+		do update set description = excluded.description
+		returning permission_id, created_at`,
+		permission.Name, permission.Description).
+		Scan(&permission.ID, &permission.CreatedAt)
+	return
+}
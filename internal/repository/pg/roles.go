@@ -0,0 +1,136 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// selectRoles selects roles with names of their permissions
+const selectRoles = `
+	select
+		r.role_id, r.tenant_id, r.name, r.description, r.created_at,
+		coalesce(array_agg(p.name order by p.name) filter (where p.name is not null), '{}') as permissions
+	from roles r
+	left join role_permissions rp
+		on rp.role_idref = r.role_id
+	left join permissions p
+		on p.permission_id = rp.permission_idref`
+
+type RolesRepository struct {
+	db *sqlx.DB
+}
+
+func InitRolesRepository(db *sqlx.DB) *RolesRepository {
+	return &RolesRepository{
+		db: db,
+	}
+}
+
+// FindAll returns roles of the tenant ordered by name
+func (repo *RolesRepository) FindAll(ctx context.Context, tenantID int64) (roles []models.Role, err error) {
+	defer errs.WrapIfErr("repo.roles.FindAll", &err)
+
+	err = conn(ctx, repo.db).SelectContext(ctx, &roles, selectRoles+`
+		where r.tenant_id = $1
+		group by r.role_id
+		order by r.name`, tenantID)
+	return
+}
+
+func (repo *RolesRepository) FindByName(ctx context.Context, tenantID int64, name string) (role *models.Role, err error) {
+	defer errs.WrapIfErr("repo.roles.FindByName", &err)
+
+	role = &models.Role{}
+	err = conn(ctx, repo.db).GetContext(ctx, role, selectRoles+`
+		where r.tenant_id = $1 and r.name = $2
+		group by r.role_id`, tenantID, name)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// FindByUserID returns roles assigned to the user
+func (repo *RolesRepository) FindByUserID(ctx context.Context, userID int64) (roles []models.Role, err error) {
+	defer errs.WrapIfErr("repo.roles.FindByUserID", &err)
+
+	err = conn(ctx, repo.db).SelectContext(ctx, &roles, selectRoles+`
+		join user_roles ur
+			on ur.role_idref = r.role_id
+		where ur.user_idref = $1
+		group by r.role_id
+		order by r.name`, userID)
+	return
+}
+
+// Save creates role or updates description of the existing one
+func (repo *RolesRepository) Save(ctx context.Context, role *models.Role) (err error) {
+	defer errs.WrapIfErr("repo.roles.Save", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into roles
+		(tenant_id, name, description)
+		values ($1,$2,$3)
+		on conflict (tenant_id, name)
+		do update set description = excluded.description
+		returning role_id, created_at`,
+		role.TenantID, role.Name, role.Description).
+		Scan(&role.ID, &role.CreatedAt)
+	return
+}
+
+// SetPermissions replaces permissions of the role, unknown permission names are skipped
+// and the number of granted permissions is returned
+func (repo *RolesRepository) SetPermissions(ctx context.Context, roleID int64, permissions []string) (granted int64, err error) {
+	defer errs.WrapIfErr("repo.roles.SetPermissions", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from role_permissions where role_idref = $1`, roleID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := conn(ctx, repo.db).ExecContext(ctx, `
+		insert into role_permissions
+		(role_idref, permission_idref)
+		select $1, permission_id from permissions where name = any($2)`,
+		roleID, pq.StringArray(permissions))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (repo *RolesRepository) Delete(ctx context.Context, roleID int64) (err error) {
+	defer errs.WrapIfErr("repo.roles.Delete", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from roles where role_id = $1`, roleID)
+	return
+}
+
+func (repo *RolesRepository) Assign(ctx context.Context, userID, roleID int64) (err error) {
+	defer errs.WrapIfErr("repo.roles.Assign", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		insert into user_roles
+		(user_idref, role_idref)
+		values ($1,$2)
+		on conflict do nothing`,
+		userID, roleID)
+	return
+}
+
+func (repo *RolesRepository) Unassign(ctx context.Context, userID, roleID int64) (err error) {
+	defer errs.WrapIfErr("repo.roles.Unassign", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from user_roles where user_idref = $1 and role_idref = $2`,
+		userID, roleID)
+	return
+}
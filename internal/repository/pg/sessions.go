@@ -24,12 +24,13 @@ func InitSessionsRepository(db *sqlx.DB) *SessionsRepository {
 
 // Create ...
 func (repo *SessionsRepository) Create(ctx context.Context, session *models.Session) error {
-	err := repo.db.QueryRowxContext(ctx, `
+	err := conn(ctx, repo.db).QueryRowxContext(ctx, `
 		insert into sessions
-		(user_idref, refresh_token, session_ip, started_at) 
-		values ($1,$2,$3,$4)
+		(user_idref, tenant_id, refresh_token, session_ip, user_agent, device_name, started_at) 
+		values ($1,$2,$3,$4,$5,$6,$7)
 		returning session_id`,
-		session.UserIDRef, session.RefreshToken, session.IP, session.StartedAt).
+		session.UserIDRef, session.TenantID, session.RefreshToken, session.IP,
+		session.UserAgent, session.DeviceName, session.StartedAt).
 		Scan(&session.ID)
 	if err != nil {
 		return errs.Wrap("repository.session.Create", err)
@@ -40,7 +41,7 @@ func (repo *SessionsRepository) Create(ctx context.Context, session *models.Sess
 // FindByID ...
 func (repo *SessionsRepository) FindByID(ctx context.Context, sessionID int64) (*models.Session, error) {
 	session := &models.Session{}
-	err := repo.db.GetContext(ctx, session,
+	err := conn(ctx, repo.db).GetContext(ctx, session,
 		`select * from sessions where session_id = $1`, sessionID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -52,10 +53,10 @@ func (repo *SessionsRepository) FindByID(ctx context.Context, sessionID int64) (
 }
 
 // FindByToken ...
-func (repo *SessionsRepository) FindByToken(ctx context.Context, refreshToken string) (*models.Session, error) {
+func (repo *SessionsRepository) FindByToken(ctx context.Context, tenantID int64, refreshToken string) (*models.Session, error) {
 	session := &models.Session{}
-	err := repo.db.GetContext(ctx, session,
-		`select * from sessions where refresh_token = $1`, refreshToken)
+	err := conn(ctx, repo.db).GetContext(ctx, session,
+		`select * from sessions where tenant_id = $1 and refresh_token = $2`, tenantID, refreshToken)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -65,17 +66,34 @@ func (repo *SessionsRepository) FindByToken(ctx context.Context, refreshToken st
 	return session, nil
 }
 
+// FindByUserID returns the latest sessions of the user
+func (repo *SessionsRepository) FindByUserID(ctx context.Context, userID int64, limit uint64) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := conn(ctx, repo.db).SelectContext(ctx, &sessions, `
+		select * from sessions
+		where user_idref = $1
+		order by session_id desc
+		limit $2`, userID, limit)
+	if err != nil {
+		return nil, errs.Wrap("repository.session.FindByUserID", err)
+	}
+	return sessions, nil
+}
+
 // UpdateByID ...
 func (repo *SessionsRepository) UpdateByID(ctx context.Context, session *models.Session) error {
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := conn(ctx, repo.db).ExecContext(ctx, `
 		update sessions
 		set 
 			session_ip = $1,
-			refresh_token = $2,
-			started_at = $3,
-			ended_at = $4
-		where session_id = $5`,
-		session.IP, session.RefreshToken, session.StartedAt,
+			user_agent = $2,
+			device_name = $3,
+			refresh_token = $4,
+			started_at = $5,
+			ended_at = $6
+		where session_id = $7`,
+		session.IP, session.UserAgent, session.DeviceName,
+		session.RefreshToken, session.StartedAt,
 		session.EndedAt, session.ID)
 	if err != nil {
 		return errs.Wrap("repository.session.UpdateByID", err)
@@ -85,18 +103,22 @@ func (repo *SessionsRepository) UpdateByID(ctx context.Context, session *models.
 
 // UpdateByUserID ...
 func (repo *SessionsRepository) UpdateByUserID(ctx context.Context, session *models.Session) error {
-	_, err := repo.db.ExecContext(ctx, `
+	err := conn(ctx, repo.db).QueryRowxContext(ctx, `
 		update sessions
 		set 
 			session_ip = $1,
-			refresh_token = $2,
-			started_at = $3,
-			ended_at = $4
-		where user_idref = $5`,
-		session.IP, session.RefreshToken,
-		session.StartedAt, session.EndedAt,
-		session.UserIDRef)
-	if err != nil {
+			user_agent = $2,
+			device_name = $3,
+			refresh_token = $4,
+			started_at = $5,
+			ended_at = $6
+		where user_idref = $7
+		returning session_id`,
+		session.IP, session.UserAgent, session.DeviceName,
+		session.RefreshToken, session.StartedAt, session.EndedAt,
+		session.UserIDRef).
+		Scan(&session.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return errs.Wrap("repository.session.UpdateByUserID", err)
 	}
 	return nil
@@ -104,10 +126,12 @@ func (repo *SessionsRepository) UpdateByUserID(ctx context.Context, session *mod
 
 // EndSession ...
 func (repo *SessionsRepository) EndSession(ctx context.Context, sessionID int64) error {
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := conn(ctx, repo.db).ExecContext(ctx, `
 		update sessions
 		set 
 			session_ip = '',
+			user_agent = '',
+			device_name = '',
 			refresh_token = '',
 			ended_at = $1
 		where session_id = $2`,
@@ -118,9 +142,28 @@ func (repo *SessionsRepository) EndSession(ctx context.Context, sessionID int64)
 	return nil
 }
 
+// EndByUserID ends all active sessions of the user and returns their ids
+func (repo *SessionsRepository) EndByUserID(ctx context.Context, userID int64) (sessionIDs []int64, err error) {
+	err = conn(ctx, repo.db).SelectContext(ctx, &sessionIDs, `
+		update sessions
+		set 
+			session_ip = '',
+			user_agent = '',
+			device_name = '',
+			refresh_token = '',
+			ended_at = $1
+		where user_idref = $2 and refresh_token <> ''
+		returning session_id`,
+		time.Now().Unix(), userID)
+	if err != nil {
+		return nil, errs.Wrap("repository.session.EndByUserID", err)
+	}
+	return sessionIDs, nil
+}
+
 // DeleteByID ...
 func (repo *SessionsRepository) DeleteByID(ctx context.Context, sessionID int64) error {
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := conn(ctx, repo.db).ExecContext(ctx, `
 		delete from sessions where session_id = $1`, sessionID)
 	if err != nil {
 		return errs.Wrap("repository.session.DeleteByID", err)
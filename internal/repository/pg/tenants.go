@@ -0,0 +1,60 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type TenantsRepository struct {
+	db         *sqlx.DB
+	secretsKey string
+}
+
+func InitTenantsRepository(db *sqlx.DB, secretsKey string) *TenantsRepository {
+	return &TenantsRepository{
+		db:         db,
+		secretsKey: secretsKey,
+	}
+}
+
+// FindAll returns tenants with decrypted secrets, secrets not sealed yet are read in plain text
+func (repo *TenantsRepository) FindAll(ctx context.Context) (tenants []models.Tenant, err error) {
+	defer errs.WrapIfErr("repo.tenants.FindAll", &err)
+
+	err = conn(ctx, repo.db).SelectContext(ctx, &tenants, `
+		select
+			tenant_id, tenant_code, name, hosts,
+			coalesce(pgp_sym_decrypt(access_secret_sealed, $1), access_secret) as access_secret,
+			coalesce(pgp_sym_decrypt(refresh_secret_sealed, $1), refresh_secret) as refresh_secret,
+			google_client_id,
+			coalesce(pgp_sym_decrypt(google_client_secret_sealed, $1), google_client_secret) as google_client_secret,
+			google_server_callback_uri, google_client_callback_uri, allow_sign_up, allow_google, created_at
+		from tenants
+		order by tenant_id`, repo.secretsKey)
+	return
+}
+
+// SealSecrets encrypts token and google client secrets written to the tenants in plain text and clears them
+func (repo *TenantsRepository) SealSecrets(ctx context.Context) (err error) {
+	defer errs.WrapIfErr("repo.tenants.SealSecrets", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update tenants
+		set
+			access_secret_sealed = case
+				when access_secret <> '' then pgp_sym_encrypt(access_secret, $1)
+				else access_secret_sealed end,
+			refresh_secret_sealed = case
+				when refresh_secret <> '' then pgp_sym_encrypt(refresh_secret, $1)
+				else refresh_secret_sealed end,
+			google_client_secret_sealed = case
+				when google_client_secret <> '' then pgp_sym_encrypt(google_client_secret, $1)
+				else google_client_secret_sealed end,
+			access_secret = '',
+			refresh_secret = '',
+			google_client_secret = ''
+		where access_secret <> '' or refresh_secret <> '' or google_client_secret <> ''`, repo.secretsKey)
+	return
+}
@@ -0,0 +1,52 @@
+package pg
+
+import (
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// conn returns transaction started by WithTx, or db itself if there is no one
+func conn(ctx context.Context, db *sqlx.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// WithTx runs fn inside single transaction, all repositories called with
+// the ctx passed to fn use it. Nested calls join already started transaction
+func WithTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errs.Wrap("begin tx", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = errs.Wrap("commit tx", err)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
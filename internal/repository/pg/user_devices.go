@@ -0,0 +1,52 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserDevicesRepository struct {
+	db *sqlx.DB
+}
+
+func InitUserDevicesRepository(db *sqlx.DB) *UserDevicesRepository {
+	return &UserDevicesRepository{
+		db: db,
+	}
+}
+
+// FindByUserID ...
+func (repo *UserDevicesRepository) FindByUserID(ctx context.Context, userID int64) (devices []models.UserDevice, err error) {
+	defer errs.WrapIfErr("repo.user_devices.FindByUserID", &err)
+
+	err = conn(ctx, repo.db).SelectContext(ctx, &devices,
+		`select * from user_devices where user_idref = $1`, userID)
+	return
+}
+
+// Save inserts new device or refreshes last_seen_at of the known one
+func (repo *UserDevicesRepository) Save(ctx context.Context, device *models.UserDevice) (err error) {
+	defer errs.WrapIfErr("repo.user_devices.Save", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into user_devices
+		(user_idref, device_ip, user_agent, first_seen_at, last_seen_at)
+		values ($1,$2,$3,$4,$5)
+		on conflict (user_idref, device_ip, user_agent)
+		do update set last_seen_at = excluded.last_seen_at
+		returning device_id`,
+		device.UserIDRef, device.IP, device.UserAgent,
+		device.FirstSeenAt, device.LastSeenAt).
+		Scan(&device.ID)
+	return
+}
+
+func (repo *UserDevicesRepository) DeleteByUserID(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.user_devices.DeleteByUserID", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from user_devices where user_idref = $1`, userID)
+	return
+}
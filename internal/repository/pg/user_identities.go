@@ -0,0 +1,97 @@
+package pg
+
+import (
+	"auth-api/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserIdentitiesRepository struct {
+	db *sqlx.DB
+}
+
+func InitUserIdentitiesRepository(db *sqlx.DB) *UserIdentitiesRepository {
+	return &UserIdentitiesRepository{
+		db: db,
+	}
+}
+
+// Create ...
+func (repo *UserIdentitiesRepository) Create(ctx context.Context, identity *models.UserIdentity) (err error) {
+	defer errs.WrapIfErr("repo.user_identities.Create", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		insert into user_identities
+		(user_idref, tenant_id, provider, subject, email, email_verified)
+		values ($1,$2,$3,$4,$5,$6)
+		returning identity_id, created_at, last_used_at`,
+		identity.UserIDRef, identity.TenantID, identity.Provider, identity.Subject,
+		identity.Email, identity.EmailVerified).
+		Scan(&identity.ID, &identity.CreatedAt, &identity.LastUsedAt)
+	return
+}
+
+// FindBySubject ...
+func (repo *UserIdentitiesRepository) FindBySubject(
+	ctx context.Context, tenantID int64, provider models.OAuthProvider, subject string) (identity *models.UserIdentity, err error) {
+	defer errs.WrapIfErr("repo.user_identities.FindBySubject", &err)
+
+	identity = &models.UserIdentity{}
+	err = conn(ctx, repo.db).GetContext(ctx, identity, `
+		select * from user_identities
+		where tenant_id = $1 and provider = $2 and subject = $3`,
+		tenantID, provider, subject)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// FindByUserID ...
+func (repo *UserIdentitiesRepository) FindByUserID(ctx context.Context, userID int64) (identities []models.UserIdentity, err error) {
+	defer errs.WrapIfErr("repo.user_identities.FindByUserID", &err)
+
+	identities = []models.UserIdentity{}
+	err = conn(ctx, repo.db).SelectContext(ctx, &identities,
+		`select * from user_identities where user_idref = $1 order by identity_id`, userID)
+	return
+}
+
+// Touch saves the email the provider gave on the last sign in
+func (repo *UserIdentitiesRepository) Touch(ctx context.Context, identity *models.UserIdentity) (err error) {
+	defer errs.WrapIfErr("repo.user_identities.Touch", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update user_identities
+		set
+			email = $1,
+			email_verified = $2,
+			last_used_at = now()
+		where identity_id = $3`,
+		identity.Email, identity.EmailVerified, identity.ID)
+	return
+}
+
+// Delete unlinks the provider from the user, reports whether there was such identity
+func (repo *UserIdentitiesRepository) Delete(ctx context.Context, userID int64, provider models.OAuthProvider) (deleted bool, err error) {
+	defer errs.WrapIfErr("repo.user_identities.Delete", &err)
+
+	result, err := conn(ctx, repo.db).ExecContext(ctx,
+		`delete from user_identities where user_idref = $1 and provider = $2`, userID, provider)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (repo *UserIdentitiesRepository) DeleteByUserID(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.user_identities.DeleteByUserID", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`delete from user_identities where user_idref = $1`, userID)
+	return
+}
@@ -5,17 +5,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	sq "github.com/Masterminds/squirrel"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
 )
 
 type UsersRepository struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
 }
 
 func InitUsersRepository(db *sqlx.DB) *UsersRepository {
 	return &UsersRepository{
-		db: db,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
 }
 
@@ -23,13 +28,15 @@ func InitUsersRepository(db *sqlx.DB) *UsersRepository {
 func (repo *UsersRepository) Create(ctx context.Context, user *models.User) (err error) {
 	defer errs.WrapIfErr("repo.user.Create", &err)
 
-	err = repo.db.QueryRowxContext(ctx,
+	err = conn(ctx, repo.db).QueryRowxContext(ctx,
 		`insert into users
-		(user_idcode, email, phone_number, password, oauth_provider, created_at) 
-		values ($1,$2,$3,$4,$5, $6)
+		(user_idcode, tenant_id, email, phone_number, activated, password, oauth_provider,
+		first_name, last_name, locale, display_name, picture_url, created_at) 
+		values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		returning user_id`,
-		user.IDCode, user.Email, user.PhoneNumber,
-		user.Password, user.OAuthProvider, user.CreatedAt).
+		user.IDCode, user.TenantID, user.Email, user.PhoneNumber, user.Activated,
+		user.Password, user.OAuthProvider, user.FirstName, user.LastName, user.Locale,
+		user.DisplayName, user.PictureURL, user.CreatedAt).
 		Scan(&user.ID)
 	return
 }
@@ -38,7 +45,7 @@ func (repo *UsersRepository) FindByID(ctx context.Context, id int64) (user *mode
 	defer errs.WrapIfErr("repo.user.FindByID", &err)
 
 	user = &models.User{}
-	err = repo.db.GetContext(ctx, user,
+	err = conn(ctx, repo.db).GetContext(ctx, user,
 		`select * from users where user_id = $1`, id)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
@@ -46,11 +53,24 @@ func (repo *UsersRepository) FindByID(ctx context.Context, id int64) (user *mode
 	return
 }
 
-func (repo *UsersRepository) FindByUserIDCode(ctx context.Context, userIDCode string) (user *models.User, err error) {
+func (repo *UsersRepository) FindByUserIDCode(ctx context.Context, tenantID int64, userIDCode string) (user *models.User, err error) {
 	defer errs.WrapIfErr("repo.user.FindByUserIDCode", &err)
 
 	user = &models.User{}
-	err = repo.db.GetContext(ctx, user,
+	err = conn(ctx, repo.db).GetContext(ctx, user,
+		`select * from users where tenant_id = $1 and user_idcode = $2`, tenantID, userIDCode)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// FindByUserIDCodeInAnyTenant is for commands of other services that address users by id only
+func (repo *UsersRepository) FindByUserIDCodeInAnyTenant(ctx context.Context, userIDCode string) (user *models.User, err error) {
+	defer errs.WrapIfErr("repo.user.FindByUserIDCodeInAnyTenant", &err)
+
+	user = &models.User{}
+	err = conn(ctx, repo.db).GetContext(ctx, user,
 		`select * from users where user_idcode = $1`, userIDCode)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
@@ -58,12 +78,12 @@ func (repo *UsersRepository) FindByUserIDCode(ctx context.Context, userIDCode st
 	return
 }
 
-func (repo *UsersRepository) FindByEmail(ctx context.Context, email string) (user *models.User, err error) {
+func (repo *UsersRepository) FindByEmail(ctx context.Context, tenantID int64, email string) (user *models.User, err error) {
 	defer errs.WrapIfErr("repo.user.FindByEmail", &err)
 
 	user = &models.User{}
-	err = repo.db.GetContext(ctx, user,
-		`select * from users where email = $1 and deleted_at is null`, email)
+	err = conn(ctx, repo.db).GetContext(ctx, user,
+		`select * from users where tenant_id = $1 and email = $2 and deleted_at is null`, tenantID, email)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -71,20 +91,184 @@ func (repo *UsersRepository) FindByEmail(ctx context.Context, email string) (use
 }
 
 // FindWSessionByToken ...
-func (repo *UsersRepository) FindWSessionByToken(ctx context.Context, refreshToken string) (us *models.UserSession, err error) {
+func (repo *UsersRepository) FindWSessionByToken(ctx context.Context, tenantID int64, refreshToken string) (us *models.UserSession, err error) {
 	defer errs.WrapIfErr("repo.user.FindByToken", &err)
 
 	us = &models.UserSession{}
-	err = repo.db.GetContext(ctx, us, `
+	err = conn(ctx, repo.db).GetContext(ctx, us, `
 		select 
 			user_id, user_code, session_id, session_ip, 
 			refresh_token, started_at, ended_at
 		from users u 
 		left join sessions s 
 			on s.user_idref = u.user_id
-		where refresh_token = $1`, refreshToken)
+		where u.tenant_id = $1 and refresh_token = $2`, tenantID, refreshToken)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	return
 }
+
+func (repo *UsersRepository) SetDisabledAt(ctx context.Context, userID int64, disabledAt *time.Time) (err error) {
+	defer errs.WrapIfErr("repo.user.SetDisabledAt", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`update users set disabled_at = $1, updated_at = now() where user_id = $2`,
+		disabledAt, userID)
+	return
+}
+
+// SetPassword stores the password hash, users signed up by the provider get the password here
+func (repo *UsersRepository) SetPassword(ctx context.Context, userID int64, hashedPassword string) (err error) {
+	defer errs.WrapIfErr("repo.user.SetPassword", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`update users set password = $1, updated_at = now() where user_id = $2`,
+		hashedPassword, userID)
+	return
+}
+
+func (repo *UsersRepository) SetEmailBouncedAt(ctx context.Context, tenantID int64, email string, bouncedAt *time.Time) (err error) {
+	defer errs.WrapIfErr("repo.user.SetEmailBouncedAt", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`update users set email_bounced_at = $1, updated_at = now() where tenant_id = $2 and email = $3`,
+		bouncedAt, tenantID, email)
+	return
+}
+
+// Search returns users matching the filter, the newest first
+func (repo *UsersRepository) Search(ctx context.Context, filter *models.UsersFilter) (users []models.User, err error) {
+	defer errs.WrapIfErr("repo.user.Search", &err)
+
+	query, args, err := repo.applyFilter(repo.builder.Select("*").From("users"), filter).
+		OrderBy("user_id desc").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	users = []models.User{}
+	err = conn(ctx, repo.db).SelectContext(ctx, &users, query, args...)
+	return
+}
+
+// Count ...
+func (repo *UsersRepository) Count(ctx context.Context, filter *models.UsersFilter) (total int64, err error) {
+	defer errs.WrapIfErr("repo.user.Count", &err)
+
+	query, args, err := repo.applyFilter(repo.builder.Select("count(*)").From("users"), filter).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	err = conn(ctx, repo.db).GetContext(ctx, &total, query, args...)
+	return
+}
+
+func (repo *UsersRepository) SetEraseAt(ctx context.Context, userID int64, eraseAt *time.Time) (err error) {
+	defer errs.WrapIfErr("repo.user.SetEraseAt", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`update users set erase_at = $1, updated_at = now() where user_id = $2`,
+		eraseAt, userID)
+	return
+}
+
+// FindDueForErasure locks the user whose grace period has passed, users locked
+// by other instances are skipped
+func (repo *UsersRepository) FindDueForErasure(ctx context.Context, now time.Time) (user *models.User, err error) {
+	defer errs.WrapIfErr("repo.user.FindDueForErasure", &err)
+
+	user = &models.User{}
+	err = conn(ctx, repo.db).GetContext(ctx, user, `
+		select * from users
+		where erase_at <= $1 and erased_at is null
+		order by erase_at
+		limit 1
+		for update skip locked`, now)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return
+}
+
+// Update saves contacts and profile fields of the user
+func (repo *UsersRepository) Update(ctx context.Context, user *models.User) (err error) {
+	defer errs.WrapIfErr("repo.user.Update", &err)
+
+	err = conn(ctx, repo.db).QueryRowxContext(ctx, `
+		update users
+		set
+			email = $1,
+			phone_number = $2,
+			activated = $3,
+			email_bounced_at = $4,
+			first_name = $5,
+			last_name = $6,
+			locale = $7,
+			display_name = $8,
+			picture_url = $9,
+			updated_at = now()
+		where user_id = $10
+		returning updated_at`,
+		user.Email, user.PhoneNumber, user.Activated, user.EmailBouncedAt,
+		user.FirstName, user.LastName, user.Locale, user.DisplayName, user.PictureURL, user.ID).
+		Scan(&user.UpdatedAt)
+	return
+}
+
+// Erase removes personal data of the user, the row itself is kept for references
+func (repo *UsersRepository) Erase(ctx context.Context, userID int64) (err error) {
+	defer errs.WrapIfErr("repo.user.Erase", &err)
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx, `
+		update users
+		set
+			email = '',
+			phone_number = '',
+			password = '',
+			first_name = '',
+			last_name = '',
+			display_name = '',
+			picture_url = '',
+			erase_at = null,
+			erased_at = now(),
+			deleted_at = coalesce(deleted_at, now()),
+			updated_at = now()
+		where user_id = $1`, userID)
+	return
+}
+
+func (repo *UsersRepository) applyFilter(builder sq.SelectBuilder, filter *models.UsersFilter) sq.SelectBuilder {
+	builder = builder.Where(sq.Eq{"tenant_id": filter.TenantID})
+	if filter.Email != "" {
+		builder = builder.Where(sq.ILike{"email": "%" + escapeLike(filter.Email) + "%"})
+	}
+	if filter.PhoneNumber != "" {
+		builder = builder.Where(sq.Like{"phone_number": "%" + escapeLike(filter.PhoneNumber) + "%"})
+	}
+	if filter.OAuthProvider != "" {
+		builder = builder.Where(sq.Eq{"oauth_provider": filter.OAuthProvider})
+	}
+
+	switch filter.Status {
+	case models.UserStatusActive:
+		builder = builder.Where("deleted_at is null and disabled_at is null and activated")
+	case models.UserStatusUnverified:
+		builder = builder.Where("deleted_at is null and disabled_at is null and not activated")
+	case models.UserStatusDisabled:
+		builder = builder.Where("deleted_at is null and disabled_at is not null")
+	case models.UserStatusDeleted:
+		builder = builder.Where("deleted_at is not null")
+	}
+	return builder
+}
+
+// escapeLike escapes wildcards of the like pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"auth-api/internal/repository/cache"
+	"auth-api/internal/repository/memory"
 	"auth-api/internal/repository/pg"
+	"context"
 	"github.com/jmoiron/sqlx"
 	"sync"
 )
@@ -13,6 +16,11 @@ type Repository struct {
 	db     *sqlx.DB
 	config *models.Config
 	cache  interfaces.ICacheProcessor
+	// store replaces postgres when the memory database driver is selected
+	store *memory.Store
+
+	tenants       interfaces.ITenantsRepository
+	tenantsRunner sync.Once
 
 	user       interfaces.IUserRepository
 	userRunner sync.Once
@@ -20,29 +28,91 @@ type Repository struct {
 	sessions       interfaces.ISessionRepository
 	sessionsRunner sync.Once
 
+	roles       interfaces.IRolesRepository
+	rolesRunner sync.Once
+
+	permissions       interfaces.IPermissionsRepository
+	permissionsRunner sync.Once
+
+	userDevices       interfaces.IUserDevicesRepository
+	userDevicesRunner sync.Once
+
+	userIdentities       interfaces.IUserIdentitiesRepository
+	userIdentitiesRunner sync.Once
+
+	authEvents       interfaces.IAuthEventsRepository
+	authEventsRunner sync.Once
+
+	dataExports       interfaces.IDataExportsRepository
+	dataExportsRunner sync.Once
+
+	outbox       interfaces.IOutboxRepository
+	outboxRunner sync.Once
+
 	sessionsCache       interfaces.ISessionsCacheRepository
 	sessionsCacheRunner sync.Once
 
-	googleAPICodes       interfaces.IGoogleAPICodesRepository
-	googleAPICodesRunner sync.Once
+	oAuthCodes       map[models.OAuthProvider]interfaces.IOAuthCodesRepository
+	oAuthCodesRunner sync.Once
+
+	oAuthResults       interfaces.IOAuthResultsRepository
+	oAuthResultsRunner sync.Once
 
 	verificationCodeCache       interfaces.IVerificationCodesRepository
 	verificationCodeCacheRunner sync.Once
+
+	contactChanges       interfaces.IContactChangesRepository
+	contactChangesRunner sync.Once
+
+	reauthAttempts       interfaces.IReauthAttemptsRepository
+	reauthAttemptsRunner sync.Once
 }
 
 func InitRepository(
 	db *sqlx.DB,
 	config *models.Config,
 	cache interfaces.ICacheProcessor) *Repository {
-	return &Repository{
+	r := &Repository{
 		db:     db,
 		cache:  cache,
 		config: config,
 	}
+	if config.DatabaseDriver == consts.DatabaseDriverMemory {
+		r.store = memory.NewStore()
+	}
+	return r
+}
+
+// MemoryStore returns the store of the memory database driver, nil for postgres
+func (r *Repository) MemoryStore() *memory.Store {
+	return r.store
+}
+
+// Transaction runs fn in a single postgres transaction shared by all pg repositories
+func (r *Repository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.store != nil {
+		return r.store.Transaction(ctx, fn)
+	}
+	return pg.WithTx(ctx, r.db, fn)
+}
+
+func (r *Repository) Tenants() interfaces.ITenantsRepository {
+	r.tenantsRunner.Do(func() {
+		if r.store != nil {
+			r.tenants = memory.InitTenantsRepository(r.store)
+			return
+		}
+		r.tenants = pg.InitTenantsRepository(r.db, r.config.TenantSecretsKey)
+	})
+	return r.tenants
 }
 
 func (r *Repository) Users() interfaces.IUserRepository {
 	r.userRunner.Do(func() {
+		if r.store != nil {
+			r.user = memory.InitUsersRepository(r.store)
+			return
+		}
 		r.user = pg.InitUsersRepository(r.db)
 	})
 	return r.user
@@ -50,11 +120,92 @@ func (r *Repository) Users() interfaces.IUserRepository {
 
 func (r *Repository) Sessions() interfaces.ISessionRepository {
 	r.sessionsRunner.Do(func() {
+		if r.store != nil {
+			r.sessions = memory.InitSessionsRepository(r.store)
+			return
+		}
 		r.sessions = pg.InitSessionsRepository(r.db)
 	})
 	return r.sessions
 }
 
+func (r *Repository) Roles() interfaces.IRolesRepository {
+	r.rolesRunner.Do(func() {
+		if r.store != nil {
+			r.roles = memory.InitRolesRepository(r.store)
+			return
+		}
+		r.roles = pg.InitRolesRepository(r.db)
+	})
+	return r.roles
+}
+
+func (r *Repository) Permissions() interfaces.IPermissionsRepository {
+	r.permissionsRunner.Do(func() {
+		if r.store != nil {
+			r.permissions = memory.InitPermissionsRepository(r.store)
+			return
+		}
+		r.permissions = pg.InitPermissionsRepository(r.db)
+	})
+	return r.permissions
+}
+
+func (r *Repository) UserDevices() interfaces.IUserDevicesRepository {
+	r.userDevicesRunner.Do(func() {
+		if r.store != nil {
+			r.userDevices = memory.InitUserDevicesRepository(r.store)
+			return
+		}
+		r.userDevices = pg.InitUserDevicesRepository(r.db)
+	})
+	return r.userDevices
+}
+
+func (r *Repository) UserIdentities() interfaces.IUserIdentitiesRepository {
+	r.userIdentitiesRunner.Do(func() {
+		if r.store != nil {
+			r.userIdentities = memory.InitUserIdentitiesRepository(r.store)
+			return
+		}
+		r.userIdentities = pg.InitUserIdentitiesRepository(r.db)
+	})
+	return r.userIdentities
+}
+
+func (r *Repository) AuthEvents() interfaces.IAuthEventsRepository {
+	r.authEventsRunner.Do(func() {
+		if r.store != nil {
+			r.authEvents = memory.InitAuthEventsRepository(r.store)
+			return
+		}
+		r.authEvents = pg.InitAuthEventsRepository(r.db)
+	})
+	return r.authEvents
+}
+
+func (r *Repository) DataExports() interfaces.IDataExportsRepository {
+	r.dataExportsRunner.Do(func() {
+		if r.store != nil {
+			r.dataExports = memory.InitDataExportsRepository(r.store)
+			return
+		}
+		r.dataExports = pg.InitDataExportsRepository(r.db)
+	})
+	return r.dataExports
+}
+
+func (r *Repository) Outbox() interfaces.IOutboxRepository {
+	r.outboxRunner.Do(func() {
+		if r.store != nil {
+			r.outbox = memory.InitOutboxRepository(r.store)
+			return
+		}
+		r.outbox = pg.InitOutboxRepository(r.db)
+	})
+	return r.outbox
+}
+
 func (r *Repository) SessionsCache() interfaces.ISessionsCacheRepository {
 	r.sessionsCacheRunner.Do(func() {
 		r.sessionsCache = cache.InitSessionCacheRepository(r.cache)
@@ -62,11 +213,22 @@ func (r *Repository) SessionsCache() interfaces.ISessionsCacheRepository {
 	return r.sessionsCache
 }
 
-func (r *Repository) GoogleAPICodes() interfaces.IGoogleAPICodesRepository {
-	r.googleAPICodesRunner.Do(func() {
-		r.googleAPICodes = cache.InitOAuthCacheRepository(models.GoogleOAuth, r.cache)
+// OAuthCodes returns states of authorization requests to the provider, provider must be valid
+func (r *Repository) OAuthCodes(provider models.OAuthProvider) interfaces.IOAuthCodesRepository {
+	r.oAuthCodesRunner.Do(func() {
+		r.oAuthCodes = make(map[models.OAuthProvider]interfaces.IOAuthCodesRepository, len(models.SocialProviders))
+		for _, p := range models.SocialProviders {
+			r.oAuthCodes[p] = cache.InitOAuthCacheRepository(p, r.cache)
+		}
+	})
+	return r.oAuthCodes[provider]
+}
+
+func (r *Repository) OAuthResults() interfaces.IOAuthResultsRepository {
+	r.oAuthResultsRunner.Do(func() {
+		r.oAuthResults = cache.InitOAuthResultsRepository(r.cache)
 	})
-	return r.googleAPICodes
+	return r.oAuthResults
 }
 
 func (r *Repository) VerificationCodes() interfaces.IVerificationCodesRepository {
@@ -75,3 +237,17 @@ func (r *Repository) VerificationCodes() interfaces.IVerificationCodesRepository
 	})
 	return r.verificationCodeCache
 }
+
+func (r *Repository) ContactChanges() interfaces.IContactChangesRepository {
+	r.contactChangesRunner.Do(func() {
+		r.contactChanges = cache.InitContactChangesRepository(r.cache)
+	})
+	return r.contactChanges
+}
+
+func (r *Repository) ReauthAttempts() interfaces.IReauthAttemptsRepository {
+	r.reauthAttemptsRunner.Do(func() {
+		r.reauthAttempts = cache.InitReauthAttemptsRepository(r.cache)
+	})
+	return r.reauthAttempts
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"github.com/doxanocap/pkg/ctxholder"
+	"github.com/doxanocap/pkg/errs"
+	"go.uber.org/zap"
+	"time"
+)
+
+type AuditService struct {
+	log     *zap.Logger
+	manager interfaces.IManager
+}
+
+func InitAuditService(manager interfaces.IManager, log *zap.Logger) *AuditService {
+	return &AuditService{
+		log:     log,
+		manager: manager,
+	}
+}
+
+// Record stores event with the result taken from err, better use with defer.
+// Storage errors are only logged, audit must not break authentication
+func (s *AuditService) Record(ctx context.Context, event *models.AuthEvent, err *error) {
+	event.Result = models.AuthResultSuccess
+	if err != nil && *err != nil {
+		event.Result = models.AuthResultFailure
+		event.Reason = "internal error"
+		if httpError := errs.UnmarshalError(*err); httpError.StatusCode != 0 {
+			event.Reason = httpError.Message
+		}
+	}
+
+	event.TenantID = s.tenantID(ctx, event)
+	event.IP = ctxholder.GetStringByKey(ctx, consts.CtxKeyClientIP)
+	event.UserAgent = ctxholder.GetStringByKey(ctx, consts.CtxKeyUserAgent)
+	event.CreatedAt = time.Now()
+
+	if createErr := s.manager.Repository().AuthEvents().Create(ctx, event); createErr != nil {
+		s.log.With(
+			zap.String("type", string(event.Type)),
+			zap.String("actor", event.Actor)).
+			Error(createErr.Error())
+	}
+}
+
+// tenantID is the tenant of the event user, or of the request when the user is unknown
+func (s *AuditService) tenantID(ctx context.Context, event *models.AuthEvent) int64 {
+	if event.UserIDRef != nil {
+		user, err := s.manager.Repository().Users().FindByID(ctx, *event.UserIDRef)
+		if err == nil && user != nil {
+			return user.TenantID
+		}
+	}
+
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return 0
+	}
+	return tenant.ID
+}
+
+func (s *AuditService) ListByUser(ctx context.Context, userIDCode string, limit, offset uint64) (*models.AuthEventsPage, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.manager.Repository().Users().FindByUserIDCode(ctx, tenant.ID, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	return s.Search(ctx, &models.AuthEventsFilter{
+		UserIDRef: &user.ID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// Search looks for events of the users of the request tenant
+func (s *AuditService) Search(ctx context.Context, filter *models.AuthEventsFilter) (*models.AuthEventsPage, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter.TenantID = tenant.ID
+
+	events, err := s.manager.Repository().AuthEvents().Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.manager.Repository().AuthEvents().Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthEventsPage{
+		Items:  events,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
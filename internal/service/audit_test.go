@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAuditService_RecordsAuthentication(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	_, err := m.Service().User().Authenticate(ctx, &models.UserDTO{Email: "user@example.com", Password: "Wrong123!"})
+	require.ErrorIs(t, err, models.ErrIncorrectPassword)
+
+	page, err := m.Service().Audit().ListByUser(ctx, response.IDCode, 10, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, page.Total)
+
+	// the newest event comes first
+	signIn, signUp := page.Items[0], page.Items[1]
+	require.Equal(t, models.AuthEventSignIn, signIn.Type)
+	require.Equal(t, models.AuthResultFailure, signIn.Result)
+	require.Equal(t, models.ErrIncorrectPassword.Error(), signIn.Reason)
+	require.Equal(t, "user@example.com", signIn.Actor)
+
+	require.Equal(t, models.AuthEventSignUp, signUp.Type)
+	require.Equal(t, models.AuthResultSuccess, signUp.Result)
+	require.Empty(t, signUp.Reason)
+	require.Equal(t, "10.0.0.1", signUp.IP)
+	require.Equal(t, "laptop", signUp.UserAgent)
+	require.Equal(t, response.Tokens.SessionID, *signUp.SessionIDRef)
+}
+
+func TestAuditService_Record(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name           string
+		err            error
+		expectedResult models.AuthEventResult
+		expectedReason string
+	}{
+		{name: "success", err: nil, expectedResult: models.AuthResultSuccess},
+		{name: "http error", err: models.ErrUserDisabled, expectedResult: models.AuthResultFailure,
+			expectedReason: models.ErrUserDisabled.Error()},
+		{name: "internal error", err: errors.New("connection refused"), expectedResult: models.AuthResultFailure,
+			expectedReason: "internal error"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			event := &models.AuthEvent{Type: models.AuthEventLogout, Actor: testCase.name}
+			m.Service().Audit().Record(ctx, event, &testCase.err)
+
+			require.Equal(t, testCase.expectedResult, event.Result)
+			require.Equal(t, testCase.expectedReason, event.Reason)
+			require.NotZero(t, event.ID)
+		})
+	}
+}
+
+func TestAuditService_SearchIsTenantScoped(t *testing.T) {
+	m, _ := managertest.New(t)
+	shop := &models.Tenant{Code: "shop", Name: "Shop", AllowSignUp: true}
+	managertest.AddTenant(t, m, shop)
+
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	shopCtx := managertest.TenantContext(shop.ID, "10.0.0.1", "laptop")
+	managertest.SignUp(t, m, ctx, "user@example.com")
+	shopUser := managertest.SignUp(t, m, shopCtx, "user@shop.example.com")
+
+	// the event without user belongs to the request tenant
+	_, err := m.Service().User().Authenticate(shopCtx, &models.UserDTO{Email: "unknown@example.com", Password: "Password123!"})
+	require.Error(t, err)
+
+	// the command comes without tenant, the event belongs to the tenant of the user
+	require.NoError(t, m.Service().User().ForceLogout(context.Background(), shopUser.IDCode, ""))
+
+	page, err := m.Service().Audit().Search(shopCtx, &models.AuthEventsFilter{})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, page.Total)
+	require.Equal(t, models.AuthEventForceLogout, page.Items[0].Type)
+	require.Equal(t, "unknown@example.com", page.Items[1].Actor)
+	require.Equal(t, "user@shop.example.com", page.Items[2].Actor)
+
+	page, err = m.Service().Audit().Search(ctx, &models.AuthEventsFilter{UserIDCode: shopUser.IDCode})
+	require.NoError(t, err)
+	require.Zero(t, page.Total)
+
+	page, err = m.Service().Audit().Search(ctx, &models.AuthEventsFilter{})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, page.Total)
+	require.Equal(t, "user@example.com", page.Items[0].Actor)
+}
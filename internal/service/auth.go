@@ -11,16 +11,21 @@ import (
 	"github.com/doxanocap/pkg/ctxholder"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"net/url"
+	"strconv"
 	"time"
 )
 
 type AuthService struct {
+	log     *zap.Logger
 	config  *models.Config
 	manager interfaces.IManager
 }
 
-func InitAuthService(manager interfaces.IManager, config *models.Config) *AuthService {
+func InitAuthService(manager interfaces.IManager, config *models.Config, log *zap.Logger) *AuthService {
 	return &AuthService{
+		log:     log,
 		config:  config,
 		manager: manager,
 	}
@@ -29,79 +34,150 @@ func InitAuthService(manager interfaces.IManager, config *models.Config) *AuthSe
 func (s *AuthService) NewSession(ctx context.Context, user *models.User) (result *models.Tokens, err error) {
 	startedAt := time.Now().Unix()
 	userSession := &models.UserSession{
+		UserID:        user.ID,
 		UserIDCode:    user.IDCode,
+		TenantID:      user.TenantID,
 		UserEmail:     user.Email,
 		UserActivated: user.Activated,
 		StartedAt:     startedAt,
 	}
 
-	tokens, err := s.NewPairTokens(userSession)
+	tokens, err := s.NewPairTokens(ctx, userSession)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = s.manager.Repository().Sessions().Create(ctx, &models.Session{
+	session := &models.Session{
 		UserIDRef:    user.ID,
+		TenantID:     user.TenantID,
 		RefreshToken: tokens.RefreshToken,
-		IP:           ctxholder.GetStringByKey(ctx, "client_ip"),
+		IP:           ctxholder.GetStringByKey(ctx, consts.CtxKeyClientIP),
+		UserAgent:    ctxholder.GetStringByKey(ctx, consts.CtxKeyUserAgent),
+		DeviceName:   ctxholder.GetStringByKey(ctx, consts.CtxKeyDeviceName),
 		StartedAt:    tools.GetPtr(startedAt),
-	}); err != nil {
+	}
+	err = s.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := s.manager.Repository().Sessions().Create(ctx, session); err != nil {
+			return err
+		}
+		if err := s.trackDevice(ctx, user, session); err != nil {
+			return err
+		}
+		return s.publishSessionStarted(ctx, user, session)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if err = s.manager.Repository().SessionsCache().Set(ctx, user.IDCode, startedAt); err != nil {
+	if err = s.manager.Repository().SessionsCache().Set(ctx, user.TenantID, user.IDCode, startedAt); err != nil {
 		return nil, err
 	}
 
+	tokens.SessionID = session.ID
 	return tokens, nil
 }
 
+// UpdateSession starts new session of the user on sign in
 func (s *AuthService) UpdateSession(ctx context.Context, user *models.User) (result *models.Tokens, err error) {
+	return s.updateSession(ctx, user, true)
+}
+
+// RefreshSession re-issues tokens of the existing session
+func (s *AuthService) RefreshSession(ctx context.Context, user *models.User) (result *models.Tokens, err error) {
+	return s.updateSession(ctx, user, false)
+}
+
+func (s *AuthService) updateSession(ctx context.Context, user *models.User, isSignIn bool) (*models.Tokens, error) {
 	startedAt := time.Now().Unix()
 	userSession := &models.UserSession{
+		UserID:        user.ID,
 		UserIDCode:    user.IDCode,
+		TenantID:      user.TenantID,
 		UserEmail:     user.Email,
 		UserActivated: user.Activated,
 		StartedAt:     startedAt,
 	}
 
-	tokens, err := s.NewPairTokens(userSession)
+	tokens, err := s.NewPairTokens(ctx, userSession)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = s.manager.Repository().Sessions().UpdateByUserID(ctx, &models.Session{
+	session := &models.Session{
 		UserIDRef:    user.ID,
+		TenantID:     user.TenantID,
 		RefreshToken: tokens.RefreshToken,
-		IP:           ctxholder.GetStringByKey(ctx, "client_ip"),
+		IP:           ctxholder.GetStringByKey(ctx, consts.CtxKeyClientIP),
+		UserAgent:    ctxholder.GetStringByKey(ctx, consts.CtxKeyUserAgent),
+		DeviceName:   ctxholder.GetStringByKey(ctx, consts.CtxKeyDeviceName),
 		StartedAt:    tools.GetPtr(startedAt),
-	}); err != nil {
+	}
+	err = s.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := s.manager.Repository().Sessions().UpdateByUserID(ctx, session); err != nil {
+			return err
+		}
+		// refreshes continue the session, devices are tracked and notified about when it starts
+		if !isSignIn {
+			return nil
+		}
+		if err := s.trackDevice(ctx, user, session); err != nil {
+			return err
+		}
+		return s.publishSessionStarted(ctx, user, session)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if err = s.manager.Repository().SessionsCache().Set(ctx, user.IDCode, startedAt); err != nil {
+	if err = s.manager.Repository().SessionsCache().Set(ctx, user.TenantID, user.IDCode, startedAt); err != nil {
 		return nil, err
 	}
 
+	tokens.SessionID = session.ID
 	return tokens, nil
 }
 
-func (s *AuthService) NewPairTokens(userSession *models.UserSession) (result *models.Tokens, err error) {
+// accessClaims carry roles and permissions of the user next to the registered claims,
+// so other services read them without parsing the issuer
+type accessClaims struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	jwt.RegisteredClaims
+}
+
+// NewPairTokens signs tokens with the keys of the user tenant, roles and permissions
+// of the user are embedded as claims of the access token
+func (s *AuthService) NewPairTokens(ctx context.Context, userSession *models.UserSession) (result *models.Tokens, err error) {
+	tenant, err := s.manager.Service().Tenant().GetByID(ctx, userSession.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	userSession.TenantID = tenant.ID
+
+	userSession.Roles, userSession.Permissions, err = s.manager.Service().Role().Claims(ctx, userSession.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	payload, err := json.Marshal(userSession)
 	if err != nil {
 		return
 	}
 
-	accessClaim := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.AccessTokenTTL)),
-		Issuer:    string(payload),
+	accessClaim := jwt.NewWithClaims(jwt.SigningMethodHS256, &accessClaims{
+		Roles:       userSession.Roles,
+		Permissions: userSession.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.AccessTokenTTL)),
+			Issuer:    string(payload),
+		},
 	})
 	refreshClaim := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.RefreshTokenTTL)),
 		Issuer:    string(payload),
 	})
-	accessToken, _ := accessClaim.SignedString([]byte(s.config.Token.AccessSecret))
-	refreshToken, _ := refreshClaim.SignedString([]byte(s.config.Token.RefreshSecret))
+	accessToken, _ := accessClaim.SignedString([]byte(tenant.AccessSecret))
+	refreshToken, _ := refreshClaim.SignedString([]byte(tenant.RefreshSecret))
 
 	return &models.Tokens{
 		AccessToken:  accessToken,
@@ -110,7 +186,13 @@ func (s *AuthService) NewPairTokens(userSession *models.UserSession) (result *mo
 }
 
 func (s *AuthService) ValidateRefreshToken(ctx context.Context, refreshToken string) (*models.UserSession, error) {
-	session, err := s.manager.Repository().Sessions().FindByToken(ctx, refreshToken)
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// sessions of other tenants are not found, so their tokens are rejected
+	session, err := s.manager.Repository().Sessions().FindByToken(ctx, tenant.ID, refreshToken)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +201,7 @@ func (s *AuthService) ValidateRefreshToken(ctx context.Context, refreshToken str
 	}
 
 	token, err := jwt.ParseWithClaims(refreshToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.Token.RefreshSecret), nil
+		return []byte(tenant.RefreshSecret), nil
 	})
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -134,6 +216,7 @@ func (s *AuthService) ValidateRefreshToken(ctx context.Context, refreshToken str
 	if err != nil {
 		return nil, errs.Wrap("unmarshal claims", err)
 	}
+	userSession.TenantID = tenant.ID
 
 	if !ok || !token.Valid || userSession.StartedAt != *session.StartedAt {
 		return nil, models.ErrInvalidToken
@@ -142,9 +225,7 @@ func (s *AuthService) ValidateRefreshToken(ctx context.Context, refreshToken str
 }
 
 func (s *AuthService) ValidateAccessToken(ctx context.Context, accessToken string) (*models.UserSession, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.Token.AccessSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(accessToken, &accessClaims{}, s.accessKey(ctx))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, models.ErrInvalidToken
@@ -152,18 +233,26 @@ func (s *AuthService) ValidateAccessToken(ctx context.Context, accessToken strin
 		return nil, errs.Wrap("parse with claims", err)
 	}
 
+	claims, ok := token.Claims.(*accessClaims)
+	if !ok || !token.Valid {
+		return nil, models.ErrInvalidToken
+	}
 	var userSession models.UserSession
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	err = json.Unmarshal([]byte(claims.Issuer), &userSession)
-	if err != nil {
+	if err = json.Unmarshal([]byte(claims.Issuer), &userSession); err != nil {
 		return nil, errs.Wrap("unmarshal claims", err)
 	}
-
-	if !ok || !token.Valid || userSession.UserIDCode == "" {
+	if userSession.UserIDCode == "" {
 		return nil, models.ErrInvalidToken
 	}
+	userSession.Roles, userSession.Permissions = claims.Roles, claims.Permissions
 
-	startedAt, err := s.manager.Repository().SessionsCache().Get(ctx, userSession.UserIDCode)
+	tenant, err := s.requestTenant(ctx, userSession.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	userSession.TenantID = tenant.ID
+
+	startedAt, err := s.manager.Repository().SessionsCache().Get(ctx, tenant.ID, userSession.UserIDCode)
 	if err != nil {
 		return nil, err
 	}
@@ -173,3 +262,204 @@ func (s *AuthService) ValidateAccessToken(ctx context.Context, accessToken strin
 
 	return &userSession, nil
 }
+
+// revokeClaims bind the revoke link to the sign in it was sent for
+type revokeClaims struct {
+	StartedAt int64 `json:"started_at"`
+	jwt.RegisteredClaims
+}
+
+// RevokeSession ends session referenced by the "this wasn't me" link from the new device notification.
+// The link works once and only while the session is the one it was sent for
+func (s *AuthService) RevokeSession(ctx context.Context, revokeToken string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventSessionRevoke}
+	defer s.manager.Service().Audit().Record(ctx, event, &err)
+
+	token, err := jwt.ParseWithClaims(revokeToken, &revokeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.config.Token.RevokeSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return models.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*revokeClaims)
+	if !ok || !token.Valid {
+		return models.ErrInvalidToken
+	}
+
+	sessionID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return models.ErrInvalidToken
+	}
+
+	session, err := s.manager.Repository().Sessions().FindByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return models.ErrSessionNotFound
+	}
+	event.UserIDRef = &session.UserIDRef
+	event.SessionIDRef = &session.ID
+
+	// ended session or the later sign in reusing the session row is not the one the link was sent for
+	if session.RefreshToken == "" || session.StartedAt == nil || *session.StartedAt != claims.StartedAt {
+		return models.ErrInvalidToken
+	}
+
+	user, err := s.manager.Repository().Users().FindByID(ctx, session.UserIDRef)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	event.Actor = user.Email
+
+	err = s.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := s.manager.Repository().Sessions().EndSession(ctx, session.ID); err != nil {
+			return err
+		}
+		return s.manager.Service().Outbox().Event(ctx, user.ID, models.EventSessionEnded, &models.SessionEventPayload{
+			UserID:    user.IDCode,
+			SessionID: session.ID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return s.manager.Repository().SessionsCache().Delete(ctx, user.TenantID, user.IDCode)
+}
+
+// accessKey verifies access token with the key of the tenant it was issued in,
+// tokens issued before tenants were introduced belong to the default tenant
+func (s *AuthService) accessKey(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		claims, ok := token.Claims.(*accessClaims)
+		if !ok {
+			return nil, models.ErrInvalidToken
+		}
+
+		var userSession models.UserSession
+		if err := json.Unmarshal([]byte(claims.Issuer), &userSession); err != nil {
+			return nil, errs.Wrap("unmarshal claims", err)
+		}
+
+		tenant, err := s.manager.Service().Tenant().GetByID(ctx, userSession.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(tenant.AccessSecret), nil
+	}
+}
+
+// requestTenant returns tenant of the token and rejects tokens used in another tenant
+func (s *AuthService) requestTenant(ctx context.Context, tenantID int64) (*models.Tenant, error) {
+	tenant, err := s.manager.Service().Tenant().GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.ID != current.ID {
+		return nil, models.ErrInvalidToken
+	}
+	return tenant, nil
+}
+
+// trackDevice remembers ip and user agent of the session and notifies user by email
+// when one of them was never used before. Notification is stored in the outbox
+// within the session transaction
+func (s *AuthService) trackDevice(ctx context.Context, user *models.User, session *models.Session) error {
+	devices, err := s.manager.Repository().UserDevices().FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var knownIP, knownUserAgent bool
+	for _, device := range devices {
+		knownIP = knownIP || device.IP == session.IP
+		knownUserAgent = knownUserAgent || device.UserAgent == session.UserAgent
+	}
+
+	now := time.Now().Unix()
+	if err = s.manager.Repository().UserDevices().Save(ctx, &models.UserDevice{
+		UserIDRef:   user.ID,
+		IP:          session.IP,
+		UserAgent:   session.UserAgent,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}); err != nil {
+		return err
+	}
+
+	// the very first device of the account is not suspicious
+	if len(devices) == 0 || (knownIP && knownUserAgent) || user.Email == "" || session.ID == 0 {
+		return nil
+	}
+	return s.notifyNewDevice(ctx, user, session)
+}
+
+func (s *AuthService) publishSessionStarted(ctx context.Context, user *models.User, session *models.Session) error {
+	return s.manager.Service().Outbox().Event(ctx, user.ID, models.EventSessionStarted, &models.SessionEventPayload{
+		UserID:     user.IDCode,
+		SessionID:  session.ID,
+		IP:         session.IP,
+		UserAgent:  session.UserAgent,
+		DeviceName: session.DeviceName,
+	})
+}
+
+func (s *AuthService) notifyNewDevice(ctx context.Context, user *models.User, session *models.Session) error {
+	if user.EmailBouncedAt != nil {
+		return nil
+	}
+
+	revokeToken, err := s.newRevokeToken(session)
+	if err != nil {
+		return err
+	}
+
+	revokeURL, err := url.Parse(s.config.ServerPublicURL)
+	if err != nil {
+		return errs.Wrap("parse public url", err)
+	}
+	revokeURL = revokeURL.JoinPath("/v1/auth/sessions/revoke")
+	query := revokeURL.Query()
+	query.Set("token", revokeToken)
+	revokeURL.RawQuery = query.Encode()
+
+	device := session.DeviceName
+	if device == "" {
+		device = session.UserAgent
+	}
+
+	return s.manager.Service().Outbox().Mail(ctx, user.ID, models.NewMailCommand(
+		models.MailTemplateNewDeviceSignIn,
+		mailLocale(ctx),
+		user.MailRecipient(user.Email),
+		models.MailVariables{
+			"device":       models.StringVar(device),
+			"ip":           models.StringVar(session.IP),
+			"location":     models.StringVar(s.manager.Processor().GeoIP().Locate(session.IP).String()),
+			"signed_in_at": models.DateTimeVar(time.Unix(*session.StartedAt, 0)),
+			"revoke_url":   models.URLVar(revokeURL.String()),
+		}))
+}
+
+func (s *AuthService) newRevokeToken(session *models.Session) (string, error) {
+	claim := jwt.NewWithClaims(jwt.SigningMethodHS256, &revokeClaims{
+		StartedAt: *session.StartedAt,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(session.ID, 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.RevokeTokenTTL)),
+		},
+	})
+	token, err := claim.SignedString([]byte(s.config.Token.RevokeSecret))
+	if err != nil {
+		return "", errs.Wrap("sign revoke token", err)
+	}
+	return token, nil
+}
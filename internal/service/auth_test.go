@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// revokeToken returns token of the latest "this wasn't me" link
+func revokeToken(t *testing.T, mails []models.MailCommand) string {
+	t.Helper()
+	require.NotEmpty(t, mails)
+
+	link, err := url.Parse(mails[len(mails)-1].Variables["revoke_url"].Value.(string))
+	require.NoError(t, err)
+	require.Equal(t, "/v1/auth/sessions/revoke", link.Path)
+	return link.Query().Get("token")
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	m, _ := managertest.New(t)
+	managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+
+	newDevice := managertest.RequestContext("10.0.0.2", "phone")
+	signIn, err := m.Service().User().Authenticate(newDevice, &models.UserDTO{Email: "user@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	token := revokeToken(t, managertest.PendingMails(t, m, models.MailTemplateNewDeviceSignIn))
+
+	ctx := context.Background()
+	require.NoError(t, m.Service().Auth().RevokeSession(ctx, token))
+
+	session, err := m.Repository().Sessions().FindByID(ctx, signIn.Tokens.SessionID)
+	require.NoError(t, err)
+	require.Empty(t, session.RefreshToken)
+	require.NotNil(t, session.EndedAt)
+
+	// the link works once
+	require.ErrorIs(t, m.Service().Auth().RevokeSession(ctx, token), models.ErrInvalidToken)
+}
+
+func TestAuthService_RevokeSession_LaterSignIn(t *testing.T) {
+	m, _ := managertest.New(t)
+	managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+
+	signIn, err := m.Service().User().Authenticate(managertest.RequestContext("10.0.0.2", "phone"),
+		&models.UserDTO{Email: "user@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	token := revokeToken(t, managertest.PendingMails(t, m, models.MailTemplateNewDeviceSignIn))
+
+	// the later sign in reuses the session row with the new start time
+	ctx := context.Background()
+	session, err := m.Repository().Sessions().FindByID(ctx, signIn.Tokens.SessionID)
+	require.NoError(t, err)
+	startedAt := *session.StartedAt + 1
+	session.StartedAt = &startedAt
+	require.NoError(t, m.Repository().Sessions().UpdateByID(ctx, session))
+
+	require.ErrorIs(t, m.Service().Auth().RevokeSession(ctx, token), models.ErrInvalidToken)
+
+	session, err = m.Repository().Sessions().FindByID(ctx, signIn.Tokens.SessionID)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.RefreshToken)
+}
+
+func TestAuthService_RevokeSession_InvalidToken(t *testing.T) {
+	m, _ := managertest.New(t)
+	response := managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+
+	ctx := context.Background()
+	session, err := m.Repository().Sessions().FindByID(ctx, response.Tokens.SessionID)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{
+		"sub":        strconv.FormatInt(session.ID, 10),
+		"started_at": *session.StartedAt,
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}
+	foreignAlg, err := jwt.NewWithClaims(jwt.SigningMethodHS384, claims).SignedString([]byte("revoke-secret"))
+	require.NoError(t, err)
+	foreignKey, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("other-secret"))
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "token", foreignAlg, foreignKey} {
+		require.ErrorIs(t, m.Service().Auth().RevokeSession(ctx, token), models.ErrInvalidToken)
+	}
+}
+
+func TestAuthService_RefreshFromNewDevice(t *testing.T) {
+	m, _ := managertest.New(t)
+	response := managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+
+	// the refresh continues the session, it is not a new sign in
+	_, err := m.Service().User().Refresh(managertest.RequestContext("10.0.0.2", "phone"), response.Tokens.RefreshToken)
+	require.NoError(t, err)
+	require.Empty(t, managertest.PendingMails(t, m, models.MailTemplateNewDeviceSignIn))
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(context.Background(), response.IDCode)
+	require.NoError(t, err)
+	devices, err := m.Repository().UserDevices().FindByUserID(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+}
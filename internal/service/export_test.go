@@ -0,0 +1,22 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"context"
+)
+
+var OutboxBackoff = outboxBackoff
+
+func RelayBatch(ctx context.Context, outbox interfaces.IOutboxService) error {
+	return outbox.(*OutboxService).relayBatch(ctx)
+}
+
+var NormalizeHost = normalizeHost
+
+func EraseNext(ctx context.Context, user interfaces.IUserService) (bool, error) {
+	return user.(*UserService).eraseNext(ctx)
+}
+
+func ExportNext(ctx context.Context, user interfaces.IUserService) (bool, error) {
+	return user.(*UserService).exportNext(ctx)
+}
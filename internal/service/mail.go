@@ -0,0 +1,41 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"github.com/doxanocap/pkg/ctxholder"
+	"go.uber.org/zap"
+)
+
+type MailService struct {
+	log     *zap.Logger
+	manager interfaces.IManager
+}
+
+func InitMailService(manager interfaces.IManager, log *zap.Logger) *MailService {
+	return &MailService{
+		log:     log,
+		manager: manager,
+	}
+}
+
+// DeadLetters returns verification mails that exhausted delivery attempts.
+// Only verification mails are exposed, other notifications are stale by the time of requeue
+func (s *MailService) DeadLetters(ctx context.Context, limit int) ([]models.DeadLetteredMail, error) {
+	return s.manager.Processor().Queue().DeadLetters().Mails(ctx, models.MailTemplateVerificationCode, limit)
+}
+
+// RequeueDeadLetters gives dead-lettered verification mails with given ids another round of attempts
+func (s *MailService) RequeueDeadLetters(ctx context.Context, ids []string) (int, error) {
+	return s.manager.Processor().Queue().DeadLetters().RequeueMails(ctx, models.MailTemplateVerificationCode, ids)
+}
+
+// mailLocale returns locale requested by the client or the default one
+func mailLocale(ctx context.Context) string {
+	if locale := ctxholder.GetStringByKey(ctx, consts.CtxKeyLocale); locale != "" {
+		return locale
+	}
+	return consts.DefaultLocale
+}
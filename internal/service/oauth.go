@@ -4,24 +4,72 @@ import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
 	"auth-api/internal/service/oauth"
+	"context"
 )
 
 type OAuthService struct {
 	config  *models.Config
 	manager interfaces.IManager
 
-	google interfaces.IGoogleAPI
+	google    interfaces.IGoogleAPI
+	providers map[models.OAuthProvider]interfaces.IOAuthProvider
 }
 
 func InitOAuthService(manager interfaces.IManager, config *models.Config) *OAuthService {
+	// google is enabled per tenant, so it is always registered and GoogleAPI checks the tenant
+	google := oauth.InitGoogleAPI(manager, config)
+	providers := map[models.OAuthProvider]interfaces.IOAuthProvider{
+		models.GoogleOAuth: google,
+	}
+	for name, provider := range oauth.InitProviders(manager, config) {
+		providers[name] = provider
+	}
+
 	return &OAuthService{
 		config:  config,
 		manager: manager,
 
-		google: oauth.InitGoogleAPI(manager, config),
+		google:    google,
+		providers: providers,
 	}
 }
 
 func (s *OAuthService) Google() interfaces.IGoogleAPI {
 	return s.google
 }
+
+// Provider returns google or social provider enabled by config
+func (s *OAuthService) Provider(name models.OAuthProvider) (interfaces.IOAuthProvider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, models.ErrProviderDisabled
+	}
+	return provider, nil
+}
+
+// Exchange returns the session of the provider call back once, the code is taken and deleted
+// in one cache operation, so parallel exchanges of the same code get it only once
+func (s *OAuthService) Exchange(ctx context.Context, code string) (*models.AuthResponse, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.manager.Repository().OAuthResults().GetDel(ctx, tenant.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, models.ErrOAuthCodeNotFound
+	}
+	return result, nil
+}
+
+// GetLinkURL returns url of the provider to link it to the user
+func (s *OAuthService) GetLinkURL(ctx context.Context, provider models.OAuthProvider, user *models.User) (*models.OAuthRedirectRes, error) {
+	found, err := s.Provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	return found.GetLinkURL(ctx, user)
+}
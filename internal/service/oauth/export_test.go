@@ -0,0 +1,8 @@
+package oauth
+
+// the identity flow is tested from oauth_test on the manager with memory drivers,
+// the manager can not be imported by the tests of the package itself
+var (
+	SignIn = signIn
+	Link   = link
+)
@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/tools"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// sealState returns a new state with returnTo sealed in it and the id of the state in the cache.
+// The state is signed, so the client can not change the path of the call back
+func sealState(secret, returnTo string) (state, id string) {
+	id = uuid.New().String()
+	payload := id + "." + base64.RawURLEncoding.EncodeToString([]byte(returnTo))
+	return payload + "." + stateSignature(secret, payload), id
+}
+
+// openState checks the signature of the state and returns its id and the sealed returnTo
+func openState(secret, state string) (id, returnTo string, err error) {
+	separator := strings.LastIndex(state, ".")
+	if separator < 0 {
+		return "", "", models.ErrStateNotFound
+	}
+	payload, signature := state[:separator], state[separator+1:]
+	if !hmac.Equal([]byte(signature), []byte(stateSignature(secret, payload))) {
+		return "", "", models.ErrStateNotFound
+	}
+
+	id, encoded, _ := strings.Cut(payload, ".")
+	path, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !tools.IsUUID(id) {
+		return "", "", models.ErrStateNotFound
+	}
+	return id, string(path), nil
+}
+
+func stateSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// saveState stores the authorization request until the provider calls back, states are never reused
+func saveState(
+	ctx context.Context,
+	manager interfaces.IManager,
+	provider models.OAuthProvider,
+	tenantID int64,
+	state string,
+	value *models.OAuthState) error {
+	codes := manager.Repository().OAuthCodes(provider)
+
+	found, err := codes.Get(ctx, tenantID, state)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return models.HttpConflict
+	}
+	return codes.Set(ctx, tenantID, state, value)
+}
+
+// findState returns the authorization request of the call back while it is not expired
+func findState(
+	ctx context.Context,
+	manager interfaces.IManager,
+	provider models.OAuthProvider,
+	tenantID int64,
+	state string,
+	awaitTime time.Duration) (*models.OAuthState, error) {
+	stored, err := manager.Repository().OAuthCodes(provider).Get(ctx, tenantID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if stored == nil || stored.CreatedAt > now.Unix() || time.Unix(stored.CreatedAt, 0).Add(awaitTime).Before(now) {
+		return nil, models.ErrStateNotFound
+	}
+	return stored, nil
+}
+
+// complete links the identity when the request was started by GetLinkURL, otherwise signs the user in
+// and keeps the session under a one-time code, so tokens never appear in the redirect url
+func complete(
+	ctx context.Context,
+	manager interfaces.IManager,
+	tenant *models.Tenant,
+	stored *models.OAuthState,
+	returnTo string,
+	profile *models.ExternalProfile,
+	event *models.AuthEvent) (*models.OAuthCallBackRes, error) {
+	event.Actor = profile.Email
+
+	if stored.LinkUserID != 0 {
+		event.Type = models.AuthEventIdentityLink
+		event.Reason = string(profile.Provider)
+		if err := link(ctx, manager, tenant, stored.LinkUserID, profile, event); err != nil {
+			return nil, err
+		}
+		return &models.OAuthCallBackRes{Linked: true, ReturnTo: returnTo}, nil
+	}
+
+	response, err := signIn(ctx, manager, tenant, profile, event)
+	if err != nil {
+		return nil, err
+	}
+	event.SessionIDRef = &response.Tokens.SessionID
+
+	code := uuid.New().String()
+	if err = manager.Repository().OAuthResults().Set(ctx, tenant.ID, code, response); err != nil {
+		return nil, err
+	}
+	return &models.OAuthCallBackRes{Code: code, ReturnTo: returnTo}, nil
+}
+
+// userProfile holds standard OIDC claims returned by the userinfo endpoint
+type userProfile struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Locale        string `json:"locale"`
+}
+
+func (up *userProfile) toExternal(provider models.OAuthProvider) *models.ExternalProfile {
+	locale := strings.ToLower(up.Locale)
+	if !tools.IsValidLocale(locale) {
+		locale = ""
+	}
+	return &models.ExternalProfile{
+		Provider:      provider,
+		Subject:       up.Sub,
+		Email:         up.Email,
+		EmailVerified: up.EmailVerified,
+		DisplayName:   up.Name,
+		FirstName:     up.GivenName,
+		LastName:      up.FamilyName,
+		PictureURL:    up.Picture,
+		Locale:        locale,
+	}
+}
@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"auth-api/internal/models"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSealState(t *testing.T) {
+	state, id := sealState("state-secret", "/settings?tab=security")
+	assert.True(t, strings.HasPrefix(state, id+"."))
+
+	openedID, returnTo, err := openState("state-secret", state)
+	require.NoError(t, err)
+	assert.Equal(t, id, openedID)
+	assert.Equal(t, "/settings?tab=security", returnTo)
+
+	// states of the link flow carry no path
+	state, id = sealState("state-secret", "")
+	openedID, returnTo, err = openState("state-secret", state)
+	require.NoError(t, err)
+	assert.Equal(t, id, openedID)
+	assert.Empty(t, returnTo)
+}
+
+func TestOpenState_Rejects(t *testing.T) {
+	state, id := sealState("state-secret", "/settings")
+	signature := state[strings.LastIndex(state, ".")+1:]
+	changedPath := id + "." + base64.RawURLEncoding.EncodeToString([]byte("//evil.example.com")) + "." + signature
+
+	testCases := []struct {
+		name   string
+		secret string
+		state  string
+	}{
+		{name: "other secret", secret: "other-secret", state: state},
+		{name: "changed path", secret: "state-secret", state: changedPath},
+		{name: "bare id", secret: "state-secret", state: id},
+		{name: "empty", secret: "state-secret", state: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, _, err := openState(testCase.secret, testCase.state)
+			assert.ErrorIs(t, err, models.ErrStateNotFound)
+		})
+	}
+}
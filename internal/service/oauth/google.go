@@ -4,12 +4,14 @@ import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
 	"auth-api/internal/models/consts"
-	"auth-api/internal/pkg/tools"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/doxanocap/pkg/gohttp"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"io"
@@ -21,109 +23,138 @@ type GoogleAPI struct {
 	appConfig *models.Config
 	manager   interfaces.IManager
 	awaitTime time.Duration
-
-	api *oauth2.Config
 }
 
 func InitGoogleAPI(manager interfaces.IManager, config *models.Config) *GoogleAPI {
 	return &GoogleAPI{
 		appConfig: config,
 		manager:   manager,
-		awaitTime: consts.GoogleAwaitTime,
-		api: &oauth2.Config{
-			ClientID:     config.OAuth.GoogleAPI.ClientID,
-			ClientSecret: config.OAuth.GoogleAPI.ClientSecret,
-			Scopes: []string{
-				consts.GoogleScopeUserProfile,
-				consts.GoogleScopeEmail},
-			Endpoint:    google.Endpoint,
-			RedirectURL: config.OAuth.ServerCallBackURI,
-		},
+		awaitTime: consts.OAuthAwaitTime,
 	}
 }
 
-func (g *GoogleAPI) GetRedirectURL(ctx context.Context, state string) (res *models.GoogleRedirectRes, err error) {
-	if !tools.IsUUID(state) {
-		return nil, models.ErrInvalidOAuthState
+// api returns oauth client of the tenant
+func (g *GoogleAPI) api(tenant *models.Tenant) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     tenant.GoogleClientID,
+		ClientSecret: tenant.GoogleClientSecret,
+		Scopes: []string{
+			consts.GoogleScopeOpenID,
+			consts.GoogleScopeUserProfile,
+			consts.GoogleScopeEmail},
+		Endpoint:    google.Endpoint,
+		RedirectURL: tenant.GoogleServerCallBackURI,
 	}
+}
 
-	oAuthURLParams := []oauth2.AuthCodeOption{
-		oauth2.SetAuthURLParam("response_type", "code"),
-	}
-	value, err := g.manager.Repository().GoogleAPICodes().Get(ctx, state)
+// tenant returns tenant of the request if it allows signing in with google
+func (g *GoogleAPI) tenant(ctx context.Context) (*models.Tenant, error) {
+	tenant, err := g.manager.Service().Tenant().Current(ctx)
 	if err != nil {
-		return
+		return nil, err
 	}
-	if value != 0 {
-		return nil, models.HttpConflict
+	if !tenant.AllowGoogle {
+		return nil, models.ErrGoogleDisabled
 	}
-	if err = g.manager.Repository().GoogleAPICodes().Set(ctx, state, time.Now().Unix()); err != nil {
-		return
+	return tenant, nil
+}
+
+// GetRedirectURL starts sign in with google, returnTo is a validated path of the client
+func (g *GoogleAPI) GetRedirectURL(ctx context.Context, returnTo string) (*models.OAuthRedirectRes, error) {
+	tenant, err := g.tenant(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return &models.GoogleRedirectRes{
-		RedirectURL: g.api.AuthCodeURL(state, oAuthURLParams...),
-	}, nil
+	return g.redirect(ctx, tenant, returnTo, &models.OAuthState{
+		CreatedAt: time.Now().Unix(),
+	})
 }
 
-func (g *GoogleAPI) HandleCallBack(ctx context.Context, state, exchangeCode string) (string, error) {
-	insertedAt, err := g.manager.Repository().GoogleAPICodes().Get(ctx, state)
+// GetLinkURL starts linking google account to the signed in user, the caller must
+// re-authenticate the user first
+func (g *GoogleAPI) GetLinkURL(ctx context.Context, user *models.User) (*models.OAuthRedirectRes, error) {
+	tenant, err := g.tenant(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return g.redirect(ctx, tenant, "", &models.OAuthState{
+		CreatedAt:  time.Now().Unix(),
+		LinkUserID: user.ID,
+	})
+}
 
-	now := time.Now()
-	if insertedAt > now.Unix() || now.Add(g.awaitTime).Unix() < insertedAt {
-		return "", models.ErrStateNotFound
+// redirect stores a new state with PKCE verifier and nonce, the provider gets only
+// the challenge of the verifier
+func (g *GoogleAPI) redirect(
+	ctx context.Context,
+	tenant *models.Tenant,
+	returnTo string,
+	value *models.OAuthState) (*models.OAuthRedirectRes, error) {
+	state, id := sealState(g.appConfig.Token.StateSecret, returnTo)
+	value.Verifier = oauth2.GenerateVerifier()
+	value.Nonce = uuid.New().String()
+
+	oAuthURLParams := []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("response_type", "code"),
+		oauth2.SetAuthURLParam("nonce", value.Nonce),
+		oauth2.S256ChallengeOption(value.Verifier),
 	}
+	if err := saveState(ctx, g.manager, models.GoogleOAuth, tenant.ID, id, value); err != nil {
+		return nil, err
+	}
+	return &models.OAuthRedirectRes{
+		RedirectURL: g.api(tenant).AuthCodeURL(state, oAuthURLParams...),
+		State:       state,
+	}, nil
+}
+
+// HandleCallBack signs the google user in, or links the google account when the state
+// was issued by GetLinkURL
+func (g *GoogleAPI) HandleCallBack(ctx context.Context, state, exchangeCode string) (result *models.OAuthCallBackRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventOAuthCallBack}
+	defer g.manager.Service().Audit().Record(ctx, event, &err)
 
-	token, err := g.api.Exchange(ctx, exchangeCode)
+	tenant, err := g.tenant(ctx)
 	if err != nil {
-		return "", errs.Wrap("g.exchange", err)
+		return nil, err
 	}
 
-	up, err := g.getUserInfo(ctx, token.AccessToken)
+	id, returnTo, err := openState(g.appConfig.Token.StateSecret, state)
 	if err != nil {
-		return "", errs.Wrap("g.GetUserInfo", err)
+		return nil, err
 	}
-
-	if err = g.manager.Repository().GoogleAPICodes().Delete(ctx, state); err != nil {
-		return "", err
+	stored, err := findState(ctx, g.manager, models.GoogleOAuth, tenant.ID, id, g.awaitTime)
+	if err != nil {
+		return nil, err
+	}
+	// states stored without PKCE are not accepted, so the flow is never downgraded
+	if stored.Verifier == "" || stored.Nonce == "" {
+		return nil, models.ErrStateNotFound
 	}
 
-	user, err := g.manager.Repository().Users().FindByEmail(ctx, up.Email)
+	token, err := g.api(tenant).Exchange(ctx, exchangeCode, oauth2.VerifierOption(stored.Verifier))
 	if err != nil {
-		return "", err
+		return nil, errs.Wrap("g.exchange", err)
 	}
-	if user == nil {
-		tokens, err := g.manager.Service().User().Create(ctx, &models.UserDTO{
-			Email:         up.Email,
-			Activated:     true,
-			OAuthProvider: models.GoogleOAuth,
-		})
-		if err != nil {
-			return "", err
-		}
 
-		return tokens.Tokens.AccessToken, nil
+	rawIDToken, _ := token.Extra("id_token").(string)
+	claims, err := validateIDToken(rawIDToken, tenant.GoogleClientID, stored.Nonce, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	tokens, err := g.manager.Service().Auth().UpdateSession(ctx, user)
+	up, err := g.getUserInfo(ctx, token.AccessToken)
 	if err != nil {
-		return "", err
+		return nil, errs.Wrap("g.GetUserInfo", err)
+	}
+	if up.Sub != claims.Subject {
+		return nil, models.ErrInvalidIDToken
 	}
 
-	return tokens.AccessToken, nil
-}
-
-type userProfile struct {
-	Sub           string `json:"sub"`
-	Name          string `json:"name"`
-	GivenName     string `json:"given_name"`
-	FamilyName    string `json:"family_name"`
-	Picture       string `json:"picture"`
-	Email         string `json:"email"`
-	EmailVerified bool   `json:"email_verified"`
-	Locale        string `json:"locale"`
+	if err = g.manager.Repository().OAuthCodes(models.GoogleOAuth).Delete(ctx, tenant.ID, id); err != nil {
+		return nil, err
+	}
+	return complete(ctx, g.manager, tenant, stored, returnTo, up.toExternal(models.GoogleOAuth), event)
 }
 
 func (g *GoogleAPI) getUserInfo(ctx context.Context, accessToken string) (*userProfile, error) {
@@ -147,5 +178,43 @@ func (g *GoogleAPI) getUserInfo(ctx context.Context, accessToken string) (*userP
 	if err := json.Unmarshal(body, up); err != nil {
 		return nil, err
 	}
+	if up.Sub == "" {
+		return nil, errs.New("empty subject")
+	}
 	return up, nil
 }
+
+var googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+type idTokenClaims struct {
+	Nonce string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// validateIDToken checks the id token received from the token endpoint. The token came over
+// TLS right from google, so its signature is not checked (OpenID Connect Core 3.1.3.7)
+func validateIDToken(rawIDToken, clientID, nonce string, now time.Time) (*idTokenClaims, error) {
+	if rawIDToken == "" {
+		return nil, models.ErrInvalidIDToken
+	}
+
+	claims := &idTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(rawIDToken, claims); err != nil {
+		return nil, models.ErrInvalidIDToken
+	}
+
+	validIssuer := false
+	for _, issuer := range googleIssuers {
+		validIssuer = validIssuer || claims.Issuer == issuer
+	}
+	validAudience := false
+	for _, audience := range claims.Audience {
+		validAudience = validAudience || audience == clientID
+	}
+	if !validIssuer || !validAudience || claims.Subject == "" ||
+		claims.ExpiresAt == nil || !now.Before(claims.ExpiresAt.Time) ||
+		subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, models.ErrInvalidIDToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,96 @@
+package oauth
+
+import (
+	"auth-api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateIDToken(t *testing.T) {
+	now := time.Now()
+	newToken := func(modify func(claims *idTokenClaims)) string {
+		claims := &idTokenClaims{
+			Nonce: "nonce",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "https://accounts.google.com",
+				Subject:   "42",
+				Audience:  jwt.ClaimStrings{testClientID},
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+		modify(claims)
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+
+	testCases := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{
+			name:        "valid",
+			token:       newToken(func(claims *idTokenClaims) {}),
+			expectedErr: nil,
+		},
+		{
+			name:        "issuer without scheme",
+			token:       newToken(func(claims *idTokenClaims) { claims.Issuer = "accounts.google.com" }),
+			expectedErr: nil,
+		},
+		{
+			name:        "empty token",
+			token:       "",
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "malformed token",
+			token:       "token",
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "other nonce",
+			token:       newToken(func(claims *idTokenClaims) { claims.Nonce = "other" }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "no nonce",
+			token:       newToken(func(claims *idTokenClaims) { claims.Nonce = "" }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "other audience",
+			token:       newToken(func(claims *idTokenClaims) { claims.Audience = jwt.ClaimStrings{"other"} }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "other issuer",
+			token:       newToken(func(claims *idTokenClaims) { claims.Issuer = "https://example.com" }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "expired",
+			token:       newToken(func(claims *idTokenClaims) { claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:        "no subject",
+			token:       newToken(func(claims *idTokenClaims) { claims.Subject = "" }),
+			expectedErr: models.ErrInvalidIDToken,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			claims, err := validateIDToken(testCase.token, testClientID, "nonce", now)
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+			if err == nil {
+				assert.Equal(t, "42", claims.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package oauth
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"context"
+)
+
+// signIn finds the user by the subject of the provider and starts a session, unknown users
+// are signed up. Users signed up by the provider before identities were stored are matched
+// by the verified email once and linked. Any other account with the same email must link
+// the provider itself, so owning the email at the provider does not give access to it
+func signIn(
+	ctx context.Context,
+	manager interfaces.IManager,
+	tenant *models.Tenant,
+	profile *models.ExternalProfile,
+	event *models.AuthEvent) (*models.AuthResponse, error) {
+	identities := manager.Repository().UserIdentities()
+
+	identity, err := identities.FindBySubject(ctx, tenant.ID, profile.Provider, profile.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	if identity != nil {
+		user, err = manager.Repository().Users().FindByID(ctx, identity.UserIDRef)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || user.DeletedAt != nil {
+			return nil, models.ErrUserNotFound
+		}
+		identity.Email = profile.Email
+		identity.EmailVerified = profile.EmailVerified
+		if err = identities.Touch(ctx, identity); err != nil {
+			return nil, err
+		}
+	} else {
+		if profile.Email != "" {
+			user, err = manager.Repository().Users().FindByEmail(ctx, tenant.ID, profile.Email)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if user == nil {
+			identity = profile.ToIdentity()
+			response, err := manager.Service().User().CreateWithIdentity(ctx, profile.ToUserDTO(), identity)
+			if err != nil {
+				return nil, err
+			}
+			// the identity is linked to the created user
+			event.UserIDRef = &identity.UserIDRef
+			return response, nil
+		}
+		event.UserIDRef = &user.ID
+		if user.OAuthProvider != profile.Provider || !profile.EmailVerified {
+			return nil, models.ErrIdentityNotLinked
+		}
+		linked, err := identities.FindByUserID(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if hasProvider(linked, profile.Provider) {
+			return nil, models.ErrIdentityNotLinked
+		}
+		identity = profile.ToIdentity()
+		identity.UserIDRef = user.ID
+		identity.TenantID = user.TenantID
+		if err = identities.Create(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	event.UserIDRef = &user.ID
+	if user.DisabledAt != nil {
+		return nil, models.ErrUserDisabled
+	}
+
+	if profile.ApplyTo(user) {
+		if err = manager.Repository().Users().Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	tokens, err := manager.Service().Auth().UpdateSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{
+		UserDTO: user.ToUserDTO(),
+		Tokens:  tokens,
+	}, nil
+}
+
+// link adds the identity of the provider to the user who started linking
+func link(
+	ctx context.Context,
+	manager interfaces.IManager,
+	tenant *models.Tenant,
+	userID int64,
+	profile *models.ExternalProfile,
+	event *models.AuthEvent) error {
+	identities := manager.Repository().UserIdentities()
+
+	user, err := manager.Repository().Users().FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.DeletedAt != nil || user.TenantID != tenant.ID {
+		return models.ErrUserNotFound
+	}
+	event.UserIDRef = &user.ID
+	event.Actor = user.Email
+	if user.DisabledAt != nil {
+		return models.ErrUserDisabled
+	}
+
+	identity, err := identities.FindBySubject(ctx, tenant.ID, profile.Provider, profile.Subject)
+	if err != nil {
+		return err
+	}
+	if identity != nil {
+		if identity.UserIDRef != user.ID {
+			return models.ErrIdentityLinked
+		}
+		return nil
+	}
+
+	linked, err := identities.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if hasProvider(linked, profile.Provider) {
+		return models.ErrProviderLinked
+	}
+	identity = profile.ToIdentity()
+	identity.UserIDRef = user.ID
+	identity.TenantID = user.TenantID
+	return identities.Create(ctx, identity)
+}
+
+func hasProvider(identities []models.UserIdentity, provider models.OAuthProvider) bool {
+	for i := range identities {
+		if identities[i].Provider == provider {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package oauth_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/service/oauth"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSignIn_SignUp(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	profile := &models.ExternalProfile{
+		Provider:      models.GoogleOAuth,
+		Subject:       "subject",
+		Email:         "user@example.com",
+		EmailVerified: true,
+	}
+	event := &models.AuthEvent{Type: models.AuthEventOAuthCallBack}
+	response, err := oauth.SignIn(ctx, m, tenant, profile, event)
+	require.NoError(t, err)
+	require.NotNil(t, response.Tokens)
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.NotNil(t, event.UserIDRef)
+	require.Equal(t, user.ID, *event.UserIDRef)
+
+	identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GoogleOAuth, "subject")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, identity.UserIDRef)
+}
+
+func TestSignIn_WithoutEmail(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	// github hides emails without the primary one, every sign up fails the same way
+	for _, subject := range []string{"first", "second"} {
+		profile := &models.ExternalProfile{Provider: models.GitHubOAuth, Subject: subject}
+		_, err = oauth.SignIn(ctx, m, tenant, profile, &models.AuthEvent{})
+		require.ErrorIs(t, err, models.ErrEmailRequired)
+
+		identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GitHubOAuth, subject)
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	}
+}
+
+func TestSignIn_KnownIdentity(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	profile := googleProfile("user@example.com")
+	first, err := oauth.SignIn(ctx, m, tenant, profile, &models.AuthEvent{})
+	require.NoError(t, err)
+
+	// the provider refreshes its fields and the email of the identity
+	profile.Email = "renamed@example.com"
+	profile.DisplayName = "Ann Lee"
+	event := &models.AuthEvent{}
+	response, err := oauth.SignIn(ctx, m, tenant, profile, event)
+	require.NoError(t, err)
+	require.Equal(t, first.IDCode, response.IDCode)
+	require.Equal(t, "user@example.com", response.Email)
+	require.Equal(t, "Ann Lee", response.DisplayName)
+	require.NotNil(t, event.UserIDRef)
+
+	identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GoogleOAuth, "subject")
+	require.NoError(t, err)
+	require.Equal(t, "renamed@example.com", identity.Email)
+}
+
+func TestSignIn_LinksLegacyProviderUser(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	// signed up by the provider before identities were stored
+	legacy, err := m.Service().User().Create(ctx, &models.UserDTO{
+		Email:         "user@example.com",
+		Activated:     true,
+		OAuthProvider: models.GoogleOAuth,
+	})
+	require.NoError(t, err)
+
+	response, err := oauth.SignIn(ctx, m, tenant, googleProfile("user@example.com"), &models.AuthEvent{})
+	require.NoError(t, err)
+	require.Equal(t, legacy.IDCode, response.IDCode)
+
+	identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GoogleOAuth, "subject")
+	require.NoError(t, err)
+	require.NotNil(t, identity)
+
+	// another subject of the same provider does not take the linked account
+	other := googleProfile("user@example.com")
+	other.Subject = "other"
+	_, err = oauth.SignIn(ctx, m, tenant, other, &models.AuthEvent{})
+	require.ErrorIs(t, err, models.ErrIdentityNotLinked)
+}
+
+func TestSignIn_EmailMatchIsNotLinked(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	_, err = m.Service().User().Create(ctx, &models.UserDTO{
+		Email:         "legacy@example.com",
+		OAuthProvider: models.GoogleOAuth,
+	})
+	require.NoError(t, err)
+	password := managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+
+	unverified := googleProfile("legacy@example.com")
+	unverified.EmailVerified = false
+
+	testCases := []struct {
+		name    string
+		profile *models.ExternalProfile
+	}{
+		{name: "password account", profile: googleProfile("user@example.com")},
+		{name: "unverified email", profile: unverified},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := oauth.SignIn(ctx, m, tenant, testCase.profile, &models.AuthEvent{})
+			require.ErrorIs(t, err, models.ErrIdentityNotLinked)
+		})
+	}
+
+	identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GoogleOAuth, "subject")
+	require.NoError(t, err)
+	require.Nil(t, identity)
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, password.IDCode)
+	require.NoError(t, err)
+	require.Equal(t, models.DefaultOAuth, user.OAuthProvider)
+}
+
+func TestSignIn_RejectsDisabledAndDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	disabled, err := oauth.SignIn(ctx, m, tenant, googleProfile("disabled@example.com"), &models.AuthEvent{})
+	require.NoError(t, err)
+	require.NoError(t, m.Service().User().Deactivate(ctx, disabled.IDCode, ""))
+	_, err = oauth.SignIn(ctx, m, tenant, googleProfile("disabled@example.com"), &models.AuthEvent{})
+	require.ErrorIs(t, err, models.ErrUserDisabled)
+
+	deletedProfile := googleProfile("deleted@example.com")
+	deletedProfile.Subject = "deleted"
+	deleted, err := oauth.SignIn(ctx, m, tenant, deletedProfile, &models.AuthEvent{})
+	require.NoError(t, err)
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, deleted.IDCode)
+	require.NoError(t, err)
+	require.NoError(t, m.Repository().Users().Erase(ctx, user.ID))
+	_, err = oauth.SignIn(ctx, m, tenant, deletedProfile, &models.AuthEvent{})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func googleProfile(email string) *models.ExternalProfile {
+	return &models.ExternalProfile{
+		Provider:      models.GoogleOAuth,
+		Subject:       "subject",
+		Email:         email,
+		EmailVerified: true,
+	}
+}
+
+func TestLink(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	owner, err := oauth.SignIn(ctx, m, tenant, googleProfile("owner@example.com"), &models.AuthEvent{})
+	require.NoError(t, err)
+	response := managertest.SignUp(t, m, managertest.RequestContext("10.0.0.1", "laptop"), "user@example.com")
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+
+	// the identity of another account is never moved
+	err = oauth.Link(ctx, m, tenant, user.ID, googleProfile("owner@example.com"), &models.AuthEvent{})
+	require.ErrorIs(t, err, models.ErrIdentityLinked)
+	identity, err := m.Repository().UserIdentities().FindBySubject(ctx, tenant.ID, models.GoogleOAuth, "subject")
+	require.NoError(t, err)
+	ownerUser, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, owner.IDCode)
+	require.NoError(t, err)
+	require.Equal(t, ownerUser.ID, identity.UserIDRef)
+
+	profile := googleProfile("user@example.com")
+	profile.Subject = "user"
+	require.NoError(t, oauth.Link(ctx, m, tenant, user.ID, profile, &models.AuthEvent{}))
+	// linking the same identity again is a no-op
+	require.NoError(t, oauth.Link(ctx, m, tenant, user.ID, profile, &models.AuthEvent{}))
+
+	second := googleProfile("user@example.com")
+	second.Subject = "second"
+	err = oauth.Link(ctx, m, tenant, user.ID, second, &models.AuthEvent{})
+	require.ErrorIs(t, err, models.ErrProviderLinked)
+}
@@ -0,0 +1,250 @@
+package oauth
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"golang.org/x/oauth2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// providerSettings describe how to talk to the provider
+type providerSettings struct {
+	name         models.OAuthProvider
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	// issuer of the OIDC provider, endpoints are taken from its discovery document
+	issuer      string
+	endpoint    oauth2.Endpoint
+	userInfoURL string
+
+	// profile reads the user from the provider with the access token
+	profile func(ctx context.Context, userInfoURL, accessToken string) (*models.ExternalProfile, error)
+}
+
+// Provider is a social login provider with the authorization code flow
+type Provider struct {
+	manager   interfaces.IManager
+	config    *models.Config
+	settings  providerSettings
+	awaitTime time.Duration
+
+	mu         sync.Mutex
+	discovered *discovery
+}
+
+func initProvider(manager interfaces.IManager, config *models.Config, settings providerSettings) *Provider {
+	return &Provider{
+		manager:   manager,
+		config:    config,
+		settings:  settings,
+		awaitTime: consts.OAuthAwaitTime,
+	}
+}
+
+// GetRedirectURL starts sign in with the provider, returnTo is a validated path of the client
+func (p *Provider) GetRedirectURL(ctx context.Context, returnTo string) (*models.OAuthRedirectRes, error) {
+	tenant, err := p.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.redirect(ctx, tenant, returnTo, &models.OAuthState{
+		CreatedAt: time.Now().Unix(),
+	})
+}
+
+// GetLinkURL starts linking the account at the provider to the signed in user, the caller
+// must re-authenticate the user first
+func (p *Provider) GetLinkURL(ctx context.Context, user *models.User) (*models.OAuthRedirectRes, error) {
+	tenant, err := p.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.redirect(ctx, tenant, "", &models.OAuthState{
+		CreatedAt:  time.Now().Unix(),
+		LinkUserID: user.ID,
+	})
+}
+
+func (p *Provider) redirect(
+	ctx context.Context,
+	tenant *models.Tenant,
+	returnTo string,
+	value *models.OAuthState) (*models.OAuthRedirectRes, error) {
+	state, id := sealState(p.config.Token.StateSecret, returnTo)
+	redirectURL, err := p.authURL(ctx, tenant, state)
+	if err != nil {
+		return nil, err
+	}
+	if err = saveState(ctx, p.manager, p.settings.name, tenant.ID, id, value); err != nil {
+		return nil, err
+	}
+	return &models.OAuthRedirectRes{RedirectURL: redirectURL, State: state}, nil
+}
+
+// HandleCallBack signs the user of the provider in, or links the account of the user when the state
+// was issued by GetLinkURL
+func (p *Provider) HandleCallBack(ctx context.Context, state, exchangeCode string) (result *models.OAuthCallBackRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventOAuthCallBack, Reason: string(p.settings.name)}
+	defer p.manager.Service().Audit().Record(ctx, event, &err)
+
+	tenant, err := p.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, returnTo, err := openState(p.config.Token.StateSecret, state)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := findState(ctx, p.manager, p.settings.name, tenant.ID, id, p.awaitTime)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := p.fetchProfile(ctx, tenant, exchangeCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.manager.Repository().OAuthCodes(p.settings.name).Delete(ctx, tenant.ID, id); err != nil {
+		return nil, err
+	}
+	return complete(ctx, p.manager, tenant, stored, returnTo, profile, event)
+}
+
+func (p *Provider) authURL(ctx context.Context, tenant *models.Tenant, state string) (string, error) {
+	api, _, err := p.api(ctx, tenant)
+	if err != nil {
+		return "", err
+	}
+	return api.AuthCodeURL(state, oauth2.SetAuthURLParam("response_type", "code")), nil
+}
+
+// fetchProfile exchanges the code for the access token and reads the user with it
+func (p *Provider) fetchProfile(ctx context.Context, tenant *models.Tenant, exchangeCode string) (*models.ExternalProfile, error) {
+	api, userInfoURL, err := p.api(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := api.Exchange(ctx, exchangeCode)
+	if err != nil {
+		return nil, errs.Wrap(fmt.Sprintf("%s.exchange", p.settings.name), err)
+	}
+
+	profile, err := p.settings.profile(ctx, userInfoURL, token.AccessToken)
+	if err != nil {
+		return nil, errs.Wrap(fmt.Sprintf("%s.profile", p.settings.name), err)
+	}
+	if profile.Subject == "" {
+		return nil, errs.New(fmt.Sprintf("%s.profile: empty subject", p.settings.name))
+	}
+	return profile, nil
+}
+
+// api returns oauth client of the provider for the tenant and the url of the user info
+func (p *Provider) api(ctx context.Context, tenant *models.Tenant) (*oauth2.Config, string, error) {
+	endpoint, userInfoURL := p.settings.endpoint, p.settings.userInfoURL
+	if p.settings.issuer != "" {
+		found, err := p.discover(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		endpoint = oauth2.Endpoint{
+			AuthURL:  found.AuthorizationEndpoint,
+			TokenURL: found.TokenEndpoint,
+		}
+		userInfoURL = found.UserInfoEndpoint
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.settings.clientID,
+		ClientSecret: p.settings.clientSecret,
+		Scopes:       p.settings.scopes,
+		Endpoint:     endpoint,
+		RedirectURL:  p.callBackURL(tenant),
+	}, userInfoURL, nil
+}
+
+// callBackURL is the call back of the provider, tenants other than the default one are
+// passed in the query, so the call back is served by the same tenant
+func (p *Provider) callBackURL(tenant *models.Tenant) string {
+	raw := strings.ReplaceAll(p.config.OAuth.CallBackURI, "{provider}", string(p.settings.name))
+	if tenant.Code == consts.DefaultTenantCode {
+		return raw
+	}
+
+	callBackURL, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := callBackURL.Query()
+	query.Set(consts.QueryTenant, tenant.Code)
+	callBackURL.RawQuery = query.Encode()
+	return callBackURL.String()
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// discover loads the discovery document of the issuer once, failed loads are retried
+// on the next request. The lock is not held during the fetch, so a slow issuer does not
+// block other requests, parallel first loads may fetch the document twice
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	discovered := p.discovered
+	p.mu.Unlock()
+	if discovered != nil {
+		return discovered, nil
+	}
+
+	found := &discovery{}
+	documentURL := strings.TrimSuffix(p.settings.issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, documentURL, "", found); err != nil {
+		return nil, errs.Wrap(fmt.Sprintf("%s.discover", p.settings.name), err)
+	}
+	if !sameIssuer(p.settings.issuer, found.Issuer) {
+		return nil, errs.New(fmt.Sprintf("%s.discover: issuer %q of the document does not match", p.settings.name, found.Issuer))
+	}
+	if found.AuthorizationEndpoint == "" || found.TokenEndpoint == "" || found.UserInfoEndpoint == "" {
+		return nil, errs.New(fmt.Sprintf("%s.discover: incomplete discovery document", p.settings.name))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discovered = found
+	return found, nil
+}
+
+// sameIssuer compares the configured issuer with the issuer of its discovery document. Multi-tenant
+// issuers of Microsoft (common, organizations) publish {tenantid} in place of the tenant segment
+func sameIssuer(configured, found string) bool {
+	configured, found = strings.TrimSuffix(configured, "/"), strings.TrimSuffix(found, "/")
+	if found == "" {
+		return false
+	}
+	if configured == found {
+		return true
+	}
+
+	prefix, suffix, ok := strings.Cut(found, consts.OIDCTenantPlaceholder)
+	if !ok || !strings.HasPrefix(configured, prefix) || !strings.HasSuffix(configured, suffix) ||
+		len(configured) <= len(prefix)+len(suffix) {
+		return false
+	}
+	segment := configured[len(prefix) : len(configured)-len(suffix)]
+	return !strings.Contains(segment, "/")
+}
@@ -0,0 +1,313 @@
+package oauth
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const (
+	testClientID     = "client-id"
+	testClientSecret = "client-secret"
+	testCode         = "exchange-code"
+	testAccessToken  = "access-token"
+)
+
+// newFakeOIDC starts the provider with discovery, token and userinfo endpoints
+func newFakeOIDC(t *testing.T, claims map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"userinfo_endpoint":      server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+		}
+		if clientID != testClientID || clientSecret != testClientSecret || r.FormValue("code") != testCode {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"access_token": testAccessToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, claims)
+	})
+	return server
+}
+
+func newTestProvider(settings providerSettings) *Provider {
+	settings.clientID = testClientID
+	settings.clientSecret = testClientSecret
+	return initProvider(nil, &models.Config{
+		OAuth: models.OAuth{
+			OAuthProviders: models.OAuthProviders{
+				CallBackURI: "http://localhost:5000/v1/auth/oauth/{provider}/call-back",
+			},
+		},
+	}, settings)
+}
+
+func TestProvider_AuthURL(t *testing.T) {
+	server := newFakeOIDC(t, nil)
+	provider := newTestProvider(providerSettings{
+		name:    models.GitLabOAuth,
+		scopes:  []string{"openid", "email"},
+		issuer:  server.URL,
+		profile: oidcProfile(models.GitLabOAuth),
+	})
+
+	testCases := []struct {
+		name        string
+		tenant      *models.Tenant
+		callBackURL string
+	}{
+		{
+			name:        "default tenant",
+			tenant:      &models.Tenant{Code: consts.DefaultTenantCode},
+			callBackURL: "http://localhost:5000/v1/auth/oauth/gitlab/call-back",
+		},
+		{
+			name:        "other tenant",
+			tenant:      &models.Tenant{Code: "acme"},
+			callBackURL: "http://localhost:5000/v1/auth/oauth/gitlab/call-back?tenant=acme",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			raw, err := provider.authURL(context.Background(), testCase.tenant, "state")
+			require.NoError(t, err)
+
+			authURL, err := url.Parse(raw)
+			require.NoError(t, err)
+			assert.Equal(t, server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+			query := authURL.Query()
+			assert.Equal(t, testClientID, query.Get("client_id"))
+			assert.Equal(t, "state", query.Get("state"))
+			assert.Equal(t, "code", query.Get("response_type"))
+			assert.Equal(t, "openid email", query.Get("scope"))
+			assert.Equal(t, testCase.callBackURL, query.Get("redirect_uri"))
+		})
+	}
+}
+
+func TestProvider_FetchProfile(t *testing.T) {
+	server := newFakeOIDC(t, map[string]interface{}{
+		"sub":            "42",
+		"name":           "Jane Doe",
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"picture":        "https://example.com/jane.png",
+		"locale":         "EN",
+	})
+	provider := newTestProvider(providerSettings{
+		name:    models.MicrosoftOAuth,
+		issuer:  server.URL + "/",
+		profile: oidcProfile(models.MicrosoftOAuth),
+	})
+	tenant := &models.Tenant{Code: consts.DefaultTenantCode}
+
+	profile, err := provider.fetchProfile(context.Background(), tenant, testCode)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ExternalProfile{
+		Provider:      models.MicrosoftOAuth,
+		Subject:       "42",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		DisplayName:   "Jane Doe",
+		FirstName:     "Jane",
+		LastName:      "Doe",
+		PictureURL:    "https://example.com/jane.png",
+		Locale:        "en",
+	}, profile)
+
+	_, err = provider.fetchProfile(context.Background(), tenant, "wrong-code")
+	assert.Error(t, err)
+}
+
+func TestProvider_FetchProfileWithoutSubject(t *testing.T) {
+	server := newFakeOIDC(t, map[string]interface{}{"email": "jane@example.com"})
+	provider := newTestProvider(providerSettings{
+		name:    models.GitLabOAuth,
+		issuer:  server.URL,
+		profile: oidcProfile(models.GitLabOAuth),
+	})
+
+	_, err := provider.fetchProfile(context.Background(), &models.Tenant{Code: consts.DefaultTenantCode}, testCode)
+	assert.Error(t, err)
+}
+
+func TestProvider_Discover(t *testing.T) {
+	incomplete := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": "issuer"})
+	}))
+	t.Cleanup(incomplete.Close)
+
+	provider := newTestProvider(providerSettings{name: models.GitLabOAuth, issuer: incomplete.URL})
+	_, err := provider.discover(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, provider.discovered)
+
+	// the document of another issuer is not trusted
+	server := newFakeOIDC(t, nil)
+	provider = newTestProvider(providerSettings{name: models.GitLabOAuth, issuer: server.URL + "/gitlab"})
+	_, err = provider.discover(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, provider.discovered)
+
+	provider = newTestProvider(providerSettings{name: models.GitLabOAuth, issuer: server.URL})
+	found, err := provider.discover(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/token", found.TokenEndpoint)
+	assert.Same(t, found, provider.discovered)
+}
+
+func TestProvider_DiscoverDoesNotHoldLock(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var slow *httptest.Server
+	slow = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 slow.URL,
+			"authorization_endpoint": slow.URL + "/authorize",
+			"token_endpoint":         slow.URL + "/token",
+			"userinfo_endpoint":      slow.URL + "/userinfo",
+		})
+	}))
+	t.Cleanup(slow.Close)
+
+	provider := newTestProvider(providerSettings{name: models.GitLabOAuth, issuer: slow.URL})
+	done := make(chan error)
+	go func() {
+		_, err := provider.discover(context.Background())
+		done <- err
+	}()
+
+	<-started
+	locked := provider.mu.TryLock()
+	if locked {
+		provider.mu.Unlock()
+	}
+	close(release)
+	assert.True(t, locked)
+	require.NoError(t, <-done)
+	assert.NotNil(t, provider.discovered)
+}
+
+func TestSameIssuer(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured string
+		found      string
+		same       bool
+	}{
+		{name: "equal", configured: "https://gitlab.com", found: "https://gitlab.com", same: true},
+		{name: "trailing slash", configured: "https://gitlab.com/", found: "https://gitlab.com", same: true},
+		{name: "other issuer", configured: "https://gitlab.com", found: "https://evil.example.com", same: false},
+		{name: "empty", configured: "https://gitlab.com", found: "", same: false},
+		{
+			name:       "microsoft common",
+			configured: "https://login.microsoftonline.com/common/v2.0",
+			found:      "https://login.microsoftonline.com/{tenantid}/v2.0",
+			same:       true,
+		},
+		{
+			name:       "template of another host",
+			configured: "https://login.microsoftonline.com/common/v2.0",
+			found:      "https://evil.example.com/{tenantid}/v2.0",
+			same:       false,
+		},
+		{
+			name:       "template of several segments",
+			configured: "https://login.microsoftonline.com/a/b/v2.0",
+			found:      "https://login.microsoftonline.com/{tenantid}/v2.0",
+			same:       false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.same, sameIssuer(testCase.configured, testCase.found))
+		})
+	}
+}
+
+func TestGitHubProfile(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         1001,
+			"login":      "jane",
+			"avatar_url": "https://example.com/jane.png",
+		})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "jane@example.com", "primary": true, "verified": true},
+		})
+	})
+
+	profile, err := githubProfile(context.Background(), server.URL+"/user", testAccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ExternalProfile{
+		Provider:      models.GitHubOAuth,
+		Subject:       "1001",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		DisplayName:   "jane",
+		PictureURL:    "https://example.com/jane.png",
+	}, profile)
+}
+
+func TestInitProviders(t *testing.T) {
+	providers := InitProviders(nil, &models.Config{
+		OAuth: models.OAuth{
+			OAuthProviders: models.OAuthProviders{
+				GitHubClientID:     testClientID,
+				GitHubClientSecret: testClientSecret,
+				GitLabClientID:     `""`,
+				YandexClientID:     testClientID,
+			},
+		},
+	})
+
+	assert.Len(t, providers, 2)
+	assert.Contains(t, providers, models.GitHubOAuth)
+	assert.Contains(t, providers, models.YandexOAuth)
+}
@@ -0,0 +1,180 @@
+package oauth
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/tools"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/doxanocap/pkg/gohttp"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/yandex"
+	"net/http"
+	"strconv"
+)
+
+// InitProviders returns social providers enabled by config, google is served by GoogleAPI
+func InitProviders(manager interfaces.IManager, config *models.Config) map[models.OAuthProvider]*Provider {
+	providers := config.OAuth.OAuthProviders
+	all := []providerSettings{
+		{
+			name:         models.GitHubOAuth,
+			clientID:     tools.EnvString(providers.GitHubClientID),
+			clientSecret: tools.EnvString(providers.GitHubClientSecret),
+			scopes:       []string{"read:user", "user:email"},
+			endpoint:     github.Endpoint,
+			userInfoURL:  "https://api.github.com/user",
+			profile:      githubProfile,
+		},
+		{
+			name:         models.GitLabOAuth,
+			clientID:     tools.EnvString(providers.GitLabClientID),
+			clientSecret: tools.EnvString(providers.GitLabClientSecret),
+			scopes:       []string{"openid", "profile", "email"},
+			issuer:       providers.GitLabIssuer,
+			profile:      oidcProfile(models.GitLabOAuth),
+		},
+		{
+			name:         models.MicrosoftOAuth,
+			clientID:     tools.EnvString(providers.MicrosoftClientID),
+			clientSecret: tools.EnvString(providers.MicrosoftClientSecret),
+			scopes:       []string{"openid", "profile", "email"},
+			issuer:       providers.MicrosoftIssuer,
+			profile:      oidcProfile(models.MicrosoftOAuth),
+		},
+		{
+			name:         models.YandexOAuth,
+			clientID:     tools.EnvString(providers.YandexClientID),
+			clientSecret: tools.EnvString(providers.YandexClientSecret),
+			scopes:       []string{"login:email", "login:info", "login:avatar"},
+			endpoint:     yandex.Endpoint,
+			userInfoURL:  "https://login.yandex.ru/info?format=json",
+			profile:      yandexProfile,
+		},
+	}
+
+	result := make(map[models.OAuthProvider]*Provider, len(all))
+	for _, settings := range all {
+		if settings.clientID == "" {
+			continue
+		}
+		result[settings.name] = initProvider(manager, config, settings)
+	}
+	return result
+}
+
+// oidcProfile reads standard claims of the OIDC provider
+func oidcProfile(provider models.OAuthProvider) func(ctx context.Context, userInfoURL, accessToken string) (*models.ExternalProfile, error) {
+	return func(ctx context.Context, userInfoURL, accessToken string) (*models.ExternalProfile, error) {
+		up := &userProfile{}
+		if err := getJSON(ctx, userInfoURL, "Bearer "+accessToken, up); err != nil {
+			return nil, err
+		}
+		return up.toExternal(provider), nil
+	}
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// githubProfile reads the user and the primary email, public email of the profile may be
+// hidden or not verified
+func githubProfile(ctx context.Context, userInfoURL, accessToken string) (*models.ExternalProfile, error) {
+	user := &githubUser{}
+	if err := getJSON(ctx, userInfoURL, "Bearer "+accessToken, user); err != nil {
+		return nil, err
+	}
+	var emails []githubEmail
+	if err := getJSON(ctx, userInfoURL+"/emails", "Bearer "+accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &models.ExternalProfile{
+		Provider:    models.GitHubOAuth,
+		DisplayName: user.Name,
+		PictureURL:  user.AvatarURL,
+	}
+	if user.ID != 0 {
+		profile.Subject = strconv.FormatInt(user.ID, 10)
+	}
+	if profile.DisplayName == "" {
+		profile.DisplayName = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+		}
+	}
+	return profile, nil
+}
+
+type yandexUser struct {
+	ID              string `json:"id"`
+	DefaultEmail    string `json:"default_email"`
+	DisplayName     string `json:"display_name"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	DefaultAvatarID string `json:"default_avatar_id"`
+	IsAvatarEmpty   bool   `json:"is_avatar_empty"`
+}
+
+// yandexProfile reads the user of Yandex ID, its default email is always confirmed
+func yandexProfile(ctx context.Context, userInfoURL, accessToken string) (*models.ExternalProfile, error) {
+	user := &yandexUser{}
+	if err := getJSON(ctx, userInfoURL, "OAuth "+accessToken, user); err != nil {
+		return nil, err
+	}
+
+	profile := &models.ExternalProfile{
+		Provider:      models.YandexOAuth,
+		Subject:       user.ID,
+		Email:         user.DefaultEmail,
+		EmailVerified: user.DefaultEmail != "",
+		DisplayName:   user.DisplayName,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+	}
+	if !user.IsAvatarEmpty && user.DefaultAvatarID != "" {
+		profile.PictureURL = fmt.Sprintf("https://avatars.yandex.net/get-yapic/%s/islands-200", user.DefaultAvatarID)
+	}
+	return profile, nil
+}
+
+// getJSON reads the response of the provider, authorization is omitted when empty
+func getJSON(ctx context.Context, url, authorization string, dst interface{}) error {
+	request := gohttp.NewRequest().
+		SetURL(url).
+		SetMethod(http.MethodGet).
+		SetRequestFormat(gohttp.FormatJSON).
+		SetHeader("Accept", "application/json")
+	if authorization != "" {
+		request.SetHeader("Authorization", authorization)
+	}
+
+	response, err := request.Execute(ctx)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return errs.New(fmt.Sprintf("unexpected status %d", response.StatusCode))
+	}
+	if err = json.NewDecoder(response.Body).Decode(dst); err != nil {
+		return errs.Wrap("decode response", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestOAuthService_ExchangeOnce(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+
+	result := &models.AuthResponse{
+		UserDTO: models.UserDTO{Email: "user@example.com"},
+		Tokens:  &models.Tokens{AccessToken: "access", RefreshToken: "refresh"},
+	}
+	require.NoError(t, m.Repository().OAuthResults().Set(ctx, tenant.ID, "code", result))
+
+	exchanged, err := m.Service().OAuth().Exchange(ctx, "code")
+	require.NoError(t, err)
+	require.Equal(t, result, exchanged)
+
+	_, err = m.Service().OAuth().Exchange(ctx, "code")
+	require.ErrorIs(t, err, models.ErrOAuthCodeNotFound)
+}
+
+func TestOAuthService_Provider(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+
+	// google is served by the generic routes as well
+	google, err := m.Service().OAuth().Provider(models.GoogleOAuth)
+	require.NoError(t, err)
+	require.Same(t, m.Service().OAuth().Google(), google)
+
+	redirect, err := google.GetRedirectURL(ctx, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(redirect.RedirectURL, "https://accounts.google.com/"))
+
+	_, err = m.Service().OAuth().Provider(models.GitHubOAuth)
+	require.ErrorIs(t, err, models.ErrProviderDisabled)
+}
+
+func TestOAuthService_StateIsStoredByID(t *testing.T) {
+	ctx := context.Background()
+	m, _ := managertest.New(t)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	redirect, err := m.Service().OAuth().Google().GetRedirectURL(ctx, "/settings")
+	require.NoError(t, err)
+
+	// the path is sealed in the state, the cache keeps the rest by the id of the state
+	id, _, _ := strings.Cut(redirect.State, ".")
+	stored, err := m.Repository().OAuthCodes(models.GoogleOAuth).Get(ctx, tenant.ID, id)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	require.NotEmpty(t, stored.Verifier)
+
+	stored, err = m.Repository().OAuthCodes(models.GoogleOAuth).Get(ctx, tenant.ID, redirect.State)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
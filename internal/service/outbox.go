@@ -0,0 +1,270 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OutboxService struct {
+	log     *zap.Logger
+	manager interfaces.IManager
+}
+
+func InitOutboxService(manager interfaces.IManager, log *zap.Logger) *OutboxService {
+	return &OutboxService{
+		log:     log,
+		manager: manager,
+	}
+}
+
+// Mail validates mail command and stores it into the outbox, joins transaction of the ctx if there is one.
+// userID is the user the mail is about, 0 when there is no user yet
+func (s *OutboxService) Mail(ctx context.Context, userID int64, message *models.MailCommand) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return errs.Wrap("marshal mail", err)
+	}
+
+	return s.manager.Repository().Outbox().Create(ctx, &models.OutboxMessage{
+		MessageID: message.ID,
+		Topic:     models.OutboxTopicMails,
+		Payload:   payload,
+		UserIDRef: outboxUser(userID),
+	})
+}
+
+// Sms validates sms command and stores it into the outbox, joins transaction of the ctx if there is one
+func (s *OutboxService) Sms(ctx context.Context, userID int64, message *models.SmsCommand) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return errs.Wrap("marshal sms", err)
+	}
+
+	return s.manager.Repository().Outbox().Create(ctx, &models.OutboxMessage{
+		MessageID: message.ID,
+		Topic:     models.OutboxTopicSms,
+		Payload:   payload,
+		UserIDRef: outboxUser(userID),
+	})
+}
+
+// Event wraps payload into the event envelope and stores it into the outbox,
+// joins transaction of the ctx if there is one
+func (s *OutboxService) Event(ctx context.Context, userID int64, eventType models.EventType, payload interface{}) error {
+	event, err := models.NewEvent(eventType, payload)
+	if err != nil {
+		return errs.Wrap("new event", err)
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return errs.Wrap("marshal event", err)
+	}
+
+	return s.manager.Repository().Outbox().Create(ctx, &models.OutboxMessage{
+		MessageID: event.ID,
+		Topic:     models.OutboxTopicEvents,
+		Payload:   raw,
+		UserIDRef: outboxUser(userID),
+	})
+}
+
+// outboxUser ties the message to the user, so it is purged when the user is erased
+func outboxUser(userID int64) *int64 {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
+
+// RunRelay publishes stored messages until ctx is done
+func (s *OutboxService) RunRelay(ctx context.Context) {
+	log := s.log.Named("RunRelay")
+	ticker := time.NewTicker(consts.OutboxRelayInterval)
+	defer ticker.Stop()
+
+	log.Info("started")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopped")
+			return
+		case <-ticker.C:
+			if err := s.relayBatch(ctx); err != nil {
+				log.Error(err.Error())
+			}
+			if err := s.manager.Repository().Outbox().
+				DeletePublished(ctx, time.Now().Add(-consts.OutboxRetention)); err != nil {
+				log.Error(err.Error())
+			}
+		}
+	}
+}
+
+// relayBatch claims messages and publishes them outside of any transaction, so rows are not locked
+// while the broker is awaited. Messages that failed too many times are dead-lettered
+func (s *OutboxService) relayBatch(ctx context.Context) error {
+	outbox := s.manager.Repository().Outbox()
+	messages, err := outbox.Claim(ctx, consts.OutboxBatchSize, consts.OutboxClaimLease)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		message := &messages[i]
+		publishErr := s.publish(ctx, message)
+		if publishErr == nil {
+			if err = outbox.MarkPublished(ctx, message.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if errors.Is(publishErr, models.ErrQueueUnavailable) {
+			// the broker is down, messages stay pending until it is back
+			s.log.Warn(publishErr.Error())
+			return s.release(ctx, messages[i:])
+		}
+
+		attempt := message.Attempts + 1
+		log := s.log.With(
+			zap.String("message_id", message.MessageID),
+			zap.String("topic", string(message.Topic)),
+			zap.Int("attempt", attempt))
+		if attempt >= consts.OutboxMaxAttempts {
+			log.Error(fmt.Sprintf("dead-lettered: %s", publishErr))
+			err = outbox.MarkDead(ctx, message.ID, publishErr.Error())
+		} else {
+			log.Warn(publishErr.Error())
+			err = outbox.MarkFailed(ctx, message.ID, publishErr.Error(), time.Now().Add(outboxBackoff(attempt)))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *OutboxService) release(ctx context.Context, messages []models.OutboxMessage) error {
+	for i := range messages {
+		if err := s.manager.Repository().Outbox().Release(ctx, messages[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *OutboxService) publish(ctx context.Context, message *models.OutboxMessage) error {
+	producers := s.manager.Processor().Queue().Producers()
+
+	switch message.Topic {
+	case models.OutboxTopicMails:
+		mail, err := decodeMail(message.Payload)
+		if err != nil {
+			return errs.Wrap("unmarshal mail", err)
+		}
+		return producers.Mails().Send(ctx, mail)
+	case models.OutboxTopicSms:
+		sms := &models.SmsCommand{}
+		if err := json.Unmarshal(message.Payload, sms); err != nil {
+			return errs.Wrap("unmarshal sms", err)
+		}
+		return producers.Sms().Send(ctx, sms)
+	case models.OutboxTopicEvents:
+		event := &models.Event{}
+		if err := json.Unmarshal(message.Payload, event); err != nil {
+			return errs.Wrap("unmarshal event", err)
+		}
+		return producers.Events().Publish(ctx, event)
+	default:
+		return fmt.Errorf("unknown outbox topic: %s", message.Topic)
+	}
+}
+
+// legacyMail is the mail message stored before mail commands were versioned,
+// such messages may still be pending in the outbox after the upgrade
+type legacyMail struct {
+	Type             models.MailTemplate `json:"type"`
+	SendTo           string              `json:"send_to"`
+	VerificationCode string              `json:"verification_code"`
+	NewDevice        *struct {
+		Device     string    `json:"device"`
+		IP         string    `json:"ip"`
+		Location   string    `json:"location"`
+		SignedInAt time.Time `json:"signed_in_at"`
+		RevokeURL  string    `json:"revoke_url"`
+	} `json:"new_device"`
+}
+
+// decodeMail reads the mail command, messages of the legacy format are converted to it
+func decodeMail(payload []byte) (*models.MailCommand, error) {
+	mail := &models.MailCommand{}
+	if err := json.Unmarshal(payload, mail); err != nil {
+		return nil, err
+	}
+	if mail.Version != 0 {
+		return mail, nil
+	}
+
+	legacy := &legacyMail{}
+	if err := json.Unmarshal(payload, legacy); err != nil {
+		return nil, err
+	}
+
+	var variables models.MailVariables
+	switch {
+	case legacy.Type == models.MailTemplateVerificationCode:
+		variables = models.MailVariables{
+			"code":               models.StringVar(legacy.VerificationCode),
+			"expires_in_minutes": models.NumberVar(consts.VerificationCodesTTL.Minutes()),
+		}
+	case legacy.Type == models.MailTemplateNewDeviceSignIn && legacy.NewDevice != nil:
+		variables = models.MailVariables{
+			"device":       models.StringVar(legacy.NewDevice.Device),
+			"ip":           models.StringVar(legacy.NewDevice.IP),
+			"location":     models.StringVar(legacy.NewDevice.Location),
+			"signed_in_at": models.DateTimeVar(legacy.NewDevice.SignedInAt),
+			"revoke_url":   models.URLVar(legacy.NewDevice.RevokeURL),
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown legacy mail type %q", models.ErrInvalidMailCommand, legacy.Type)
+	}
+
+	converted := models.NewMailCommand(legacy.Type, consts.DefaultLocale, models.MailRecipient{Email: legacy.SendTo}, variables)
+	converted.ID = mail.ID
+	return converted, converted.Validate()
+}
+
+// outboxBackoff grows exponentially with the attempt number up to the limit
+func outboxBackoff(attempt int) time.Duration {
+	backoff := consts.OutboxMinBackoff
+	for i := 1; i < attempt && backoff < consts.OutboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > consts.OutboxMaxBackoff {
+		return consts.OutboxMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,121 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/memory"
+	"auth-api/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{attempt: 1, backoff: time.Second},
+		{attempt: 2, backoff: 2 * time.Second},
+		{attempt: 5, backoff: 16 * time.Second},
+		{attempt: 10, backoff: 512 * time.Second},
+		{attempt: 11, backoff: consts.OutboxMaxBackoff},
+		{attempt: consts.OutboxMaxAttempts, backoff: consts.OutboxMaxBackoff},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.backoff, service.OutboxBackoff(test.attempt), test.attempt)
+	}
+}
+
+func TestOutboxService_RelayPublishes(t *testing.T) {
+	m, queue := managertest.New(t)
+	ctx := context.Background()
+
+	require.NoError(t, m.Service().Outbox().Event(ctx, 0, models.EventUserCreated, map[string]string{"user_id": "user"}))
+	require.NoError(t, service.RelayBatch(ctx, m.Service().Outbox()))
+
+	records := queue.Records()
+	require.Len(t, records, 1)
+	require.Equal(t, "events", records[0].Exchange)
+	require.Equal(t, string(models.EventUserCreated), records[0].RoutingKey)
+	require.Empty(t, managertest.PendingEvents(t, m, models.EventUserCreated))
+}
+
+func TestOutboxService_RelayRetriesAndDeadLetters(t *testing.T) {
+	m, queue := managertest.New(t)
+	ctx := context.Background()
+	outbox := m.Repository().Outbox()
+
+	require.NoError(t, outbox.Create(ctx, &models.OutboxMessage{MessageID: "00000000-0000-0000-0000-000000000001", Topic: "unknown", Payload: []byte(`{}`)}))
+	claimed, err := outbox.Claim(ctx, consts.OutboxBatchSize, 0)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	message := claimed[0]
+
+	// the failed message is delayed by the backoff
+	require.NoError(t, service.RelayBatch(ctx, m.Service().Outbox()))
+	claimed, err = outbox.Claim(ctx, consts.OutboxBatchSize, 0)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	for attempt := 2; attempt < consts.OutboxMaxAttempts; attempt++ {
+		require.NoError(t, outbox.MarkFailed(ctx, message.ID, "failed", time.Now()))
+	}
+
+	// the last attempt dead-letters the message, it is not claimed even when due
+	require.NoError(t, service.RelayBatch(ctx, m.Service().Outbox()))
+	require.NoError(t, outbox.MarkFailed(ctx, message.ID, "failed", time.Now()))
+	claimed, err = outbox.Claim(ctx, consts.OutboxBatchSize, 0)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+	require.Empty(t, queue.Records())
+}
+
+type unavailableProducer struct {
+	*memory.Queue
+}
+
+func (unavailableProducer) Publish(context.Context, string, string, string, []byte) error {
+	return fmt.Errorf("publish: %w", models.ErrQueueUnavailable)
+}
+
+func TestOutboxService_RelayKeepsMessagesWhileQueueIsUnavailable(t *testing.T) {
+	queue := memory.NewQueue()
+	m := managertest.WithProducer(t, unavailableProducer{queue}, queue)
+	ctx := context.Background()
+
+	require.NoError(t, m.Service().Outbox().Event(ctx, 0, models.EventUserCreated, map[string]string{"user_id": "user"}))
+	for i := 0; i < consts.OutboxMaxAttempts; i++ {
+		require.NoError(t, service.RelayBatch(ctx, m.Service().Outbox()))
+	}
+
+	// attempts are not counted, so the message is neither delayed nor dead-lettered
+	claimed, err := m.Repository().Outbox().Claim(ctx, consts.OutboxBatchSize, 0)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Zero(t, claimed[0].Attempts)
+	require.Empty(t, queue.Records())
+}
+
+func TestOutboxService_RelayConvertsLegacyMails(t *testing.T) {
+	m, queue := managertest.New(t)
+	ctx := context.Background()
+
+	payload := []byte(`{"id": "legacy", "type": "verification_code", "send_to": "user@example.com", "verification_code": "123456"}`)
+	require.NoError(t, m.Repository().Outbox().Create(ctx, &models.OutboxMessage{MessageID: "legacy", Topic: models.OutboxTopicMails, Payload: payload}))
+	require.NoError(t, service.RelayBatch(ctx, m.Service().Outbox()))
+
+	records := queue.Records()
+	require.Len(t, records, 1)
+	mail := &models.MailCommand{}
+	require.NoError(t, json.Unmarshal(records[0].Body, mail))
+	require.NoError(t, mail.Validate())
+	require.Equal(t, "legacy", mail.ID)
+	require.Equal(t, models.MailTemplateVerificationCode, mail.TemplateID)
+	require.Equal(t, "user@example.com", mail.Recipient.Email)
+	require.Equal(t, "123456", mail.Variables["code"].Value)
+}
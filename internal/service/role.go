@@ -0,0 +1,198 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"context"
+	"go.uber.org/zap"
+	"sort"
+)
+
+type RoleService struct {
+	log     *zap.Logger
+	manager interfaces.IManager
+}
+
+func InitRoleService(manager interfaces.IManager, log *zap.Logger) *RoleService {
+	return &RoleService{
+		log:     log,
+		manager: manager,
+	}
+}
+
+// Roles returns roles of the request tenant
+func (s *RoleService) Roles(ctx context.Context) ([]models.Role, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.manager.Repository().Roles().FindAll(ctx, tenant.ID)
+}
+
+// SaveRole creates role of the request tenant or replaces description and permissions of the existing one
+func (s *RoleService) SaveRole(ctx context.Context, request *models.SaveRoleReq) (*models.Role, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := unique(request.Permissions)
+	role := &models.Role{
+		TenantID:    tenant.ID,
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: permissions,
+	}
+	err = s.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := s.manager.Repository().Roles().Save(ctx, role); err != nil {
+			return err
+		}
+		granted, err := s.manager.Repository().Roles().SetPermissions(ctx, role.ID, permissions)
+		if err != nil {
+			return err
+		}
+		if granted != int64(len(permissions)) {
+			return models.ErrUnknownPermission
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.findRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	return s.manager.Repository().Roles().Delete(ctx, role.ID)
+}
+
+func (s *RoleService) Permissions(ctx context.Context) ([]models.Permission, error) {
+	return s.manager.Repository().Permissions().FindAll(ctx)
+}
+
+// SavePermission registers permission shared by all tenants
+func (s *RoleService) SavePermission(ctx context.Context, permission *models.Permission) error {
+	return s.manager.Repository().Permissions().Save(ctx, permission)
+}
+
+// UserRoles returns roles and permissions of the user of the request tenant
+func (s *RoleService) UserRoles(ctx context.Context, userIDCode string) (*models.UserRolesRes, error) {
+	user, err := s.findUser(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, permissions, err := s.Claims(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserRolesRes{
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// AssignRole grants role to the user, tokens get it on the next refresh
+func (s *RoleService) AssignRole(ctx context.Context, userIDCode, roleName string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventRoleAssign, Reason: roleName}
+	defer s.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, role, err := s.findUserRole(ctx, userIDCode, roleName, event)
+	if err != nil {
+		return err
+	}
+	return s.manager.Repository().Roles().Assign(ctx, user.ID, role.ID)
+}
+
+// UnassignRole revokes role from the user, tokens lose it on the next refresh
+func (s *RoleService) UnassignRole(ctx context.Context, userIDCode, roleName string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventRoleUnassign, Reason: roleName}
+	defer s.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, role, err := s.findUserRole(ctx, userIDCode, roleName, event)
+	if err != nil {
+		return err
+	}
+	return s.manager.Repository().Roles().Unassign(ctx, user.ID, role.ID)
+}
+
+// Claims returns sorted names of the user roles and permissions granted by them
+func (s *RoleService) Claims(ctx context.Context, userID int64) (roles, permissions []string, err error) {
+	assigned, err := s.manager.Repository().Roles().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, role := range assigned {
+		roles = append(roles, role.Name)
+		permissions = append(permissions, role.Permissions...)
+	}
+	return roles, unique(permissions), nil
+}
+
+func (s *RoleService) findUserRole(
+	ctx context.Context, userIDCode, roleName string, event *models.AuthEvent) (*models.User, *models.Role, error) {
+	user, err := s.findUser(ctx, userIDCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	event.UserIDRef = &user.ID
+	event.Actor = user.Email
+
+	role, err := s.findRole(ctx, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, role, nil
+}
+
+func (s *RoleService) findUser(ctx context.Context, userIDCode string) (*models.User, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.manager.Repository().Users().FindByUserIDCode(ctx, tenant.ID, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *RoleService) findRole(ctx context.Context, name string) (*models.Role, error) {
+	tenant, err := s.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.manager.Repository().Roles().FindByName(ctx, tenant.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, models.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// unique returns sorted values without duplicates
+func unique(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
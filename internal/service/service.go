@@ -3,10 +3,12 @@ package service
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"go.uber.org/zap"
 	"sync"
 )
 
 type Service struct {
+	log     *zap.Logger
 	config  *models.Config
 	manager interfaces.IManager
 
@@ -18,10 +20,26 @@ type Service struct {
 
 	user       interfaces.IUserService
 	userRunner sync.Once
+
+	audit       interfaces.IAuditService
+	auditRunner sync.Once
+
+	outbox       interfaces.IOutboxService
+	outboxRunner sync.Once
+
+	mail       interfaces.IMailService
+	mailRunner sync.Once
+
+	tenant       interfaces.ITenantService
+	tenantRunner sync.Once
+
+	role       interfaces.IRoleService
+	roleRunner sync.Once
 }
 
-func InitService(manager interfaces.IManager, config *models.Config) *Service {
+func InitService(manager interfaces.IManager, config *models.Config, log *zap.Logger) *Service {
 	return &Service{
+		log:     log,
 		config:  config,
 		manager: manager,
 	}
@@ -29,14 +47,14 @@ func InitService(manager interfaces.IManager, config *models.Config) *Service {
 
 func (s *Service) Auth() interfaces.IAuthService {
 	s.authRunner.Do(func() {
-		s.auth = InitAuthService(s.manager, s.config)
+		s.auth = InitAuthService(s.manager, s.config, s.log.Named("[AUTH]"))
 	})
 	return s.auth
 }
 
 func (s *Service) User() interfaces.IUserService {
 	s.userRunner.Do(func() {
-		s.user = InitUserService(s.manager)
+		s.user = InitUserService(s.manager, s.config, s.log.Named("[USER]"))
 	})
 	return s.user
 }
@@ -47,3 +65,38 @@ func (s *Service) OAuth() interfaces.IOAuthService {
 	})
 	return s.oAuth
 }
+
+func (s *Service) Audit() interfaces.IAuditService {
+	s.auditRunner.Do(func() {
+		s.audit = InitAuditService(s.manager, s.log.Named("[AUDIT]"))
+	})
+	return s.audit
+}
+
+func (s *Service) Outbox() interfaces.IOutboxService {
+	s.outboxRunner.Do(func() {
+		s.outbox = InitOutboxService(s.manager, s.log.Named("[OUTBOX]"))
+	})
+	return s.outbox
+}
+
+func (s *Service) Mail() interfaces.IMailService {
+	s.mailRunner.Do(func() {
+		s.mail = InitMailService(s.manager, s.log.Named("[MAIL]"))
+	})
+	return s.mail
+}
+
+func (s *Service) Tenant() interfaces.ITenantService {
+	s.tenantRunner.Do(func() {
+		s.tenant = InitTenantService(s.manager, s.config, s.log.Named("[TENANT]"))
+	})
+	return s.tenant
+}
+
+func (s *Service) Role() interfaces.IRoleService {
+	s.roleRunner.Do(func() {
+		s.role = InitRoleService(s.manager, s.log.Named("[ROLE]"))
+	})
+	return s.role
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"fmt"
+	"github.com/doxanocap/pkg/ctxholder"
+	"go.uber.org/zap"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TenantService struct {
+	log     *zap.Logger
+	config  *models.Config
+	manager interfaces.IManager
+
+	mu       sync.RWMutex
+	byID     map[int64]*models.Tenant
+	byCode   map[string]*models.Tenant
+	byHost   map[string]*models.Tenant
+	loadedAt time.Time
+}
+
+func InitTenantService(manager interfaces.IManager, config *models.Config, log *zap.Logger) *TenantService {
+	return &TenantService{
+		log:     log,
+		config:  config,
+		manager: manager,
+	}
+}
+
+// Resolve finds tenant of the request by explicit code, then by host,
+// requests that match neither are served by the default tenant
+func (s *TenantService) Resolve(ctx context.Context, code, host string) (*models.Tenant, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if code != "" {
+		tenant, ok := s.byCode[code]
+		if !ok {
+			return nil, models.ErrTenantNotFound
+		}
+		return tenant, nil
+	}
+	if tenant, ok := s.byHost[normalizeHost(host)]; ok {
+		return tenant, nil
+	}
+	return s.defaultTenant()
+}
+
+// Current returns tenant resolved for the request, default one when there is no request
+func (s *TenantService) Current(ctx context.Context) (*models.Tenant, error) {
+	return s.GetByID(ctx, ctxholder.GetInt64ByKey(ctx, consts.CtxKeyTenantID))
+}
+
+// GetByID returns tenant by id, zero id stands for the default tenant
+func (s *TenantService) GetByID(ctx context.Context, tenantID int64) (*models.Tenant, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if tenantID == 0 {
+		return s.defaultTenant()
+	}
+	tenant, ok := s.byID[tenantID]
+	if !ok {
+		return nil, models.ErrTenantNotFound
+	}
+	return tenant, nil
+}
+
+func (s *TenantService) defaultTenant() (*models.Tenant, error) {
+	tenant, ok := s.byCode[consts.DefaultTenantCode]
+	if !ok {
+		return nil, models.ErrTenantNotFound
+	}
+	return tenant, nil
+}
+
+// load reads tenants into memory and refreshes them once in a while,
+// on failure the previously loaded tenants keep being served
+func (s *TenantService) load(ctx context.Context) error {
+	s.mu.RLock()
+	fresh := time.Since(s.loadedAt) < consts.TenantsRefreshInterval
+	loaded := s.byID != nil
+	s.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < consts.TenantsRefreshInterval {
+		return nil
+	}
+
+	tenants, err := s.manager.Repository().Tenants().FindAll(ctx)
+	if err != nil {
+		if !loaded {
+			return err
+		}
+		s.log.Error(fmt.Sprintf("refresh tenants: %s", err))
+		s.loadedAt = time.Now()
+		return nil
+	}
+
+	byID := make(map[int64]*models.Tenant, len(tenants))
+	byCode := make(map[string]*models.Tenant, len(tenants))
+	byHost := make(map[string]*models.Tenant)
+	for i := range tenants {
+		tenant := tenants[i].WithDefaults(s.config)
+		byID[tenant.ID] = tenant
+		byCode[tenant.Code] = tenant
+		for _, host := range tenant.Hosts {
+			byHost[normalizeHost(host)] = tenant
+		}
+	}
+	s.byID, s.byCode, s.byHost = byID, byCode, byHost
+	s.loadedAt = time.Now()
+	return nil
+}
+
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/service"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTenantService_Resolve(t *testing.T) {
+	m, _ := managertest.New(t)
+	shop := &models.Tenant{Code: "shop", Name: "Shop", Hosts: []string{"auth.shop.example.com"}}
+	blog := &models.Tenant{Code: "blog", Name: "Blog", Hosts: []string{"auth.blog.example.com"}}
+	managertest.AddTenant(t, m, shop)
+	managertest.AddTenant(t, m, blog)
+	ctx := context.Background()
+
+	defaultTenant, err := m.Service().Tenant().GetByID(ctx, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		code   string
+		host   string
+		tenant *models.Tenant
+	}{
+		{name: "code wins over host", code: "shop", host: "auth.blog.example.com", tenant: shop},
+		{name: "host", host: "Auth.Blog.Example.com:443", tenant: blog},
+		{name: "unknown host", host: "example.com", tenant: defaultTenant},
+		{name: "nothing", tenant: defaultTenant},
+	}
+	for _, test := range tests {
+		tenant, err := m.Service().Tenant().Resolve(ctx, test.code, test.host)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.tenant.ID, tenant.ID, test.name)
+	}
+
+	_, err = m.Service().Tenant().Resolve(ctx, "unknown", "auth.shop.example.com")
+	require.ErrorIs(t, err, models.ErrTenantNotFound)
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"auth.example.com":      "auth.example.com",
+		"Auth.Example.COM":      "auth.example.com",
+		"auth.example.com:8080": "auth.example.com",
+		"[::1]:8080":            "::1",
+		"":                      "",
+	}
+	for host, normalized := range tests {
+		require.Equal(t, normalized, service.NormalizeHost(host), host)
+	}
+}
+
+func TestTenants_RejectForeignTokens(t *testing.T) {
+	m, _ := managertest.New(t)
+	shop := &models.Tenant{Code: "shop", Name: "Shop", AllowSignUp: true}
+	managertest.AddTenant(t, m, shop)
+
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+	shopCtx := managertest.TenantContext(shop.ID, "10.0.0.1", "laptop")
+
+	_, err := m.Service().Auth().ValidateAccessToken(shopCtx, response.Tokens.AccessToken)
+	require.ErrorIs(t, err, models.ErrInvalidToken)
+	_, err = m.Service().Auth().ValidateRefreshToken(shopCtx, response.Tokens.RefreshToken)
+	require.ErrorIs(t, err, models.ErrInvalidToken)
+	require.ErrorIs(t, m.Service().User().Logout(shopCtx, response.Tokens.RefreshToken), models.ErrInvalidToken)
+	_, err = m.Service().User().GetByUserIDCode(shopCtx, response.IDCode)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	// the session still works in its own tenant
+	_, err = m.Service().Auth().ValidateAccessToken(ctx, response.Tokens.AccessToken)
+	require.NoError(t, err)
+	_, err = m.Service().Auth().ValidateRefreshToken(ctx, response.Tokens.RefreshToken)
+	require.NoError(t, err)
+	require.NoError(t, m.Service().User().Logout(ctx, response.Tokens.RefreshToken))
+}
@@ -6,26 +6,57 @@ import (
 	"auth-api/internal/models/consts"
 	"auth-api/internal/pkg/tools"
 	"context"
+	"github.com/doxanocap/pkg/ctxholder"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
+	log     *zap.Logger
+	config  *models.Config
 	manager interfaces.IManager
 }
 
-func InitUserService(manager interfaces.IManager) *UserService {
+func InitUserService(manager interfaces.IManager, config *models.Config, log *zap.Logger) *UserService {
 	return &UserService{
+		log:     log,
+		config:  config,
 		manager: manager,
 	}
 }
 
-func (us *UserService) Create(ctx context.Context, userDTO *models.UserDTO) (result *models.AuthResponse, err error) {
-	found, err := us.manager.Repository().Users().FindByEmail(ctx, userDTO.Email)
+func (us *UserService) Create(ctx context.Context, userDTO *models.UserDTO) (*models.AuthResponse, error) {
+	return us.create(ctx, userDTO, nil)
+}
+
+// CreateWithIdentity signs up the user of the external provider, the identity is linked
+// in the same transaction
+func (us *UserService) CreateWithIdentity(ctx context.Context, userDTO *models.UserDTO, identity *models.UserIdentity) (*models.AuthResponse, error) {
+	return us.create(ctx, userDTO, identity)
+}
+
+func (us *UserService) create(ctx context.Context, userDTO *models.UserDTO, identity *models.UserIdentity) (result *models.AuthResponse, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventSignUp, Actor: userDTO.Email}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
 	if err != nil {
 		return
 	}
+	if !tenant.AllowSignUp {
+		return nil, models.ErrSignUpDisabled
+	}
+
+	// providers may hide the email, users are never created without one
+	if userDTO.Email == "" {
+		return nil, models.ErrEmailRequired
+	}
+	found, err := us.manager.Repository().Users().FindByEmail(ctx, tenant.ID, userDTO.Email)
+	if err != nil {
+		return nil, err
+	}
 	if found != nil {
 		return nil, models.ErrUserAlreadyExist
 	}
@@ -42,14 +73,42 @@ func (us *UserService) Create(ctx context.Context, userDTO *models.UserDTO) (res
 	userDTO.CreatedAt = tools.CurrTimePtr()
 
 	user := userDTO.ToUser()
-	if err = us.manager.Repository().Users().Create(ctx, user); err != nil {
-		return
-	}
+	user.TenantID = tenant.ID
+	var tokens *models.Tokens
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) (err error) {
+		if err = us.manager.Repository().Users().Create(ctx, user); err != nil {
+			return err
+		}
+		if identity != nil {
+			identity.UserIDRef = user.ID
+			identity.TenantID = user.TenantID
+			if err = us.manager.Repository().UserIdentities().Create(ctx, identity); err != nil {
+				return err
+			}
+		}
 
-	tokens, err := us.manager.Service().Auth().NewSession(ctx, user)
+		userPayload := &models.UserEventPayload{
+			UserID:        user.IDCode,
+			Email:         user.Email,
+			OAuthProvider: user.OAuthProvider,
+		}
+		if err = us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserCreated, userPayload); err != nil {
+			return err
+		}
+		if user.Activated {
+			if err = us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserVerified, userPayload); err != nil {
+				return err
+			}
+		}
+
+		tokens, err = us.manager.Service().Auth().NewSession(ctx, user)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	event.UserIDRef = &user.ID
+	event.SessionIDRef = &tokens.SessionID
 
 	return &models.AuthResponse{
 		UserDTO: *userDTO,
@@ -57,16 +116,29 @@ func (us *UserService) Create(ctx context.Context, userDTO *models.UserDTO) (res
 	}, nil
 }
 
-func (us *UserService) Authenticate(ctx context.Context, userDTO *models.UserDTO) (*models.AuthResponse, error) {
-	user, err := us.manager.Repository().Users().FindByEmail(ctx, userDTO.Email)
+func (us *UserService) Authenticate(ctx context.Context, userDTO *models.UserDTO) (result *models.AuthResponse, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventSignIn, Actor: userDTO.Email}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := us.manager.Repository().Users().FindByEmail(ctx, tenant.ID, userDTO.Email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
 		return nil, models.ErrUserNotFound
 	}
+	event.UserIDRef = &user.ID
 
-	if user.OAuthProvider == models.GoogleOAuth {
+	if user.DisabledAt != nil {
+		return nil, models.ErrUserDisabled
+	}
+	// users signed up by the provider sign in with it until they set a password
+	if user.Password == "" {
 		return nil, models.ErrUserMustAuthWGoogle
 	}
 
@@ -79,6 +151,7 @@ func (us *UserService) Authenticate(ctx context.Context, userDTO *models.UserDTO
 	if err != nil {
 		return nil, err
 	}
+	event.SessionIDRef = &tokens.SessionID
 
 	return &models.AuthResponse{
 		UserDTO: user.ToUserDTO(),
@@ -86,59 +159,114 @@ func (us *UserService) Authenticate(ctx context.Context, userDTO *models.UserDTO
 	}, nil
 }
 
-func (us *UserService) Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error) {
+func (us *UserService) Refresh(ctx context.Context, refreshToken string) (result *models.Tokens, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventRefresh}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
 	userSession, err := us.manager.Service().Auth().ValidateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	event.UserIDRef = &userSession.UserID
+	event.Actor = userSession.UserEmail
 
-	tokens, err := us.manager.Service().Auth().UpdateSession(ctx, userSession.ToUser())
+	tokens, err := us.manager.Service().Auth().RefreshSession(ctx, userSession.ToUser())
 	if err != nil {
 		return nil, err
 	}
+	event.SessionIDRef = &tokens.SessionID
 	return tokens, nil
 }
 
-func (us *UserService) Logout(ctx context.Context, refreshToken string) error {
-	session, err := us.manager.Repository().Sessions().FindByToken(ctx, refreshToken)
+func (us *UserService) Logout(ctx context.Context, refreshToken string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventLogout}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return err
+	}
+
+	session, err := us.manager.Repository().Sessions().FindByToken(ctx, tenant.ID, refreshToken)
 	if err != nil {
 		return err
 	}
 	if session == nil {
 		return models.ErrInvalidToken
 	}
-	if err = us.manager.Repository().Sessions().EndSession(ctx, session.ID); err != nil {
+	event.UserIDRef = &session.UserIDRef
+	event.SessionIDRef = &session.ID
+
+	user, err := us.manager.Repository().Users().FindByID(ctx, session.UserIDRef)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	return us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := us.manager.Repository().Sessions().EndSession(ctx, session.ID); err != nil {
+			return err
+		}
+		// the session of the erased user is still ended, there is no one to publish the event about
+		if user == nil {
+			return nil
+		}
+		return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventSessionEnded, &models.SessionEventPayload{
+			UserID:    user.IDCode,
+			SessionID: session.ID,
+		})
+	})
 }
 
 func (us *UserService) SendVerifyCode(ctx context.Context, email string) error {
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return err
+	}
 	code := tools.NewVerificationCode()
 
+	// the code is also sent before sign up, then the recipient has no name yet
+	recipient := models.MailRecipient{Email: email}
+	user, err := us.manager.Repository().Users().FindByEmail(ctx, tenant.ID, email)
+	if err != nil {
+		return err
+	}
+	var userID int64
+	if user != nil {
+		recipient = user.MailRecipient(email)
+		userID = user.ID
+	}
+
 	if err := us.manager.
 		Repository().
 		VerificationCodes().
-		Set(ctx, email, code); err != nil {
+		Set(ctx, tenant.ID, email, code); err != nil {
 		return err
 	}
 
+	// code is stored first, so the mail is never sent with the code that does not exist
 	if err := us.manager.
-		Processor().
-		Queue().
-		Producers().
-		Mails().
-		Send(ctx, &models.MailsProducerMsg{
-			SendTo:           email,
-			VerificationCode: code,
-		}); err != nil {
+		Service().
+		Outbox().
+		Mail(ctx, userID, models.NewMailCommand(
+			models.MailTemplateVerificationCode,
+			mailLocale(ctx),
+			recipient,
+			models.MailVariables{
+				"code":               models.StringVar(code),
+				"expires_in_minutes": models.NumberVar(consts.VerificationCodesTTL.Minutes()),
+			})); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (us *UserService) GetByUserIDCode(ctx context.Context, userIDCode string) (*models.UserDTO, error) {
-	user, err := us.manager.Repository().Users().FindByUserIDCode(ctx, userIDCode)
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := us.manager.Repository().Users().FindByUserIDCode(ctx, tenant.ID, userIDCode)
 	if err != nil {
 		return nil, err
 	}
@@ -148,3 +276,223 @@ func (us *UserService) GetByUserIDCode(ctx context.Context, userIDCode string) (
 	userDTO := user.ToUserDTO()
 	return &userDTO, nil
 }
+
+// Deactivate disables the user and ends all sessions of the user
+func (us *UserService) Deactivate(ctx context.Context, userIDCode, reason string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventDeactivate, Reason: reason}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := us.manager.Repository().Users().SetDisabledAt(ctx, user.ID, tools.CurrTimePtr()); err != nil {
+			return err
+		}
+		if err := us.endSessions(ctx, user); err != nil {
+			return err
+		}
+		return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserDisabled, &models.UserEventPayload{
+			UserID:        user.IDCode,
+			Email:         user.Email,
+			OAuthProvider: user.OAuthProvider,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return us.manager.Repository().SessionsCache().Delete(ctx, user.TenantID, user.IDCode)
+}
+
+// ForceLogout ends all sessions of the user, issued access tokens stop passing validation
+func (us *UserService) ForceLogout(ctx context.Context, userIDCode, reason string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventForceLogout, Reason: reason}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return err
+	}
+
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		return us.endSessions(ctx, user)
+	})
+	if err != nil {
+		return err
+	}
+	return us.manager.Repository().SessionsCache().Delete(ctx, user.TenantID, user.IDCode)
+}
+
+// Enable lifts disabling of the user, sessions ended on disabling stay ended
+func (us *UserService) Enable(ctx context.Context, userIDCode, reason string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventEnable, Reason: reason}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt == nil {
+		return nil
+	}
+
+	return us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := us.manager.Repository().Users().SetDisabledAt(ctx, user.ID, nil); err != nil {
+			return err
+		}
+		return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserEnabled, &models.UserEventPayload{
+			UserID:        user.IDCode,
+			Email:         user.Email,
+			OAuthProvider: user.OAuthProvider,
+		})
+	})
+}
+
+// ResendVerification sends new verification code to the user who has not verified email yet
+func (us *UserService) ResendVerification(ctx context.Context, userIDCode string) error {
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return models.ErrUserNotFound
+	}
+	if user.DisabledAt != nil {
+		return models.ErrUserDisabled
+	}
+	if user.Activated {
+		return models.ErrUserAlreadyVerified
+	}
+	return us.SendVerifyCode(ctx, user.Email)
+}
+
+// Search returns users of the request tenant matching the filter
+func (us *UserService) Search(ctx context.Context, filter *models.UsersFilter) (*models.UsersPage, error) {
+	tenant, err := us.manager.Service().Tenant().Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter.TenantID = tenant.ID
+
+	users, err := us.manager.Repository().Users().Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := us.manager.Repository().Users().Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.AdminUser, 0, len(users))
+	for i := range users {
+		items = append(items, users[i].ToAdminUser())
+	}
+	return &models.UsersPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// GetDetail returns user of the request tenant with the roles and the latest sessions of the user
+func (us *UserService) GetDetail(ctx context.Context, userIDCode string) (*models.UserDetailRes, error) {
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, permissions, err := us.manager.Service().Role().Claims(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := us.manager.Repository().Sessions().FindByUserID(ctx, user.ID, consts.MaxPageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.UserDetailRes{
+		User:        user.ToAdminUser(),
+		Roles:       roles,
+		Permissions: permissions,
+		Sessions:    make([]models.AdminSession, 0, len(sessions)),
+	}
+	for i := range sessions {
+		detail.Sessions = append(detail.Sessions, sessions[i].ToAdminSession())
+	}
+	return detail, nil
+}
+
+// MarkEmailBounced stops notification emails to the address in every tenant,
+// the mailbox bounces regardless of the product it was registered in
+func (us *UserService) MarkEmailBounced(ctx context.Context, email string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventEmailBounced, Actor: email}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	tenants, err := us.manager.Repository().Tenants().FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	bouncedAt := tools.CurrTimePtr()
+	return us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		for i := range tenants {
+			if err := us.manager.Repository().Users().SetEmailBouncedAt(ctx, tenants[i].ID, email, bouncedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (us *UserService) findForCommand(ctx context.Context, userIDCode string, event *models.AuthEvent) (*models.User, error) {
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+	event.UserIDRef = &user.ID
+	event.Actor = user.Email
+	return user, nil
+}
+
+// findManaged finds user managed by command or admin. Admins see users of their tenant only,
+// commands from the queue come without tenant and may reach any user
+func (us *UserService) findManaged(ctx context.Context, userIDCode string) (user *models.User, err error) {
+	users := us.manager.Repository().Users()
+	if tenantID := ctxholder.GetInt64ByKey(ctx, consts.CtxKeyTenantID); tenantID != 0 {
+		user, err = users.FindByUserIDCode(ctx, tenantID, userIDCode)
+	} else {
+		user, err = users.FindByUserIDCodeInAnyTenant(ctx, userIDCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (us *UserService) endSessions(ctx context.Context, user *models.User) error {
+	sessionIDs, err := us.manager.Repository().Sessions().EndByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessionIDs {
+		err = us.manager.Service().Outbox().Event(ctx, user.ID, models.EventSessionEnded, &models.SessionEventPayload{
+			UserID:    user.IDCode,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUserService_SearchIsTenantScoped(t *testing.T) {
+	m, _ := managertest.New(t)
+	shop := &models.Tenant{Code: "shop", Name: "Shop", AllowSignUp: true}
+	managertest.AddTenant(t, m, shop)
+
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	shopCtx := managertest.TenantContext(shop.ID, "10.0.0.1", "laptop")
+	managertest.SignUp(t, m, ctx, "first@example.com")
+	second := managertest.SignUp(t, m, ctx, "second@example.com")
+	shopUser := managertest.SignUp(t, m, shopCtx, "first@shop.example.com")
+
+	require.NoError(t, m.Service().User().Deactivate(ctx, second.IDCode, "fraud"))
+
+	testCases := []struct {
+		name     string
+		filter   models.UsersFilter
+		expected []string
+	}{
+		{name: "all", filter: models.UsersFilter{}, expected: []string{"second@example.com", "first@example.com"}},
+		{name: "email", filter: models.UsersFilter{Email: "first"}, expected: []string{"first@example.com"}},
+		{name: "status", filter: models.UsersFilter{Status: models.UserStatusDisabled}, expected: []string{"second@example.com"}},
+		{name: "other tenant", filter: models.UsersFilter{Email: "shop"}, expected: []string{}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			page, err := m.Service().User().Search(ctx, &testCase.filter)
+			require.NoError(t, err)
+
+			emails := []string{}
+			for _, item := range page.Items {
+				emails = append(emails, item.Email)
+			}
+			require.ElementsMatch(t, testCase.expected, emails)
+			require.EqualValues(t, len(testCase.expected), page.Total)
+		})
+	}
+
+	// admin requests always come with the tenant
+	defaultTenant, err := m.Service().Tenant().GetByID(ctx, 0)
+	require.NoError(t, err)
+	adminCtx := managertest.TenantContext(defaultTenant.ID, "10.0.0.1", "laptop")
+	_, err = m.Service().User().GetDetail(adminCtx, shopUser.IDCode)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+	require.ErrorIs(t, m.Service().User().ForceLogout(adminCtx, shopUser.IDCode, ""), models.ErrUserNotFound)
+}
+
+func TestUserService_DeactivateAndEnable(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	require.NoError(t, m.Service().User().Deactivate(ctx, response.IDCode, "fraud"))
+	require.Len(t, managertest.PendingEvents(t, m, models.EventUserDisabled), 1)
+	require.Len(t, managertest.PendingEvents(t, m, models.EventSessionEnded), 1)
+
+	detail, err := m.Service().User().GetDetail(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.Equal(t, models.UserStatusDisabled, detail.User.Status)
+	require.Len(t, detail.Sessions, 1)
+	require.NotNil(t, detail.Sessions[0].EndedAt)
+
+	credentials := &models.UserDTO{Email: "user@example.com", Password: "Password123!"}
+	_, err = m.Service().User().Authenticate(ctx, credentials)
+	require.ErrorIs(t, err, models.ErrUserDisabled)
+
+	require.NoError(t, m.Service().User().Enable(ctx, response.IDCode, ""))
+	require.Len(t, managertest.PendingEvents(t, m, models.EventUserEnabled), 1)
+	_, err = m.Service().User().Authenticate(ctx, credentials)
+	require.NoError(t, err)
+}
+
+func TestUserService_ResendVerification(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+	sent := len(managertest.PendingMails(t, m, models.MailTemplateVerificationCode))
+
+	require.NoError(t, m.Service().User().ResendVerification(ctx, response.IDCode))
+	require.Len(t, managertest.PendingMails(t, m, models.MailTemplateVerificationCode), sent+1)
+
+	require.NoError(t, m.Service().User().Deactivate(ctx, response.IDCode, ""))
+	require.ErrorIs(t, m.Service().User().ResendVerification(ctx, response.IDCode), models.ErrUserDisabled)
+}
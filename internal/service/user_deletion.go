@@ -0,0 +1,127 @@
+package service
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"time"
+)
+
+// ScheduleDeletion marks the user for erasure after the grace period. The account keeps
+// working until then, so the user or admin could cancel the deletion
+func (us *UserService) ScheduleDeletion(ctx context.Context, userIDCode, reason string) (result *models.DeletionRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventDelete, Reason: reason}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+	if user.EraseAt != nil {
+		return &models.DeletionRes{EraseAt: *user.EraseAt}, nil
+	}
+
+	eraseAt := time.Now().Add(us.config.Privacy.DeletionGracePeriod())
+	if err = us.manager.Repository().Users().SetEraseAt(ctx, user.ID, &eraseAt); err != nil {
+		return nil, err
+	}
+	return &models.DeletionRes{EraseAt: eraseAt}, nil
+}
+
+// CancelDeletion cancels scheduled deletion of the user, erased users can not be restored
+func (us *UserService) CancelDeletion(ctx context.Context, userIDCode, reason string) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventCancelDelete, Reason: reason}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return models.ErrUserNotFound
+	}
+	if user.EraseAt == nil {
+		return nil
+	}
+	return us.manager.Repository().Users().SetEraseAt(ctx, user.ID, nil)
+}
+
+// RunErasure erases users whose grace period has passed until ctx is done
+func (us *UserService) RunErasure(ctx context.Context) {
+	log := us.log.Named("RunErasure")
+	ticker := time.NewTicker(consts.ErasureInterval)
+	defer ticker.Stop()
+
+	log.Info("started")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopped")
+			return
+		case <-ticker.C:
+			for i := 0; i < consts.ErasureBatchSize && ctx.Err() == nil; i++ {
+				erased, err := us.eraseNext(ctx)
+				if err != nil {
+					log.Error(err.Error())
+					break
+				}
+				if !erased {
+					break
+				}
+			}
+		}
+	}
+}
+
+// eraseNext removes personal data of the next due user: email, phone, password, profile,
+// linked identities, data exports, outbox messages of the user, ip addresses and user agents
+// of the sessions, devices and audit events. Sessions are ended and other services are told
+// to erase the user by the user.deleted event
+func (us *UserService) eraseNext(ctx context.Context) (erased bool, err error) {
+	var user *models.User
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) (err error) {
+		user, err = us.manager.Repository().Users().FindDueForErasure(ctx, time.Now())
+		if err != nil || user == nil {
+			return err
+		}
+
+		// pending mails and events must not deliver the data after erasure, the messages
+		// about the ended sessions and the deletion are written after the purge
+		if err := us.manager.Repository().Outbox().DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := us.endSessions(ctx, user); err != nil {
+			return err
+		}
+		if err := us.manager.Repository().UserDevices().DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := us.manager.Repository().UserIdentities().DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := us.manager.Repository().DataExports().DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := us.manager.Repository().AuthEvents().EraseByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := us.manager.Repository().Users().Erase(ctx, user.ID); err != nil {
+			return err
+		}
+		return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserDeleted, &models.UserDeletedPayload{
+			UserID: user.IDCode,
+		})
+	})
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	us.manager.Service().Audit().Record(ctx, &models.AuthEvent{
+		Type:      models.AuthEventErase,
+		UserIDRef: &user.ID,
+	}, &err)
+	return true, us.manager.Repository().SessionsCache().Delete(ctx, user.TenantID, user.IDCode)
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/service"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUserService_ScheduleAndCancelDeletion(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	deletion, err := m.Service().User().ScheduleDeletion(ctx, response.IDCode, "")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(720*time.Hour), deletion.EraseAt, time.Minute)
+
+	// the repeated request keeps the original date
+	repeated, err := m.Service().User().ScheduleDeletion(ctx, response.IDCode, "")
+	require.NoError(t, err)
+	require.Equal(t, deletion.EraseAt, repeated.EraseAt)
+
+	// the account keeps working during the grace period
+	_, err = m.Service().User().Authenticate(ctx, &models.UserDTO{Email: "user@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Service().User().CancelDeletion(ctx, response.IDCode, ""))
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	require.Nil(t, user.EraseAt)
+
+	// nothing is due, so nothing is erased
+	erased, err := service.EraseNext(ctx, m.Service().User())
+	require.NoError(t, err)
+	require.False(t, erased)
+}
+
+func TestUserService_EraseNext(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	// contacts of the other user contain the ones of the erased user
+	response := managertest.SignUp(t, m, ctx, "a@b.co")
+	other := managertest.SignUp(t, m, ctx, "ba@b.com")
+	require.NoError(t, m.Service().User().SendVerifyCode(ctx, "a@b.co"))
+	require.NoError(t, m.Service().User().SendVerifyCode(ctx, "ba@b.com"))
+	require.Len(t, managertest.PendingMails(t, m, models.MailTemplateVerificationCode), 2)
+
+	user, err := m.Repository().Users().FindByUserIDCodeInAnyTenant(ctx, response.IDCode)
+	require.NoError(t, err)
+	user.PhoneNumber = "+7700123456"
+	require.NoError(t, m.Repository().Users().Update(ctx, user))
+	phone := "+77001234567"
+	_, err = m.Service().User().UpdateProfile(ctx, other.IDCode, &models.UpdateProfileReq{PhoneNumber: &phone})
+	require.NoError(t, err)
+
+	_, err = m.Service().User().ScheduleDeletion(ctx, response.IDCode, "")
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, m.Repository().Users().SetEraseAt(ctx, user.ID, &past))
+
+	// the erasure job runs outside of requests
+	erased, err := service.EraseNext(context.Background(), m.Service().User())
+	require.NoError(t, err)
+	require.True(t, erased)
+
+	user, err = m.Repository().Users().FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, user.Email)
+	require.Empty(t, user.Password)
+	require.NotNil(t, user.ErasedAt)
+
+	// pending messages about the user are gone, messages about other users are kept
+	mails := managertest.PendingMails(t, m, models.MailTemplateVerificationCode)
+	require.Len(t, mails, 1)
+	require.Equal(t, "ba@b.com", mails[0].Recipient.Email)
+	sms := managertest.PendingSms(t, m, models.SmsTemplatePhoneChangeCode)
+	require.Len(t, sms, 1)
+	require.Equal(t, phone, sms[0].Recipient.PhoneNumber)
+	created := managertest.PendingEvents(t, m, models.EventUserCreated)
+	require.Len(t, created, 1)
+	require.Contains(t, string(created[0]), other.IDCode)
+
+	require.Len(t, managertest.PendingEvents(t, m, models.EventSessionEnded), 1)
+	deleted := managertest.PendingEvents(t, m, models.EventUserDeleted)
+	require.Len(t, deleted, 1)
+	require.JSONEq(t, `{"user_id": "`+response.IDCode+`"}`, string(deleted[0]))
+
+	events, err := m.Repository().AuthEvents().Find(context.Background(), &models.AuthEventsFilter{UserIDRef: &user.ID})
+	require.NoError(t, err)
+	for _, event := range events {
+		require.Empty(t, event.IP)
+		require.Empty(t, event.UserAgent)
+	}
+
+	erased, err = service.EraseNext(context.Background(), m.Service().User())
+	require.NoError(t, err)
+	require.False(t, erased)
+}
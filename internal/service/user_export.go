@@ -0,0 +1,267 @@
+package service
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/tools"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+// RequestExport queues the export of personal data of the user, the download link is mailed
+// when the bundle is ready. Only one export of the user is prepared at a time
+func (us *UserService) RequestExport(ctx context.Context, userIDCode string, format models.ExportFormat) (result *models.DataExportRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventExport}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	export := &models.DataExport{
+		ID:        uuid.New().String(),
+		UserIDRef: user.ID,
+		Format:    format,
+		Status:    models.ExportStatusPending,
+		Locale:    mailLocale(ctx),
+	}
+	created, err := us.manager.Repository().DataExports().Create(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, models.ErrExportInProgress
+	}
+	return export.ToDataExportRes(), nil
+}
+
+// GetExport returns status of the export, the download link is given while the export is ready
+func (us *UserService) GetExport(ctx context.Context, userIDCode, exportID string) (*models.DataExportRes, error) {
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := us.manager.Repository().DataExports().FindByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.UserIDRef != user.ID {
+		return nil, models.ErrExportNotFound
+	}
+
+	result := export.ToDataExportRes()
+	if export.IsDownloadable(time.Now()) {
+		if result.DownloadURL, err = us.exportURL(export); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// DownloadExport returns the ready export by the token of the download link
+func (us *UserService) DownloadExport(ctx context.Context, exportToken string) (result *models.DataExport, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventExportDownload}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	token, err := jwt.ParseWithClaims(exportToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(us.config.Token.ExportSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	if !ok || !token.Valid {
+		return nil, models.ErrInvalidToken
+	}
+
+	export, err := us.manager.Repository().DataExports().FindByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || !export.IsDownloadable(time.Now()) {
+		return nil, models.ErrExportNotFound
+	}
+	event.UserIDRef = &export.UserIDRef
+	return export, nil
+}
+
+// RunExports prepares pending exports and removes expired ones until ctx is done
+func (us *UserService) RunExports(ctx context.Context) {
+	log := us.log.Named("RunExports")
+	ticker := time.NewTicker(consts.ExportInterval)
+	defer ticker.Stop()
+
+	log.Info("started")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopped")
+			return
+		case <-ticker.C:
+			if err := us.manager.Repository().DataExports().DeleteExpired(ctx, time.Now()); err != nil {
+				log.Error(err.Error())
+			}
+			for i := 0; i < consts.ExportBatchSize && ctx.Err() == nil; i++ {
+				exported, err := us.exportNext(ctx)
+				if err != nil {
+					log.Error(err.Error())
+					break
+				}
+				if !exported {
+					break
+				}
+			}
+		}
+	}
+}
+
+// exportNext builds the bundle of the oldest pending export and mails the download link.
+// Export that could not be built is marked as failed, so it does not block the queue
+func (us *UserService) exportNext(ctx context.Context) (exported bool, err error) {
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		export, err := us.manager.Repository().DataExports().FetchPending(ctx)
+		if err != nil || export == nil {
+			return err
+		}
+		exported = true
+
+		user, err := us.manager.Repository().Users().FindByID(ctx, export.UserIDRef)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.DeletedAt != nil {
+			return us.manager.Repository().DataExports().MarkFailed(ctx, export.ID, "user is deleted")
+		}
+
+		content, err := us.buildExport(ctx, user, export.Format)
+		if err != nil {
+			us.log.Error(fmt.Sprintf("build export %s: %s", export.ID, err))
+			return us.manager.Repository().DataExports().MarkFailed(ctx, export.ID, err.Error())
+		}
+
+		expiresAt := time.Now().Add(us.config.Privacy.ExportTTL())
+		if err = us.manager.Repository().DataExports().MarkReady(ctx, export.ID, content, expiresAt); err != nil {
+			return err
+		}
+		export.Status = models.ExportStatusReady
+		export.ExpiresAt = &expiresAt
+		return us.notifyExportReady(ctx, user, export)
+	})
+	return
+}
+
+func (us *UserService) buildExport(ctx context.Context, user *models.User, format models.ExportFormat) ([]byte, error) {
+	roles, _, err := us.manager.Service().Role().Claims(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := us.manager.Repository().Sessions().FindByUserID(ctx, user.ID, consts.ExportSessionsLimit)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := us.manager.Repository().UserDevices().FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := us.manager.Repository().UserIdentities().FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := us.manager.Repository().AuthEvents().Find(ctx, &models.AuthEventsFilter{
+		UserIDRef: &user.ID,
+		Limit:     consts.ExportEventsLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &models.DataExportBundle{
+		ExportedAt:      time.Now().UTC(),
+		User:            user.ToAdminUser(),
+		LinkedProviders: make([]models.LinkedIdentity, 0, len(identities)),
+		Roles:           append([]string{}, roles...),
+		Sessions:        make([]models.AdminSession, 0, len(sessions)),
+		Devices:         devices,
+		AuthEvents:      events,
+	}
+	for i := range identities {
+		bundle.LinkedProviders = append(bundle.LinkedProviders, identities[i].ToLinkedIdentity())
+	}
+	for i := range sessions {
+		bundle.Sessions = append(bundle.Sessions, sessions[i].ToAdminSession())
+	}
+	if bundle.Devices == nil {
+		bundle.Devices = []models.UserDevice{}
+	}
+
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, errs.Wrap("marshal export", err)
+	}
+	if format == models.ExportFormatZIP {
+		content, err = tools.ZipFile("personal-data.json", content)
+		if err != nil {
+			return nil, errs.Wrap("zip export", err)
+		}
+	}
+	return content, nil
+}
+
+func (us *UserService) notifyExportReady(ctx context.Context, user *models.User, export *models.DataExport) error {
+	// the link is still available by GET /v1/auth/user/me/exports/:export_id
+	if user.Email == "" || user.EmailBouncedAt != nil {
+		return nil
+	}
+
+	downloadURL, err := us.exportURL(export)
+	if err != nil {
+		return err
+	}
+
+	locale := export.Locale
+	if locale == "" {
+		locale = consts.DefaultLocale
+	}
+	return us.manager.Service().Outbox().Mail(ctx, user.ID, models.NewMailCommand(
+		models.MailTemplateDataExportReady,
+		locale,
+		user.MailRecipient(user.Email),
+		models.MailVariables{
+			"download_url": models.URLVar(downloadURL),
+			"expires_at":   models.DateTimeVar(*export.ExpiresAt),
+		}))
+}
+
+// exportURL is a link to download the export signed until the export expires
+func (us *UserService) exportURL(export *models.DataExport) (string, error) {
+	claim := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
+		Subject:   export.ID,
+		ExpiresAt: jwt.NewNumericDate(*export.ExpiresAt),
+	})
+	token, err := claim.SignedString([]byte(us.config.Token.ExportSecret))
+	if err != nil {
+		return "", errs.Wrap("sign export token", err)
+	}
+
+	downloadURL, err := url.Parse(us.config.ServerPublicURL)
+	if err != nil {
+		return "", errs.Wrap("parse public url", err)
+	}
+	downloadURL = downloadURL.JoinPath("/v1/auth/exports/download")
+	query := downloadURL.Query()
+	query.Set("token", token)
+	downloadURL.RawQuery = query.Encode()
+	return downloadURL.String(), nil
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"archive/zip"
+	"auth-api/internal/manager"
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestUserService_Export(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	export, downloadURL := readyExport(t, m, ctx, response.IDCode, models.ExportFormatJSON)
+	require.Equal(t, models.ExportStatusReady, export.Status)
+
+	parsed, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:5000", parsed.Host)
+	require.Equal(t, "/v1/auth/exports/download", parsed.Path)
+
+	// the link is signed until the export expires
+	token := parsed.Query().Get("token")
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("export-secret"), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, export.ID, claims.Subject)
+	require.WithinDuration(t, *export.ExpiresAt, claims.ExpiresAt.Time, time.Second)
+
+	downloaded, err := m.Service().User().DownloadExport(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, "application/json", downloaded.ContentType())
+
+	var bundle models.DataExportBundle
+	require.NoError(t, json.Unmarshal(downloaded.Content, &bundle))
+	require.Equal(t, response.IDCode, bundle.User.IDCode)
+	require.Equal(t, "user@example.com", bundle.User.Email)
+	require.Empty(t, bundle.Roles)
+	require.Empty(t, bundle.LinkedProviders)
+	require.Len(t, bundle.Sessions, 1)
+	require.Equal(t, "10.0.0.1", bundle.Sessions[0].IP)
+	require.NotEmpty(t, bundle.AuthEvents)
+	require.Equal(t, models.AuthEventSignUp, bundle.AuthEvents[len(bundle.AuthEvents)-1].Type)
+
+	// the user is notified by mail
+	mails := managertest.PendingMails(t, m, models.MailTemplateDataExportReady)
+	require.Len(t, mails, 1)
+	require.Equal(t, downloadURL, mails[0].Variables["download_url"].Value)
+}
+
+func TestUserService_ExportZIP(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	_, downloadURL := readyExport(t, m, ctx, response.IDCode, models.ExportFormatZIP)
+	parsed, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+	downloaded, err := m.Service().User().DownloadExport(ctx, parsed.Query().Get("token"))
+	require.NoError(t, err)
+	require.Equal(t, "application/zip", downloaded.ContentType())
+
+	archive, err := zip.NewReader(bytes.NewReader(downloaded.Content), int64(len(downloaded.Content)))
+	require.NoError(t, err)
+	require.Len(t, archive.File, 1)
+	require.Equal(t, "personal-data.json", archive.File[0].Name)
+
+	file, err := archive.File[0].Open()
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	var bundle models.DataExportBundle
+	require.NoError(t, json.Unmarshal(content, &bundle))
+	require.Equal(t, response.IDCode, bundle.User.IDCode)
+}
+
+func TestUserService_DownloadExport_RejectsTokens(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+	export, _ := readyExport(t, m, ctx, response.IDCode, models.ExportFormatJSON)
+
+	sign := func(method jwt.SigningMethod, secret, subject string, expiresAt time.Time) string {
+		token, err := jwt.NewWithClaims(method, &jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return token
+	}
+	later := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{name: "expired", token: sign(jwt.SigningMethodHS256, "export-secret", export.ID, time.Now().Add(-time.Minute)),
+			expectedErr: models.ErrInvalidToken},
+		{name: "foreign secret", token: sign(jwt.SigningMethodHS256, "access-secret", export.ID, later),
+			expectedErr: models.ErrInvalidToken},
+		{name: "foreign method", token: sign(jwt.SigningMethodHS512, "export-secret", export.ID, later),
+			expectedErr: models.ErrInvalidToken},
+		{name: "access token", token: response.Tokens.AccessToken, expectedErr: models.ErrInvalidToken},
+		{name: "unknown export", token: sign(jwt.SigningMethodHS256, "export-secret", "unknown", later),
+			expectedErr: models.ErrExportNotFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := m.Service().User().DownloadExport(ctx, testCase.token)
+			require.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+// readyExport requests the export of the user and builds it like the export job
+func readyExport(t *testing.T, m *manager.Manager, ctx context.Context, userIDCode string, format models.ExportFormat) (*models.DataExportRes, string) {
+	t.Helper()
+
+	requested, err := m.Service().User().RequestExport(ctx, userIDCode, format)
+	require.NoError(t, err)
+	exported, err := service.ExportNext(context.Background(), m.Service().User())
+	require.NoError(t, err)
+	require.True(t, exported)
+
+	export, err := m.Service().User().GetExport(ctx, userIDCode, requested.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, export.DownloadURL)
+	return export, export.DownloadURL
+}
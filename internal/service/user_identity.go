@@ -0,0 +1,204 @@
+package service
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"crypto/subtle"
+	"github.com/doxanocap/pkg/errs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Identities returns providers linked to the user and whether the user can sign in with password
+func (us *UserService) Identities(ctx context.Context, userIDCode string) (*models.IdentitiesRes, error) {
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := us.manager.Repository().UserIdentities().FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.IdentitiesRes{
+		HasPassword: user.Password != "",
+		Identities:  make([]models.LinkedIdentity, 0, len(identities)),
+	}
+	for i := range identities {
+		result.Identities = append(result.Identities, identities[i].ToLinkedIdentity())
+	}
+	return result, nil
+}
+
+// StartLink re-authenticates the user and returns the url of the provider, the identity
+// is linked when the provider calls back
+func (us *UserService) StartLink(ctx context.Context, userIDCode string, provider models.OAuthProvider, reauth *models.ReauthReq) (result *models.OAuthRedirectRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventIdentityLink, Reason: string(provider)}
+
+	user, err := us.findManaged(ctx, userIDCode)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+	if user.DisabledAt != nil {
+		return nil, models.ErrUserDisabled
+	}
+
+	// only failed re-authentication is recorded, the link itself is recorded on the call back
+	if err = us.reauthenticate(ctx, user, reauth); err != nil {
+		event.UserIDRef = &user.ID
+		event.Actor = user.Email
+		us.manager.Service().Audit().Record(ctx, event, &err)
+		return nil, err
+	}
+	return us.manager.Service().OAuth().GetLinkURL(ctx, provider, user)
+}
+
+// UnlinkIdentity removes the provider from the user, the last way to sign in is kept
+func (us *UserService) UnlinkIdentity(ctx context.Context, userIDCode string, provider models.OAuthProvider) (err error) {
+	event := &models.AuthEvent{Type: models.AuthEventIdentityUnlink, Reason: string(provider)}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return models.ErrUserNotFound
+	}
+
+	identities, err := us.manager.Repository().UserIdentities().FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" && len(identities) <= 1 {
+		for i := range identities {
+			if identities[i].Provider == provider {
+				return models.ErrLastLoginMethod
+			}
+		}
+	}
+
+	deleted, err := us.manager.Repository().UserIdentities().Delete(ctx, user.ID, provider)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return models.ErrIdentityNotFound
+	}
+	return nil
+}
+
+// SetPassword sets or changes the password, users signed up by the provider confirm it
+// with the code sent to their email. Sessions on other devices are ended and the new one
+// is started for the caller
+func (us *UserService) SetPassword(ctx context.Context, userIDCode string, request *models.SetPasswordReq) (result *models.AuthResponse, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventPasswordChange}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+	if user.DisabledAt != nil {
+		return nil, models.ErrUserDisabled
+	}
+
+	if err = us.reauthenticate(ctx, user, request.ToReauthReq()); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), consts.AuthHashCost)
+	if err != nil {
+		return nil, errs.Wrap("generate hash password", err)
+	}
+	user.Password = string(hashedPassword)
+
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := us.manager.Repository().Users().SetPassword(ctx, user.ID, user.Password); err != nil {
+			return err
+		}
+		return us.endSessions(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := us.manager.Service().Auth().UpdateSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	event.SessionIDRef = &tokens.SessionID
+
+	return &models.AuthResponse{
+		UserDTO: user.ToUserDTO(),
+		Tokens:  tokens,
+	}, nil
+}
+
+// reauthenticate checks the current password, or the verification code sent to the email
+// of the user. Checks are counted before comparing, so concurrent guesses can not pass the limit:
+// the password is checked a few times in the window, the code is used once and dropped
+// after a few wrong guesses
+func (us *UserService) reauthenticate(ctx context.Context, user *models.User, request *models.ReauthReq) error {
+	if request.Password != "" {
+		return us.reauthenticatePassword(ctx, user, request.Password)
+	}
+
+	if user.Email == "" {
+		return models.ErrInvalidCode
+	}
+	codes := us.manager.Repository().VerificationCodes()
+	attempts, err := codes.Attempt(ctx, user.TenantID, user.Email)
+	if err != nil {
+		return err
+	}
+	if attempts == 0 {
+		return models.ErrInvalidCode
+	}
+	if attempts > consts.VerificationCodeMaxAttempts {
+		if err = codes.Delete(ctx, user.TenantID, user.Email); err != nil {
+			return err
+		}
+		return models.ErrInvalidCode
+	}
+
+	code, err := codes.Get(ctx, user.TenantID, user.Email)
+	if err != nil {
+		return err
+	}
+	if code == "" || subtle.ConstantTimeCompare([]byte(code), []byte(request.Code)) != 1 {
+		if attempts == consts.VerificationCodeMaxAttempts {
+			if err = codes.Delete(ctx, user.TenantID, user.Email); err != nil {
+				return err
+			}
+		}
+		return models.ErrInvalidCode
+	}
+	return codes.Delete(ctx, user.TenantID, user.Email)
+}
+
+func (us *UserService) reauthenticatePassword(ctx context.Context, user *models.User, password string) error {
+	reauthAttempts := us.manager.Repository().ReauthAttempts()
+	attempts, err := reauthAttempts.Incr(ctx, user.TenantID, user.IDCode)
+	if err != nil {
+		return err
+	}
+	if attempts > consts.ReauthMaxAttempts {
+		return models.ErrTooManyAttempts
+	}
+
+	if user.Password == "" {
+		return models.ErrIncorrectPassword
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return models.ErrIncorrectPassword
+	}
+	return reauthAttempts.Delete(ctx, user.TenantID, user.IDCode)
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"auth-api/internal/manager"
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUserService_UnlinkIdentity_KeepsLastLoginMethod(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := providerUser(t, m, ctx, "user@example.com")
+
+	err := m.Service().User().UnlinkIdentity(ctx, user.IDCode, models.GoogleOAuth)
+	require.ErrorIs(t, err, models.ErrLastLoginMethod)
+
+	// with the password the provider may go
+	code := verificationCode(t, m, ctx, "user@example.com")
+	_, err = m.Service().User().SetPassword(ctx, user.IDCode, &models.SetPasswordReq{Password: "Password123!", Code: code})
+	require.NoError(t, err)
+	require.NoError(t, m.Service().User().UnlinkIdentity(ctx, user.IDCode, models.GoogleOAuth))
+
+	err = m.Service().User().UnlinkIdentity(ctx, user.IDCode, models.GoogleOAuth)
+	require.ErrorIs(t, err, models.ErrIdentityNotFound)
+}
+
+func TestUserService_SetPassword_WithPassword(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	_, err := m.Service().User().SetPassword(ctx, response.IDCode,
+		&models.SetPasswordReq{Password: "Changed123!", CurrentPassword: "Wrong123!"})
+	require.ErrorIs(t, err, models.ErrIncorrectPassword)
+
+	phone := managertest.RequestContext("10.0.0.2", "phone")
+	result, err := m.Service().User().SetPassword(phone, response.IDCode,
+		&models.SetPasswordReq{Password: "Changed123!", CurrentPassword: "Password123!"})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Tokens.AccessToken)
+
+	// the session of the other device is ended, the caller gets the new one
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	session, err := m.Repository().Sessions().FindByToken(ctx, tenant.ID, result.Tokens.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "phone", session.UserAgent)
+	require.Len(t, managertest.PendingEvents(t, m, models.EventSessionEnded), 1)
+
+	_, err = m.Service().User().Authenticate(ctx, &models.UserDTO{Email: "user@example.com", Password: "Changed123!"})
+	require.NoError(t, err)
+}
+
+func TestUserService_SetPassword_WithCode(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := providerUser(t, m, ctx, "user@example.com")
+
+	// the user without password can not confirm with one
+	_, err := m.Service().User().SetPassword(ctx, user.IDCode,
+		&models.SetPasswordReq{Password: "Password123!", CurrentPassword: "Password123!"})
+	require.ErrorIs(t, err, models.ErrIncorrectPassword)
+
+	code := verificationCode(t, m, ctx, "user@example.com")
+	_, err = m.Service().User().SetPassword(ctx, user.IDCode, &models.SetPasswordReq{Password: "Password123!", Code: code})
+	require.NoError(t, err)
+
+	// the code is used once
+	_, err = m.Service().User().SetPassword(ctx, user.IDCode, &models.SetPasswordReq{Password: "Password123!", Code: code})
+	require.ErrorIs(t, err, models.ErrInvalidCode)
+
+	identities, err := m.Service().User().Identities(ctx, user.IDCode)
+	require.NoError(t, err)
+	require.True(t, identities.HasPassword)
+}
+
+func TestUserService_Reauthenticate_DropsCodeAfterWrongGuesses(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := providerUser(t, m, ctx, "user@example.com")
+
+	code := verificationCode(t, m, ctx, "user@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < consts.VerificationCodeMaxAttempts; i++ {
+		_, err := m.Service().User().SetPassword(ctx, user.IDCode, &models.SetPasswordReq{Password: "Password123!", Code: wrong})
+		require.ErrorIs(t, err, models.ErrInvalidCode)
+	}
+
+	_, err := m.Service().User().SetPassword(ctx, user.IDCode, &models.SetPasswordReq{Password: "Password123!", Code: code})
+	require.ErrorIs(t, err, models.ErrInvalidCode)
+}
+
+func TestUserService_Reauthenticate_LimitsPasswordChecks(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	response := managertest.SignUp(t, m, ctx, "user@example.com")
+	wrong := &models.ReauthReq{Password: "Wrong123!"}
+
+	for i := 0; i < consts.ReauthMaxAttempts; i++ {
+		_, err := m.Service().User().StartLink(ctx, response.IDCode, models.GoogleOAuth, wrong)
+		require.ErrorIs(t, err, models.ErrIncorrectPassword)
+	}
+
+	// the right password does not pass until the window ends
+	_, err := m.Service().User().SetPassword(ctx, response.IDCode,
+		&models.SetPasswordReq{Password: "Changed123!", CurrentPassword: "Password123!"})
+	require.ErrorIs(t, err, models.ErrTooManyAttempts)
+}
+
+// providerUser signs up the user by google, the user has no password
+func providerUser(t *testing.T, m *manager.Manager, ctx context.Context, email string) *models.AuthResponse {
+	t.Helper()
+
+	response, err := m.Service().User().CreateWithIdentity(ctx, &models.UserDTO{
+		Email:         email,
+		Activated:     true,
+		OAuthProvider: models.GoogleOAuth,
+	}, &models.UserIdentity{Provider: models.GoogleOAuth, Subject: email, Email: email, EmailVerified: true})
+	require.NoError(t, err)
+	return response
+}
+
+func verificationCode(t *testing.T, m *manager.Manager, ctx context.Context, email string) string {
+	t.Helper()
+
+	require.NoError(t, m.Service().User().SendVerifyCode(ctx, email))
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	code, err := m.Repository().VerificationCodes().Get(ctx, tenant.ID, email)
+	require.NoError(t, err)
+	require.NotEmpty(t, code)
+	return code
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/tools"
+	"context"
+	"crypto/subtle"
+	"github.com/doxanocap/pkg/ctxholder"
+	"time"
+)
+
+// UpdateProfile saves profile fields at once. New email or phone is only requested: the code
+// goes to the new address, the notice goes to the old one and the change waits for confirmation
+func (us *UserService) UpdateProfile(ctx context.Context, userIDCode string, request *models.UpdateProfileReq) (result *models.ProfileUpdateRes, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventProfileUpdate}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	changed := false
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{request.FirstName, &user.FirstName},
+		{request.LastName, &user.LastName},
+		{request.Locale, &user.Locale},
+	} {
+		if field.value != nil && *field.value != *field.target {
+			*field.target = *field.value
+			changed = true
+		}
+	}
+	if changed {
+		err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+			if err := us.manager.Repository().Users().Update(ctx, user); err != nil {
+				return err
+			}
+			return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserUpdated, &models.UserEventPayload{
+				UserID:        user.IDCode,
+				Email:         user.Email,
+				OAuthProvider: user.OAuthProvider,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result = &models.ProfileUpdateRes{Pending: []models.ContactKind{}}
+	if request.Email != nil && *request.Email != user.Email {
+		if err = us.checkEmailAvailable(ctx, user, *request.Email); err != nil {
+			return nil, err
+		}
+		if err = us.requestContactChange(ctx, user, models.ContactEmail, *request.Email); err != nil {
+			return nil, err
+		}
+		result.Pending = append(result.Pending, models.ContactEmail)
+	}
+	if request.PhoneNumber != nil && *request.PhoneNumber != user.PhoneNumber {
+		if err = us.requestContactChange(ctx, user, models.ContactPhone, *request.PhoneNumber); err != nil {
+			return nil, err
+		}
+		result.Pending = append(result.Pending, models.ContactPhone)
+	}
+
+	result.User = user.ToUserDTO()
+	return result, nil
+}
+
+// ConfirmContactChange commits the requested email or phone by its code and re-issues tokens,
+// so they carry the new email. The change is dropped after too many wrong codes
+func (us *UserService) ConfirmContactChange(ctx context.Context, userIDCode string, request *models.ConfirmContactReq) (result *models.AuthResponse, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventContactChange, Reason: string(request.Kind)}
+	defer us.manager.Service().Audit().Record(ctx, event, &err)
+
+	user, err := us.findForCommand(ctx, userIDCode, event)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	changes := us.manager.Repository().ContactChanges()
+	change, err := changes.Get(ctx, user.TenantID, user.IDCode, request.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, models.ErrChangeNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(change.Code), []byte(request.Code)) != 1 {
+		// wrong codes are counted atomically and do not extend the change,
+		// the change without the counter is dropped as well
+		attempts, err := changes.Attempt(ctx, user.TenantID, user.IDCode, request.Kind)
+		if err != nil {
+			return nil, err
+		}
+		if attempts == 0 || attempts >= consts.ContactChangeMaxAttempts {
+			if err = changes.Delete(ctx, user.TenantID, user.IDCode, request.Kind); err != nil {
+				return nil, err
+			}
+		}
+		return nil, models.ErrInvalidCode
+	}
+
+	switch change.Kind {
+	case models.ContactEmail:
+		if err = us.checkEmailAvailable(ctx, user, change.Value); err != nil {
+			return nil, err
+		}
+		// the code has reached the new address, so it is verified and not bounced
+		user.Email = change.Value
+		user.Activated = true
+		user.EmailBouncedAt = nil
+	case models.ContactPhone:
+		user.PhoneNumber = change.Value
+	}
+
+	err = us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		if err := us.manager.Repository().Users().Update(ctx, user); err != nil {
+			return err
+		}
+		payload := &models.UserEventPayload{
+			UserID:        user.IDCode,
+			Email:         user.Email,
+			OAuthProvider: user.OAuthProvider,
+		}
+		if err := us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserUpdated, payload); err != nil {
+			return err
+		}
+		if change.Kind != models.ContactEmail {
+			return nil
+		}
+		return us.manager.Service().Outbox().Event(ctx, user.ID, models.EventUserVerified, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = changes.Delete(ctx, user.TenantID, user.IDCode, change.Kind); err != nil {
+		return nil, err
+	}
+
+	tokens, err := us.manager.Service().Auth().RefreshSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	event.SessionIDRef = &tokens.SessionID
+
+	return &models.AuthResponse{
+		UserDTO: user.ToUserDTO(),
+		Tokens:  tokens,
+	}, nil
+}
+
+func (us *UserService) checkEmailAvailable(ctx context.Context, user *models.User, email string) error {
+	found, err := us.manager.Repository().Users().FindByEmail(ctx, user.TenantID, email)
+	if err != nil {
+		return err
+	}
+	if found != nil && found.ID != user.ID {
+		return models.ErrUserAlreadyExist
+	}
+	return nil
+}
+
+// requestContactChange stores the change with a new code, sends the code to the new address
+// and the notice to the old one
+func (us *UserService) requestContactChange(ctx context.Context, user *models.User, kind models.ContactKind, value string) error {
+	code := tools.NewVerificationCode()
+	if err := us.manager.Repository().ContactChanges().Set(ctx, user.TenantID, user.IDCode, &models.ContactChange{
+		Kind:  kind,
+		Value: value,
+		Code:  code,
+	}); err != nil {
+		return err
+	}
+
+	locale := mailLocale(ctx)
+	codeVars := models.MailVariables{
+		"code":               models.StringVar(code),
+		"expires_in_minutes": models.NumberVar(consts.ContactChangeTTL.Minutes()),
+	}
+	requestedAt := models.DateTimeVar(time.Now())
+
+	// code is stored first, so it is never sent before it exists
+	return us.manager.Repository().Transaction(ctx, func(ctx context.Context) error {
+		outbox := us.manager.Service().Outbox()
+		switch kind {
+		case models.ContactEmail:
+			if err := outbox.Mail(ctx, user.ID, models.NewMailCommand(
+				models.MailTemplateEmailChangeCode, locale,
+				user.MailRecipient(value), codeVars)); err != nil {
+				return err
+			}
+			if user.Email == "" || user.EmailBouncedAt != nil {
+				return nil
+			}
+			return outbox.Mail(ctx, user.ID, models.NewMailCommand(
+				models.MailTemplateEmailChangeReq, locale,
+				user.MailRecipient(user.Email),
+				models.MailVariables{
+					"ip":           models.StringVar(ctxholder.GetStringByKey(ctx, consts.CtxKeyClientIP)),
+					"requested_at": requestedAt,
+				}))
+		case models.ContactPhone:
+			if err := outbox.Sms(ctx, user.ID, models.NewSmsCommand(
+				models.SmsTemplatePhoneChangeCode, locale,
+				models.SmsRecipient{PhoneNumber: value}, codeVars)); err != nil {
+				return err
+			}
+			if user.PhoneNumber == "" {
+				return nil
+			}
+			return outbox.Sms(ctx, user.ID, models.NewSmsCommand(
+				models.SmsTemplatePhoneChangeRequested, locale,
+				models.SmsRecipient{PhoneNumber: user.PhoneNumber},
+				models.MailVariables{"requested_at": requestedAt}))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUserService_ConfirmEmailVerifiesUser(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := managertest.SignUp(t, m, ctx, "user@example.com")
+	require.Empty(t, managertest.PendingEvents(t, m, models.EventUserVerified))
+
+	email := "new@example.com"
+	_, err := m.Service().User().UpdateProfile(ctx, user.IDCode, &models.UpdateProfileReq{Email: &email})
+	require.NoError(t, err)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	change, err := m.Repository().ContactChanges().Get(ctx, tenant.ID, user.IDCode, models.ContactEmail)
+	require.NoError(t, err)
+
+	confirmed, err := m.Service().User().ConfirmContactChange(ctx, user.IDCode,
+		&models.ConfirmContactReq{Kind: models.ContactEmail, Code: change.Code})
+	require.NoError(t, err)
+	require.True(t, confirmed.Activated)
+
+	verified := managertest.PendingEvents(t, m, models.EventUserVerified)
+	require.Len(t, verified, 1)
+	var payload models.UserEventPayload
+	require.NoError(t, json.Unmarshal(verified[0], &payload))
+	require.Equal(t, user.IDCode, payload.UserID)
+	require.Equal(t, email, payload.Email)
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	// names are saved at once
+	firstName, email := "Ann", "new@example.com"
+	result, err := m.Service().User().UpdateProfile(ctx, user.IDCode,
+		&models.UpdateProfileReq{FirstName: &firstName, Email: &email})
+	require.NoError(t, err)
+	require.Equal(t, "Ann", result.User.FirstName)
+	require.Equal(t, "user@example.com", result.User.Email)
+	require.Equal(t, []models.ContactKind{models.ContactEmail}, result.Pending)
+
+	updated := managertest.PendingEvents(t, m, models.EventUserUpdated)
+	require.Len(t, updated, 1)
+	var payload models.UserEventPayload
+	require.NoError(t, json.Unmarshal(updated[0], &payload))
+	require.Equal(t, user.IDCode, payload.UserID)
+
+	// the code goes to the new address, the notice to the old one
+	codes := managertest.PendingMails(t, m, models.MailTemplateEmailChangeCode)
+	require.Len(t, codes, 1)
+	require.Equal(t, "new@example.com", codes[0].Recipient.Email)
+	notices := managertest.PendingMails(t, m, models.MailTemplateEmailChangeReq)
+	require.Len(t, notices, 1)
+	require.Equal(t, "user@example.com", notices[0].Recipient.Email)
+
+	// unchanged names are not saved again
+	_, err = m.Service().User().UpdateProfile(ctx, user.IDCode, &models.UpdateProfileReq{FirstName: &firstName})
+	require.NoError(t, err)
+	require.Len(t, managertest.PendingEvents(t, m, models.EventUserUpdated), 1)
+}
+
+func TestUserService_ConfirmContactChange_WrongCode(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	phone := "+77001234567"
+	_, err := m.Service().User().UpdateProfile(ctx, user.IDCode, &models.UpdateProfileReq{PhoneNumber: &phone})
+	require.NoError(t, err)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	change, err := m.Repository().ContactChanges().Get(ctx, tenant.ID, user.IDCode, models.ContactPhone)
+	require.NoError(t, err)
+	wrong := &models.ConfirmContactReq{Kind: models.ContactPhone, Code: "000000"}
+	if change.Code == wrong.Code {
+		wrong.Code = "111111"
+	}
+
+	for i := 1; i < consts.ContactChangeMaxAttempts; i++ {
+		_, err = m.Service().User().ConfirmContactChange(ctx, user.IDCode, wrong)
+		require.ErrorIs(t, err, models.ErrInvalidCode)
+	}
+
+	// the right code still works before the limit
+	confirmed, err := m.Service().User().ConfirmContactChange(ctx, user.IDCode,
+		&models.ConfirmContactReq{Kind: models.ContactPhone, Code: change.Code})
+	require.NoError(t, err)
+	require.Equal(t, phone, confirmed.PhoneNumber)
+	require.NotEmpty(t, confirmed.Tokens.AccessToken)
+	require.Len(t, managertest.PendingEvents(t, m, models.EventUserUpdated), 1)
+	require.Empty(t, managertest.PendingEvents(t, m, models.EventUserVerified))
+
+	_, err = m.Service().User().ConfirmContactChange(ctx, user.IDCode, wrong)
+	require.ErrorIs(t, err, models.ErrChangeNotFound)
+}
+
+func TestUserService_ConfirmContactChange_DroppedAfterMaxAttempts(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	email := "new@example.com"
+	_, err := m.Service().User().UpdateProfile(ctx, user.IDCode, &models.UpdateProfileReq{Email: &email})
+	require.NoError(t, err)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	change, err := m.Repository().ContactChanges().Get(ctx, tenant.ID, user.IDCode, models.ContactEmail)
+	require.NoError(t, err)
+	wrong := &models.ConfirmContactReq{Kind: models.ContactEmail, Code: "000000"}
+	if change.Code == wrong.Code {
+		wrong.Code = "111111"
+	}
+
+	for i := 0; i < consts.ContactChangeMaxAttempts; i++ {
+		_, err = m.Service().User().ConfirmContactChange(ctx, user.IDCode, wrong)
+		require.ErrorIs(t, err, models.ErrInvalidCode)
+	}
+
+	_, err = m.Service().User().ConfirmContactChange(ctx, user.IDCode,
+		&models.ConfirmContactReq{Kind: models.ContactEmail, Code: change.Code})
+	require.ErrorIs(t, err, models.ErrChangeNotFound)
+}
+
+func TestUserService_ConfirmContactChange_Expired(t *testing.T) {
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	user := managertest.SignUp(t, m, ctx, "user@example.com")
+
+	email := "new@example.com"
+	_, err := m.Service().User().UpdateProfile(ctx, user.IDCode, &models.UpdateProfileReq{Email: &email})
+	require.NoError(t, err)
+
+	tenant, err := m.Service().Tenant().Current(ctx)
+	require.NoError(t, err)
+	change, err := m.Repository().ContactChanges().Get(ctx, tenant.ID, user.IDCode, models.ContactEmail)
+	require.NoError(t, err)
+
+	// the change is gone like after its ttl, the ttl itself is covered by the repository tests
+	require.NoError(t, m.Repository().ContactChanges().Delete(ctx, tenant.ID, user.IDCode, models.ContactEmail))
+
+	_, err = m.Service().User().ConfirmContactChange(ctx, user.IDCode,
+		&models.ConfirmContactReq{Kind: models.ContactEmail, Code: change.Code})
+	require.ErrorIs(t, err, models.ErrChangeNotFound)
+}
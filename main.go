@@ -3,9 +3,8 @@ package main
 import (
 	"auth-api/internal/manager"
 	"auth-api/internal/models"
-	"auth-api/internal/pkg/postgres"
-	"auth-api/internal/pkg/rabbitmq"
-	"auth-api/internal/pkg/redis"
+	"auth-api/internal/pkg/geoip"
+	"auth-api/internal/pkg/memory"
 	"github.com/doxanocap/pkg/config"
 	"github.com/doxanocap/pkg/logger"
 	"go.uber.org/fx"
@@ -13,20 +12,28 @@ import (
 )
 
 func main() {
-	app := fx.New(
+	app := fx.New(options())
+
+	app.Run()
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options builds the application, tests decorate its config and logger
+func options() fx.Option {
+	return fx.Options(
 		fx.Provide(
 			config.InitConfig[models.Config],
 			logger.InitLogger[models.Config],
-			rabbitmq.NewProducerClient,
-			postgres.InitConnection,
-			redis.InitConnection,
+			manager.InitQueueProducer,
+			manager.InitQueueConsumer,
+			manager.InitDatabase,
+			manager.InitCacheProvider,
+			memory.NewQueue,
+			geoip.InitReader,
 			manager.InitManager,
 		),
 		fx.Invoke(manager.Run),
 	)
-
-	app.Run()
-	if err := app.Err(); err != nil {
-		log.Fatal(err)
-	}
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/memory"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApp_BootsOnMemoryDrivers(t *testing.T) {
+	port := freePort(t)
+	var (
+		queue  *memory.Queue
+		config *models.Config
+	)
+	app := fxtest.New(t,
+		options(),
+		fx.Decorate(func(config *models.Config) *models.Config {
+			config.CacheDriver = consts.CacheDriverMemory
+			config.QueueDriver = consts.QueueDriverMemory
+			config.DatabaseDriver = consts.DatabaseDriverMemory
+			config.ServerPORT = port
+			config.ServerPublicURL = "http://localhost:" + port
+			return config
+		}),
+		fx.Decorate(func() *zap.Logger { return zap.NewNop() }),
+		fx.Populate(&queue, &config),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	baseURL := "http://localhost:" + port + "/v1/auth"
+	credentials := models.SignUpReq{Email: "user@example.com", Password: "Password123!", PhoneNumber: "87082260629"}
+
+	var signUp models.AuthResponse
+	require.Eventually(t, func() bool {
+		return post(t, baseURL+"/sign-up", credentials, &signUp) == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NotEmpty(t, signUp.Tokens.AccessToken)
+
+	var signIn models.AuthResponse
+	status := post(t, baseURL+"/sign-in", models.SignInReq{Email: credentials.Email, Password: credentials.Password}, &signIn)
+	require.Equal(t, http.StatusOK, status)
+
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/verify", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signIn.Tokens.AccessToken)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// events go through the outbox relay to the in-memory queue
+	require.Eventually(t, func() bool {
+		for _, record := range queue.Records() {
+			if record.Exchange == config.EventsExchange && record.RoutingKey == string(models.EventUserCreated) {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func post(t *testing.T, url string, body, result any) int {
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	res, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return 0
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+	}
+	return res.StatusCode
+}
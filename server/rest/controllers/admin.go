@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"auth-api/internal/interfaces"
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/metrics"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type AdminController struct {
+	log     *zap.Logger
+	config  *models.Config
+	metrics *metrics.APIMetrics
+	service interfaces.IService
+}
+
+func InitAdminController(
+	config *models.Config,
+	service interfaces.IService,
+	metrics *metrics.APIMetrics,
+	log *zap.Logger) *AdminController {
+	return &AdminController{
+		log:     log,
+		config:  config,
+		metrics: metrics,
+		service: service,
+	}
+}
+
+// GetAuthEvents searches authentication audit log
+func (ctl *AdminController) GetAuthEvents(c *gin.Context) {
+	ctl.metrics.AdminAuthEventsRequests.Inc()
+
+	var request models.AuthEventsReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.Audit().Search(c, request.ToFilter())
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDeadLetterMails returns dead-lettered verification mails
+func (ctl *AdminController) GetDeadLetterMails(c *gin.Context) {
+	ctl.metrics.AdminDeadLetterMailsRequests.Inc()
+
+	var request models.DeadLettersReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	items, err := ctl.service.Mail().DeadLetters(c, request.GetLimit())
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DeadLetteredMailsRes{Items: items})
+}
+
+// RequeueDeadLetterMails moves dead-lettered verification mails back to the mails queue
+func (ctl *AdminController) RequeueDeadLetterMails(c *gin.Context) {
+	ctl.metrics.AdminRequeueDeadLettersRequest.Inc()
+
+	var request models.RequeueDeadLettersReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	requeued, err := ctl.service.Mail().RequeueDeadLetters(c, request.IDs)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RequeueDeadLettersRes{Requeued: requeued})
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAdminController_AuthEventsReq(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.AuthEventsReq
+	}{
+		{
+			name:        "empty request",
+			request:     models.AuthEventsReq{},
+			expectedErr: nil,
+		},
+		{
+			name: "valid request",
+			request: models.AuthEventsReq{
+				PaginationReq: models.PaginationReq{Limit: 50, Offset: 100},
+				UserIDCode:    "ac575f74-bed1-4181-9ed2-1df726774044",
+				Type:          models.AuthEventSignIn,
+				Result:        models.AuthResultFailure,
+				From:          now.Add(-time.Hour),
+				To:            now,
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "too big limit",
+			request: models.AuthEventsReq{
+				PaginationReq: models.PaginationReq{Limit: 1000},
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid user id",
+			request: models.AuthEventsReq{
+				UserIDCode: "invalid_id",
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid event type",
+			request: models.AuthEventsReq{
+				Type: "invalid_type",
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid result",
+			request: models.AuthEventsReq{
+				Result: "invalid_result",
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid period",
+			request: models.AuthEventsReq{
+				From: now,
+				To:   now.Add(-time.Hour),
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestAdminController_RequeueDeadLettersReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.RequeueDeadLettersReq
+	}{
+		{
+			name: "valid request",
+			request: models.RequeueDeadLettersReq{
+				IDs: []string{"ac575f74-bed1-4181-9ed2-1df726774044"},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "empty ids",
+			request:     models.RequeueDeadLettersReq{},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "empty id",
+			request: models.RequeueDeadLettersReq{
+				IDs: []string{""},
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "too many ids",
+			request: models.RequeueDeadLettersReq{
+				IDs: make([]string, 1000),
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestAdminController_UsersReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.UsersReq
+	}{
+		{
+			name:        "empty request",
+			request:     models.UsersReq{},
+			expectedErr: nil,
+		},
+		{
+			name: "valid request",
+			request: models.UsersReq{
+				PaginationReq: models.PaginationReq{Limit: 50, Offset: 100},
+				Email:         "john@",
+				PhoneNumber:   "+7701",
+				OAuthProvider: models.GoogleOAuth,
+				Status:        models.UserStatusDisabled,
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "too big limit",
+			request: models.UsersReq{
+				PaginationReq: models.PaginationReq{Limit: 1000},
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid provider",
+			request: models.UsersReq{
+				OAuthProvider: "myspace",
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid status",
+			request: models.UsersReq{
+				Status: "banned",
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
@@ -58,7 +58,7 @@ func (ctl *AuthController) SignIn(c *gin.Context) {
 		return
 	}
 
-	ctl.setRefreshToken(c, response.Tokens.RefreshToken)
+	setRefreshToken(c, response.Tokens.RefreshToken)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -83,7 +83,7 @@ func (ctl *AuthController) SignUp(c *gin.Context) {
 		return
 	}
 
-	ctl.setRefreshToken(c, response.Tokens.RefreshToken)
+	setRefreshToken(c, response.Tokens.RefreshToken)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -103,7 +103,7 @@ func (ctl *AuthController) Refresh(c *gin.Context) {
 	}
 
 	fmt.Println(response)
-	ctl.setRefreshToken(c, response.RefreshToken)
+	setRefreshToken(c, response.RefreshToken)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -121,7 +121,7 @@ func (ctl *AuthController) Logout(c *gin.Context) {
 		return
 	}
 
-	ctl.setRefreshToken(c, "")
+	setRefreshToken(c, "")
 	c.Status(http.StatusOK)
 }
 
@@ -152,6 +152,51 @@ func (ctl *AuthController) VerifySession(c *gin.Context) {
 	c.JSON(http.StatusOK, userSession)
 }
 
+// RevokeSessionPage is the landing page of the "this wasn't me" link from the new device notification.
+// It only asks to confirm, so link previews and mail scanners opening the link do not end the session
+func (ctl *AuthController) RevokeSessionPage(c *gin.Context) {
+	var request models.RevokeSessionReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	renderRevokePage(c, http.StatusOK, revokePageData{Action: c.Request.URL.Path, Token: request.Token})
+}
+
+// RevokeSession ends the session confirmed on the revoke page
+func (ctl *AuthController) RevokeSession(c *gin.Context) {
+	ctl.metrics.RevokeSessionRequest.Inc()
+
+	var request models.RevokeSessionReq
+	if err := c.ShouldBind(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	if err := ctl.service.Auth().RevokeSession(c, request.Token); err != nil {
+		code := errs.UnmarshalError(err).StatusCode
+		if code == http.StatusInternalServerError {
+			errs.SetGinError(c, err)
+			return
+		}
+		renderRevokePage(c, code, revokePageData{Message: "The link has expired or has already been used."})
+		return
+	}
+
+	renderRevokePage(c, http.StatusOK, revokePageData{Message: "The session has been ended."})
+}
+
 func (ctl *AuthController) getAccessToken(c *gin.Context) string {
 	authHeader := c.GetHeader("Authorization")
 	split := strings.Split(authHeader, " ")
@@ -161,7 +206,7 @@ func (ctl *AuthController) getAccessToken(c *gin.Context) string {
 	return split[1]
 }
 
-func (ctl *AuthController) setRefreshToken(ctx *gin.Context, token string) {
+func setRefreshToken(ctx *gin.Context, token string) {
 	//u, _ := url.Parse(ctl.config.ClientCallBackURI)
 
 	ctx.SetCookie(keyRefreshToken,
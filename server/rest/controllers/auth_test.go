@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"auth-api/internal/models"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -123,3 +126,27 @@ func TestAuthController_SignUpReq(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthController_RevokeSessionPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctl := &AuthController{}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/auth/sessions/revoke?token=a%22b%3Cc", nil)
+
+	ctl.RevokeSessionPage(c)
+
+	// the page only asks to confirm, the session is ended by the posted form
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-referrer", recorder.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+	body := recorder.Body.String()
+	assert.Contains(t, body, `<form method="post" action="/v1/auth/sessions/revoke">`)
+	assert.Contains(t, body, `name="token" value="a&#34;b&lt;c"`)
+}
+
+func TestRevokeSessionReq_Validate(t *testing.T) {
+	assert.Equal(t, models.ErrInvalidRequest, models.RevokeSessionReq{}.Validate())
+	assert.NoError(t, models.RevokeSessionReq{Token: "token"}.Validate())
+}
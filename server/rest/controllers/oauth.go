@@ -3,15 +3,20 @@ package controllers
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"auth-api/internal/pkg/metrics"
-	"auth-api/internal/pkg/tools"
+	"crypto/subtle"
 	"fmt"
-	"github.com/doxanocap/pkg/ctxholder"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
 	"net/url"
+	"strings"
+)
+
+const (
+	keyOAuthState = "oauth_state_"
 )
 
 type OAuthController struct {
@@ -38,19 +43,26 @@ func InitOAuthController(
 func (h *OAuthController) GoogleRedirect(c *gin.Context) {
 	h.metrics.GoogleRedirectRequest.Inc()
 	log := h.log.Named("GoogleRedirect")
-	state := c.Param("state")
-	if !tools.IsUUID(state) {
-		errs.SetGinError(c, models.HttpBadRequest)
+
+	var request models.OAuthRedirectReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
 		return
 	}
 
-	response, err := h.service.OAuth().Google().GetRedirectURL(c, state)
+	response, err := h.service.OAuth().Google().GetRedirectURL(c, request.ReturnTo)
 	if err != nil {
-		errs.SetGinError(c, models.HttpBadRequest)
+		errs.SetGinError(c, err)
 		return
 	}
 
-	log.Info(fmt.Sprintf("redirected | %s", state))
+	setStateCookie(c, h.config, models.GoogleOAuth, response.State)
+	log.Info(fmt.Sprintf("redirected | %s", response.State))
 	c.JSON(http.StatusOK, response)
 }
 
@@ -67,28 +79,194 @@ func (h *OAuthController) GoogleCallBack(c *gin.Context) {
 
 	state := c.Query("state")
 	exchangeCode := c.Query("code")
-	if !tools.IsUUID(state) || exchangeCode == "" {
+	if state == "" || exchangeCode == "" {
 		errs.SetBothErrors(c, models.HttpBadRequest, errs.New("bad state or code"))
 		return
 	}
 
-	ctxholder.SetKV(c, "client_ip", c.ClientIP())
-	accessToken, err := h.service.OAuth().Google().HandleCallBack(c, state, exchangeCode)
+	if err := checkStateCookie(c, h.config, models.GoogleOAuth, state); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := h.service.OAuth().Google().HandleCallBack(c, state, exchangeCode)
 	if err != nil {
 		errs.SetGinError(c, err)
 		return
 	}
 
-	redirectURL, err := url.Parse(h.config.ClientCallBackURI)
+	query := url.Values{}
+	query.Add("state", state)
+	if response.Linked {
+		query.Add("linked", string(models.GoogleOAuth))
+	} else {
+		query.Add("code", response.Code)
+	}
+	if response.ReturnTo != "" {
+		query.Add("return_to", response.ReturnTo)
+	}
+
+	log.Info(fmt.Sprintf("redirected | %s", state))
+	h.redirectToClient(c, query)
+}
+
+// Redirect returns url of the provider to sign in with
+func (h *OAuthController) Redirect(c *gin.Context) {
+	h.metrics.OAuthRedirectRequests.Inc()
+
+	name := models.OAuthProvider(c.Param("provider"))
+	provider, err := h.service.OAuth().Provider(name)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	var request models.OAuthRedirectReq
+	if err = c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err = request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := provider.GetRedirectURL(c, request.ReturnTo)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	setStateCookie(c, h.config, name, response.State)
+	c.JSON(http.StatusOK, response)
+}
+
+// CallBack completes sign in or linking and redirects to the client with the provider name
+func (h *OAuthController) CallBack(c *gin.Context) {
+	h.metrics.OAuthCallBackRequests.Inc()
+	log := h.log.Named("CallBack")
+
+	name := models.OAuthProvider(c.Param("provider"))
+	provider, err := h.service.OAuth().Provider(name)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	if errReason := c.Query("error"); errReason != "" {
+		errs.SetBothErrors(c, models.HttpBadRequest, errs.New(errReason))
+		return
+	}
+
+	state := c.Query("state")
+	exchangeCode := c.Query("code")
+	if state == "" || exchangeCode == "" {
+		errs.SetBothErrors(c, models.HttpBadRequest, errs.New("bad state or code"))
+		return
+	}
+
+	if err = checkStateCookie(c, h.config, name, state); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := provider.HandleCallBack(c, state, exchangeCode)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	query := url.Values{}
+	query.Add("state", state)
+	query.Add("provider", string(name))
+	if response.Linked {
+		query.Add("linked", string(name))
+	} else {
+		query.Add("code", response.Code)
+	}
+	if response.ReturnTo != "" {
+		query.Add("return_to", response.ReturnTo)
+	}
+
+	log.Info(fmt.Sprintf("redirected | %s | %s", name, state))
+	h.redirectToClient(c, query)
+}
+
+// Exchange returns the session of the provider call back by its one-time code and sets the refresh cookie
+func (h *OAuthController) Exchange(c *gin.Context) {
+	h.metrics.OAuthExchangeRequests.Inc()
+
+	var request models.OAuthExchangeReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := h.service.OAuth().Exchange(c, request.Code)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	setRefreshToken(c, response.Tokens.RefreshToken)
+	c.JSON(http.StatusOK, response)
+}
+
+// redirectToClient redirects to the client call back of the tenant with the query
+func (h *OAuthController) redirectToClient(c *gin.Context, values url.Values) {
+	tenant, err := h.service.Tenant().Current(c)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	redirectURL, err := url.Parse(tenant.GoogleClientCallBackURI)
 	if err != nil {
 		errs.SetBothErrors(c, models.HttpInternalServerError, err)
 		return
 	}
 	query := redirectURL.Query()
-	query.Add("state", state)
-	query.Add("token", accessToken)
+	for key := range values {
+		query.Set(key, values.Get(key))
+	}
 	redirectURL.RawQuery = query.Encode()
 
-	log.Info(fmt.Sprintf("redirected | %s", query.Encode()))
 	c.Redirect(http.StatusTemporaryRedirect, redirectURL.String())
 }
+
+// setStateCookie binds the issued state to the browser, the call back is accepted only
+// from the browser which started the flow
+func setStateCookie(c *gin.Context, config *models.Config, provider models.OAuthProvider, state string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(keyOAuthState+string(provider),
+		state,
+		int(consts.OAuthAwaitTime.Seconds()),
+		"/",
+		"",
+		strings.HasPrefix(config.ServerPublicURL, "https://"),
+		true)
+}
+
+// checkStateCookie compares the state of the call back with the cookie and clears it
+func checkStateCookie(c *gin.Context, config *models.Config, provider models.OAuthProvider, state string) error {
+	bound, err := c.Cookie(keyOAuthState + string(provider))
+	if err != nil || subtle.ConstantTimeCompare([]byte(bound), []byte(state)) != 1 {
+		return models.ErrStateNotBound
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(keyOAuthState+string(provider),
+		"",
+		-1,
+		"/",
+		"",
+		strings.HasPrefix(config.ServerPublicURL, "https://"),
+		true)
+	return nil
+}
@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"auth-api/internal/models"
 	"auth-api/internal/pkg/tools"
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -46,3 +50,75 @@ func TestOAuthController_StateValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestOAuthRedirectReq_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		returnTo string
+		valid    bool
+	}{
+		{name: "empty", returnTo: "", valid: true},
+		{name: "root", returnTo: "/", valid: true},
+		{name: "path with query", returnTo: "/profile?tab=1", valid: true},
+		{name: "relative path", returnTo: "profile", valid: false},
+		{name: "scheme relative url", returnTo: "//evil.com", valid: false},
+		{name: "absolute url", returnTo: "https://evil.com", valid: false},
+		{name: "backslash", returnTo: "/\\evil.com", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := models.OAuthRedirectReq{ReturnTo: testCase.returnTo}.Validate()
+			assert.Equal(t, testCase.valid, err == nil, testCase.name)
+		})
+	}
+}
+
+func TestOAuthController_StateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := &models.Config{}
+	state := uuid.New().String()
+
+	testCases := []struct {
+		name        string
+		cookie      string
+		expectedErr error
+	}{
+		{name: "bound state", cookie: state, expectedErr: nil},
+		{name: "no cookie", cookie: "", expectedErr: models.ErrStateNotBound},
+		{name: "other state", cookie: uuid.New().String(), expectedErr: models.ErrStateNotBound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if testCase.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: keyOAuthState + string(models.GoogleOAuth), Value: testCase.cookie})
+			}
+
+			err := checkStateCookie(c, config, models.GoogleOAuth, state)
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestOAuthExchangeReq_Validate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		code  string
+		valid bool
+	}{
+		{name: "valid code", code: uuid.New().String(), valid: true},
+		{name: "empty code", code: "", valid: false},
+		{name: "invalid code", code: "access-token", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := models.OAuthExchangeReq{Code: testCase.code}.Validate()
+			assert.Equal(t, testCase.valid, err == nil, testCase.name)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"bytes"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"html/template"
+)
+
+type revokePageData struct {
+	Action  string
+	Token   string
+	Message string
+}
+
+var revokePage = template.Must(template.New("revoke").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>End session</title>
+</head>
+<body>
+{{if .Token}}
+<p>Someone signed in to your account from a new device. If it was not you, end that session.</p>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">End the session</button>
+</form>
+{{else}}
+<p>{{.Message}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+// renderRevokePage writes the page, the token in its url is not leaked by referrer and the page is not framed
+func renderRevokePage(c *gin.Context, code int, data revokePageData) {
+	var page bytes.Buffer
+	if err := revokePage.Execute(&page, data); err != nil {
+		errs.SetBothErrors(c, models.HttpInternalServerError, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Frame-Options", "DENY")
+	c.Data(code, "text/html; charset=utf-8", page.Bytes())
+}
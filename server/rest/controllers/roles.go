@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/tools"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetRoles returns roles of the tenant with their permissions
+func (ctl *AdminController) GetRoles(c *gin.Context) {
+	ctl.metrics.AdminRolesRequests.Inc()
+
+	roles, err := ctl.service.Role().Roles(c)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RolesRes{Items: roles})
+}
+
+// SaveRole creates role or replaces its description and permissions
+func (ctl *AdminController) SaveRole(c *gin.Context) {
+	ctl.metrics.AdminSaveRoleRequests.Inc()
+
+	var request models.SaveRoleReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+	request.Name = c.Param("role")
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	role, err := ctl.service.Role().SaveRole(c, &request)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes role and unassigns it from all users
+func (ctl *AdminController) DeleteRole(c *gin.Context) {
+	ctl.metrics.AdminDeleteRoleRequests.Inc()
+
+	role := c.Param("role")
+	if !tools.IsValidRoleName(role) {
+		errs.SetGinError(c, models.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctl.service.Role().DeleteRole(c, role); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPermissions returns all known permissions
+func (ctl *AdminController) GetPermissions(c *gin.Context) {
+	ctl.metrics.AdminPermissionsRequests.Inc()
+
+	permissions, err := ctl.service.Role().Permissions(c)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PermissionsRes{Items: permissions})
+}
+
+// SavePermission registers permission checked by downstream services
+func (ctl *AdminController) SavePermission(c *gin.Context) {
+	ctl.metrics.AdminSavePermissionRequests.Inc()
+
+	var request models.SavePermissionReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+	request.Name = c.Param("permission")
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	permission := request.ToPermission()
+	if err := ctl.service.Role().SavePermission(c, permission); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, permission)
+}
+
+// GetUserRoles returns roles and permissions of the user
+func (ctl *AdminController) GetUserRoles(c *gin.Context) {
+	ctl.metrics.AdminUserRolesRequests.Inc()
+
+	userIDCode, ok := userIDCodeParam(c)
+	if !ok {
+		return
+	}
+
+	response, err := ctl.service.Role().UserRoles(c, userIDCode)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AssignRole grants role to the user
+func (ctl *AdminController) AssignRole(c *gin.Context) {
+	ctl.metrics.AdminAssignRoleRequests.Inc()
+
+	userIDCode, role, ok := userRoleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := ctl.service.Role().AssignRole(c, userIDCode, role); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnassignRole revokes role from the user
+func (ctl *AdminController) UnassignRole(c *gin.Context) {
+	ctl.metrics.AdminUnassignRoleRequests.Inc()
+
+	userIDCode, role, ok := userRoleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := ctl.service.Role().UnassignRole(c, userIDCode, role); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func userRoleParams(c *gin.Context) (userIDCode, role string, ok bool) {
+	userIDCode, role = c.Param("user_idcode"), c.Param("role")
+	if !tools.IsUUID(userIDCode) || !tools.IsValidRoleName(role) {
+		errs.SetGinError(c, models.ErrInvalidRequest)
+		return "", "", false
+	}
+	return userIDCode, role, true
+}
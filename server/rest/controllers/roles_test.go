@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestAdminController_SaveRoleReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.SaveRoleReq
+	}{
+		{
+			name: "valid request",
+			request: models.SaveRoleReq{
+				Name:        "support",
+				Description: "Support team",
+				Permissions: []string{"users:read", "billing:invoices:read"},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "role without permissions",
+			request:     models.SaveRoleReq{Name: "guest"},
+			expectedErr: nil,
+		},
+		{
+			name:        "invalid name",
+			request:     models.SaveRoleReq{Name: "Support Team"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "too long description",
+			request: models.SaveRoleReq{
+				Name:        "support",
+				Description: strings.Repeat("a", 1000),
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid permission",
+			request: models.SaveRoleReq{
+				Name:        "support",
+				Permissions: []string{"users"},
+			},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestAdminController_SavePermissionReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.SavePermissionReq
+	}{
+		{
+			name:        "valid request",
+			request:     models.SavePermissionReq{Name: "orders:refund"},
+			expectedErr: nil,
+		},
+		{
+			name:        "without action",
+			request:     models.SavePermissionReq{Name: "orders"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "upper case",
+			request:     models.SavePermissionReq{Name: "Orders:Refund"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"auth-api/internal/models"
 	"auth-api/internal/pkg/metrics"
 	"auth-api/internal/pkg/tools"
+	"fmt"
+	"github.com/doxanocap/pkg/ctxholder"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -71,3 +73,260 @@ func (ctl *UserController) GetByUserIDCode(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// UpdateMe updates profile of the authorized user, email and phone wait for confirmation
+func (ctl *UserController) UpdateMe(c *gin.Context) {
+	ctl.metrics.UpdateMeRequests.Inc()
+
+	var request models.UpdateProfileReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().UpdateProfile(c, ctxholder.GetUserID(c), &request)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmContact commits the email or phone change by its code, tokens are re-issued
+func (ctl *UserController) ConfirmContact(c *gin.Context) {
+	ctl.metrics.ConfirmContactRequests.Inc()
+
+	var request models.ConfirmContactReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().ConfirmContactChange(c, ctxholder.GetUserID(c), &request)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	setRefreshToken(c, response.Tokens.RefreshToken)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyActivity returns security activity of the authorized user
+func (ctl *UserController) GetMyActivity(c *gin.Context) {
+	ctl.metrics.UserActivityRequests.Inc()
+
+	var request models.PaginationReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.Audit().ListByUser(c, ctxholder.GetUserID(c), request.GetLimit(), request.Offset)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteMe schedules erasure of the authorized user after the grace period
+func (ctl *UserController) DeleteMe(c *gin.Context) {
+	ctl.metrics.DeleteMeRequests.Inc()
+
+	request, ok := bindUserActionReq(c)
+	if !ok {
+		return
+	}
+
+	response, err := ctl.service.User().ScheduleDeletion(c, ctxholder.GetUserID(c), request.Reason)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// CancelMyDeletion cancels scheduled erasure of the authorized user
+func (ctl *UserController) CancelMyDeletion(c *gin.Context) {
+	ctl.metrics.CancelMyDeletionRequests.Inc()
+
+	if err := ctl.service.User().CancelDeletion(c, ctxholder.GetUserID(c), ""); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RequestExport queues export of personal data of the authorized user
+func (ctl *UserController) RequestExport(c *gin.Context) {
+	ctl.metrics.RequestExportRequests.Inc()
+
+	var request models.ExportReq
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			errs.SetBothErrors(c, models.HttpBadRequest, err)
+			return
+		}
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().RequestExport(c, ctxholder.GetUserID(c), request.GetFormat())
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// GetMyExport returns status of the export of the authorized user
+func (ctl *UserController) GetMyExport(c *gin.Context) {
+	ctl.metrics.GetMyExportRequests.Inc()
+
+	exportID := c.Param("export_id")
+	if !tools.IsUUID(exportID) {
+		errs.SetGinError(c, models.HttpBadRequest)
+		return
+	}
+
+	response, err := ctl.service.User().GetExport(c, ctxholder.GetUserID(c), exportID)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadExport serves the export by the signed link from the mail
+func (ctl *UserController) DownloadExport(c *gin.Context) {
+	ctl.metrics.DownloadExportRequests.Inc()
+
+	token := c.Query("token")
+	if token == "" {
+		errs.SetGinError(c, models.HttpBadRequest)
+		return
+	}
+
+	export, err := ctl.service.User().DownloadExport(c, token)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName()))
+	c.Data(http.StatusOK, export.ContentType(), export.Content)
+}
+
+// GetMyIdentities returns providers linked to the authorized user
+func (ctl *UserController) GetMyIdentities(c *gin.Context) {
+	ctl.metrics.IdentitiesRequests.Inc()
+
+	response, err := ctl.service.User().Identities(c, ctxholder.GetUserID(c))
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// LinkIdentity re-authenticates the authorized user and returns url of the provider to link
+func (ctl *UserController) LinkIdentity(c *gin.Context) {
+	ctl.metrics.LinkIdentityRequests.Inc()
+
+	provider := models.OAuthProvider(c.Param("provider"))
+	if !provider.IsValid() {
+		errs.SetGinError(c, models.HttpBadRequest)
+		return
+	}
+
+	var request models.ReauthReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().StartLink(c, ctxholder.GetUserID(c), provider, &request)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	setStateCookie(c, ctl.config, provider, response.State)
+	c.JSON(http.StatusOK, response)
+}
+
+// UnlinkIdentity removes the provider from the authorized user
+func (ctl *UserController) UnlinkIdentity(c *gin.Context) {
+	ctl.metrics.UnlinkIdentityRequests.Inc()
+
+	provider := models.OAuthProvider(c.Param("provider"))
+	if !provider.IsValid() {
+		errs.SetGinError(c, models.HttpBadRequest)
+		return
+	}
+
+	if err := ctl.service.User().UnlinkIdentity(c, ctxholder.GetUserID(c), provider); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetPassword sets or changes password of the authorized user and re-issues tokens,
+// sessions on other devices are ended
+func (ctl *UserController) SetPassword(c *gin.Context) {
+	ctl.metrics.SetPasswordRequests.Inc()
+
+	var request models.SetPasswordReq
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().SetPassword(c, ctxholder.GetUserID(c), &request)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	setRefreshToken(c, response.Tokens.RefreshToken)
+	c.JSON(http.StatusOK, response)
+}
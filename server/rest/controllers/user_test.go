@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestUserController_ExportReq(t *testing.T) {
+	testCases := []struct {
+		name           string
+		expectedErr    error
+		expectedFormat models.ExportFormat
+		request        models.ExportReq
+	}{
+		{
+			name:           "empty request",
+			request:        models.ExportReq{},
+			expectedFormat: models.ExportFormatJSON,
+			expectedErr:    nil,
+		},
+		{
+			name:           "zip",
+			request:        models.ExportReq{Format: models.ExportFormatZIP},
+			expectedFormat: models.ExportFormatZIP,
+			expectedErr:    nil,
+		},
+		{
+			name:           "invalid format",
+			request:        models.ExportReq{Format: "csv"},
+			expectedFormat: "csv",
+			expectedErr:    models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+			assert.Equal(t, testCase.expectedFormat, testCase.request.GetFormat(), testCase.name)
+		})
+	}
+}
+
+func TestUserController_UpdateProfileReq(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.UpdateProfileReq
+	}{
+		{
+			name:        "empty request",
+			request:     models.UpdateProfileReq{},
+			expectedErr: nil,
+		},
+		{
+			name: "valid request",
+			request: models.UpdateProfileReq{
+				FirstName:   str("Айгерим"),
+				LastName:    str(""),
+				Locale:      str("kk-kz"),
+				Email:       str("new@example.com"),
+				PhoneNumber: str("+77011234567"),
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "reset locale",
+			request:     models.UpdateProfileReq{Locale: str("")},
+			expectedErr: nil,
+		},
+		{
+			name:        "too long name",
+			request:     models.UpdateProfileReq{FirstName: str(strings.Repeat("a", 101))},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "invalid locale",
+			request:     models.UpdateProfileReq{Locale: str("English")},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "empty email",
+			request:     models.UpdateProfileReq{Email: str("")},
+			expectedErr: models.ErrInvalidEmail,
+		},
+		{
+			name:        "invalid phone",
+			request:     models.UpdateProfileReq{PhoneNumber: str("phone")},
+			expectedErr: models.ErrInvalidPhoneNumber,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestUserController_ConfirmContactReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.ConfirmContactReq
+	}{
+		{
+			name:        "valid email",
+			request:     models.ConfirmContactReq{Kind: models.ContactEmail, Code: "123456"},
+			expectedErr: nil,
+		},
+		{
+			name:        "valid phone",
+			request:     models.ConfirmContactReq{Kind: models.ContactPhone, Code: "000001"},
+			expectedErr: nil,
+		},
+		{
+			name:        "unknown kind",
+			request:     models.ConfirmContactReq{Kind: "address", Code: "123456"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "empty code",
+			request:     models.ConfirmContactReq{Kind: models.ContactEmail},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "not a number",
+			request:     models.ConfirmContactReq{Kind: models.ContactEmail, Code: "12a456"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
+
+func TestUserController_SetPasswordReq(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedErr error
+		request     models.SetPasswordReq
+	}{
+		{
+			name:        "current password",
+			request:     models.SetPasswordReq{Password: "NewPassword1", CurrentPassword: "OldPassword1"},
+			expectedErr: nil,
+		},
+		{
+			name:        "verification code",
+			request:     models.SetPasswordReq{Password: "NewPassword1", Code: "123456"},
+			expectedErr: nil,
+		},
+		{
+			name:        "no re-authentication",
+			request:     models.SetPasswordReq{Password: "NewPassword1"},
+			expectedErr: models.ErrReauthRequired,
+		},
+		{
+			name:        "invalid code",
+			request:     models.SetPasswordReq{Password: "NewPassword1", Code: "code"},
+			expectedErr: models.ErrInvalidRequest,
+		},
+		{
+			name:        "invalid password",
+			request:     models.SetPasswordReq{Password: "", Code: "123456"},
+			expectedErr: models.ErrInvalidPassword,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.expectedErr, err, testCase.name)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"auth-api/internal/models"
+	"auth-api/internal/pkg/tools"
+	"context"
+	"errors"
+	"github.com/doxanocap/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// GetUsers searches users of the tenant
+func (ctl *AdminController) GetUsers(c *gin.Context) {
+	ctl.metrics.AdminUsersRequests.Inc()
+
+	var request models.UsersReq
+	if err := c.ShouldBindQuery(&request); err != nil {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	response, err := ctl.service.User().Search(c, request.ToFilter())
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUser returns user with the roles and sessions
+func (ctl *AdminController) GetUser(c *gin.Context) {
+	ctl.metrics.AdminUserRequests.Inc()
+
+	userIDCode, ok := userIDCodeParam(c)
+	if !ok {
+		return
+	}
+
+	response, err := ctl.service.User().GetDetail(c, userIDCode)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableUser disables the user and ends the sessions of the user
+func (ctl *AdminController) DisableUser(c *gin.Context) {
+	ctl.metrics.AdminDisableUserRequests.Inc()
+	ctl.userAction(c, ctl.service.User().Deactivate)
+}
+
+// EnableUser lifts disabling of the user
+func (ctl *AdminController) EnableUser(c *gin.Context) {
+	ctl.metrics.AdminEnableUserRequests.Inc()
+	ctl.userAction(c, ctl.service.User().Enable)
+}
+
+// LogoutUser ends all sessions of the user
+func (ctl *AdminController) LogoutUser(c *gin.Context) {
+	ctl.metrics.AdminLogoutUserRequests.Inc()
+	ctl.userAction(c, ctl.service.User().ForceLogout)
+}
+
+// DeleteUser schedules erasure of the user after the grace period
+func (ctl *AdminController) DeleteUser(c *gin.Context) {
+	ctl.metrics.AdminDeleteUserRequests.Inc()
+
+	userIDCode, ok := userIDCodeParam(c)
+	if !ok {
+		return
+	}
+
+	request, ok := bindUserActionReq(c)
+	if !ok {
+		return
+	}
+
+	response, err := ctl.service.User().ScheduleDeletion(c, userIDCode, request.Reason)
+	if err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// CancelUserDeletion cancels scheduled erasure of the user
+func (ctl *AdminController) CancelUserDeletion(c *gin.Context) {
+	ctl.metrics.AdminCancelDeletionRequests.Inc()
+	ctl.userAction(c, ctl.service.User().CancelDeletion)
+}
+
+// ResendVerification sends new verification code to the unverified user
+func (ctl *AdminController) ResendVerification(c *gin.Context) {
+	ctl.metrics.AdminResendVerificationRequests.Inc()
+
+	userIDCode, ok := userIDCodeParam(c)
+	if !ok {
+		return
+	}
+
+	if err := ctl.service.User().ResendVerification(c, userIDCode); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// userAction runs action over the user from the path, reason in the body is optional
+func (ctl *AdminController) userAction(c *gin.Context, action func(ctx context.Context, userIDCode, reason string) error) {
+	userIDCode, ok := userIDCodeParam(c)
+	if !ok {
+		return
+	}
+
+	request, ok := bindUserActionReq(c)
+	if !ok {
+		return
+	}
+
+	if err := action(c, userIDCode, request.Reason); err != nil {
+		errs.SetGinError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// bindUserActionReq binds optional body with the reason of the action
+func bindUserActionReq(c *gin.Context) (*models.UserActionReq, bool) {
+	var request models.UserActionReq
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		errs.SetBothErrors(c, models.HttpBadRequest, err)
+		return nil, false
+	}
+
+	if err := request.Validate(); err != nil {
+		errs.SetGinError(c, err)
+		return nil, false
+	}
+	return &request, true
+}
+
+func userIDCodeParam(c *gin.Context) (string, bool) {
+	userIDCode := c.Param("user_idcode")
+	if !tools.IsUUID(userIDCode) {
+		errs.SetGinError(c, models.ErrInvalidRequest)
+		return "", false
+	}
+	return userIDCode, true
+}
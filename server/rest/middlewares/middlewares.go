@@ -3,7 +3,10 @@ package middlewares
 import (
 	"auth-api/internal/interfaces"
 	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
 	"auth-api/internal/pkg/metrics"
+	"auth-api/internal/pkg/tools"
+	"crypto/subtle"
 	"github.com/doxanocap/pkg/ctxholder"
 	"github.com/doxanocap/pkg/errs"
 	"github.com/gin-gonic/gin"
@@ -11,17 +14,24 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Middlewares struct {
+	config  *models.Config
 	service interfaces.IService
 	metrics *metrics.APIMetrics
 	log     *zap.Logger
 }
 
-func InitMiddlewares(service interfaces.IService, metrics *metrics.APIMetrics, log *zap.Logger) *Middlewares {
+func InitMiddlewares(
+	config *models.Config,
+	service interfaces.IService,
+	metrics *metrics.APIMetrics,
+	log *zap.Logger) *Middlewares {
 	return &Middlewares{
+		config:  config,
 		service: service,
 		log:     log,
 		metrics: metrics,
@@ -29,11 +39,72 @@ func InitMiddlewares(service interfaces.IService, metrics *metrics.APIMetrics, l
 }
 
 func (m *Middlewares) VerifySession(c *gin.Context) {
+	if _, ok := m.verifySession(c); !ok {
+		return
+	}
+	c.Next()
+}
+
+// RequirePermission allows request only with the access token granting the permission
+func (m *Middlewares) RequirePermission(permission string) gin.HandlerFunc {
+	return m.authorize(func(uSession *models.UserSession) bool {
+		return uSession.HasPermission(permission)
+	})
+}
+
+// RequirePermissionOrAdminToken also allows request with valid admin api token, it guards only
+// the role assignment, so the first admins of the tenant could be assigned
+func (m *Middlewares) RequirePermissionOrAdminToken(permission string) gin.HandlerFunc {
+	requirePermission := m.RequirePermission(permission)
+	return func(c *gin.Context) {
+		if m.isAdminToken(c) {
+			c.Next()
+			return
+		}
+		requirePermission(c)
+	}
+}
+
+// RequireAdminToken allows request only with valid admin api token, it guards
+// resources shared by all tenants that tenant admins must not change
+func (m *Middlewares) RequireAdminToken(c *gin.Context) {
+	if !m.isAdminToken(c) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.HttpUnauthorized)
+		return
+	}
+	c.Next()
+}
+
+// RequireRole allows request only with the access token of the user with the role
+func (m *Middlewares) RequireRole(role string) gin.HandlerFunc {
+	return m.authorize(func(uSession *models.UserSession) bool {
+		return uSession.HasRole(role)
+	})
+}
+
+// authorize checks claims of the verified session
+func (m *Middlewares) authorize(allowed func(uSession *models.UserSession) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uSession, ok := m.verifySession(c)
+		if !ok {
+			return
+		}
+		if !allowed(uSession) {
+			m.metrics.PermissionDenied.Inc()
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrAccessDenied)
+			return
+		}
+		c.Next()
+	}
+}
+
+// verifySession validates access token of the request and aborts it on failure
+func (m *Middlewares) verifySession(c *gin.Context) (*models.UserSession, bool) {
 	log := m.log.Named("[SESSION]")
 	token := m.getAuthToken(c)
 	if token == "" {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, models.HttpUnauthorized)
-		return
+		return nil, false
 	}
 
 	uSession, err := m.service.Auth().ValidateAccessToken(c, token)
@@ -42,18 +113,68 @@ func (m *Middlewares) VerifySession(c *gin.Context) {
 		log.Error(err.Error())
 
 		if httpError.StatusCode == 0 {
-			c.AbortWithStatus(httpError.StatusCode)
-			return
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return nil, false
 		}
 
 		m.metrics.ErrorSessionVerification.Inc()
 		c.AbortWithStatusJSON(httpError.StatusCode, err)
-		return
+		return nil, false
 	}
 
 	m.metrics.SuccessSessionVerification.Inc()
 	ctxholder.SetUserID(c, uSession.UserIDCode)
-	c.Next()
+	return uSession, true
+}
+
+func (m *Middlewares) isAdminToken(c *gin.Context) bool {
+	token := c.GetHeader(consts.HeaderAdminToken)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(m.config.AdminToken)) == 1
+}
+
+// ClientInfo initializes context holder and stores client ip, user agent and
+// optional device name, so they could be attached to the user session
+func (m *Middlewares) ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(ctxholder.ContextHolderKey); !ok {
+			c.Set(ctxholder.ContextHolderKey, &sync.Map{})
+		}
+
+		userAgent := tools.Truncate(c.Request.UserAgent(), consts.MaxUserAgentLength)
+		deviceName := strings.TrimSpace(c.GetHeader(consts.HeaderDeviceName))
+
+		ctxholder.SetKV(c, consts.CtxKeyClientIP, c.ClientIP())
+		ctxholder.SetKV(c, consts.CtxKeyUserAgent, userAgent)
+		ctxholder.SetKV(c, consts.CtxKeyDeviceName, tools.Truncate(deviceName, consts.MaxDeviceNameLen))
+		ctxholder.SetKV(c, consts.CtxKeyLocale, tools.ParseLocale(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// Tenant resolves tenant of the request from the X-Tenant-ID header, "tenant" query
+// param used in oauth call back uris or host, and stores it in the context holder
+func (m *Middlewares) Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := strings.TrimSpace(c.GetHeader(consts.HeaderTenant))
+		if code == "" {
+			code = c.Query(consts.QueryTenant)
+		}
+
+		tenant, err := m.service.Tenant().Resolve(c, code, c.Request.Host)
+		if err != nil {
+			httpError := errs.UnmarshalError(err)
+			if httpError.StatusCode == 0 {
+				errs.SetBothErrors(c, models.HttpInternalServerError, err)
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(httpError.StatusCode, httpError)
+			return
+		}
+
+		ctxholder.SetKV(c, consts.CtxKeyTenantID, tenant.ID)
+		c.Next()
+	}
 }
 
 func (m *Middlewares) GinMetricsHandler() gin.HandlerFunc {
@@ -104,5 +225,5 @@ func (m *Middlewares) getAuthToken(c *gin.Context) string {
 	if len(split) != 2 {
 		return ""
 	}
-	return split[2]
+	return split[1]
 }
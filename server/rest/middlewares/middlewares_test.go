@@ -0,0 +1,189 @@
+package middlewares_test
+
+import (
+	"auth-api/internal/manager/managertest"
+	"auth-api/internal/models"
+	"auth-api/internal/models/consts"
+	"auth-api/internal/pkg/metrics"
+	"auth-api/server/rest/middlewares"
+	"fmt"
+	"github.com/doxanocap/pkg/ctxholder"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewares_Tenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, _ := managertest.New(t)
+	shop := &models.Tenant{Code: "shop", Name: "Shop", Hosts: []string{"auth.shop.example.com"}}
+	blog := &models.Tenant{Code: "blog", Name: "Blog", Hosts: []string{"auth.blog.example.com"}}
+	managertest.AddTenant(t, m, shop)
+	managertest.AddTenant(t, m, blog)
+	defaultTenant, err := m.Service().Tenant().GetByID(managertest.RequestContext("", ""), 0)
+	require.NoError(t, err)
+
+	mw := middlewares.InitMiddlewares(&models.Config{}, m.Service(), nil, zap.NewNop())
+	router := gin.New()
+	router.Use(mw.ClientInfo(), mw.Tenant())
+	router.GET("/tenant", func(c *gin.Context) {
+		c.String(http.StatusOK, fmt.Sprint(ctxholder.GetInt64ByKey(c, consts.CtxKeyTenantID)))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		host   string
+		status int
+		tenant int64
+	}{
+		{name: "header wins", header: "shop", query: "blog", host: "auth.blog.example.com", status: http.StatusOK, tenant: shop.ID},
+		{name: "query wins over host", query: "shop", host: "auth.blog.example.com", status: http.StatusOK, tenant: shop.ID},
+		{name: "host", host: "auth.blog.example.com", status: http.StatusOK, tenant: blog.ID},
+		{name: "default", host: "example.com", status: http.StatusOK, tenant: defaultTenant.ID},
+		{name: "unknown code", header: "unknown", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		url := "/tenant"
+		if test.query != "" {
+			url += "?" + consts.QueryTenant + "=" + test.query
+		}
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Host = test.host
+		if test.header != "" {
+			req.Header.Set(consts.HeaderTenant, test.header)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		require.Equal(t, test.status, res.Code, test.name)
+		if test.status == http.StatusOK {
+			require.Equal(t, fmt.Sprint(test.tenant), res.Body.String(), test.name)
+		}
+	}
+}
+
+func TestMiddlewares_ClientInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mw := middlewares.InitMiddlewares(&models.Config{}, nil, nil, zap.NewNop())
+	router := gin.New()
+	router.Use(mw.ClientInfo())
+	router.GET("/client", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Join([]string{
+			ctxholder.GetStringByKey(c, consts.CtxKeyClientIP),
+			ctxholder.GetStringByKey(c, consts.CtxKeyUserAgent),
+			ctxholder.GetStringByKey(c, consts.CtxKeyDeviceName),
+			ctxholder.GetStringByKey(c, consts.CtxKeyLocale),
+		}, "|"))
+	})
+
+	tests := []struct {
+		name           string
+		userAgent      string
+		deviceName     string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			name:           "captured",
+			userAgent:      "Mozilla/5.0",
+			deviceName:     "  Work laptop ",
+			acceptLanguage: "en;q=0.5, ru-RU",
+			expected:       "10.0.0.1|Mozilla/5.0|Work laptop|ru-ru",
+		},
+		{
+			name:       "truncated",
+			userAgent:  strings.Repeat("a", consts.MaxUserAgentLength+1),
+			deviceName: strings.Repeat("ж", consts.MaxDeviceNameLen+1),
+			expected: "10.0.0.1|" + strings.Repeat("a", consts.MaxUserAgentLength) + "|" +
+				strings.Repeat("ж", consts.MaxDeviceNameLen) + "|",
+		},
+		{name: "empty", expected: "10.0.0.1|||"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/client", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req.Header.Set("User-Agent", test.userAgent)
+		req.Header.Set(consts.HeaderDeviceName, test.deviceName)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code, test.name)
+		require.Equal(t, test.expected, res.Body.String(), test.name)
+	}
+}
+
+func TestMiddlewares_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, _ := managertest.New(t)
+	ctx := managertest.RequestContext("10.0.0.1", "laptop")
+	support := managertest.SignUp(t, m, ctx, "support@example.com")
+	plain := managertest.SignUp(t, m, ctx, "plain@example.com")
+
+	tenant, err := m.Service().Tenant().GetByID(ctx, 0)
+	require.NoError(t, err)
+	tenantCtx := managertest.TenantContext(tenant.ID, "10.0.0.1", "laptop")
+	_, err = m.Service().Role().SaveRole(tenantCtx, &models.SaveRoleReq{
+		Name:        "support",
+		Permissions: []string{consts.PermissionUsersRead},
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Service().Role().AssignRole(tenantCtx, support.IDCode, "support"))
+
+	// roles are claims of the access token, so the user signs in again to get them
+	signIn, err := m.Service().User().Authenticate(ctx,
+		&models.UserDTO{Email: "support@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	mw := middlewares.InitMiddlewares(&models.Config{AdminToken: "admin-token"}, m.Service(), metrics.NewAPIMetrics(), zap.NewNop())
+	router := gin.New()
+	router.Use(mw.ClientInfo(), mw.Tenant())
+	router.GET("/users", mw.RequirePermission(consts.PermissionUsersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.PUT("/permissions", mw.RequireAdminToken, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.PUT("/roles", mw.RequirePermissionOrAdminToken(consts.PermissionRolesManage), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		accessToken string
+		adminToken  string
+		status      int
+	}{
+		{name: "granted permission", method: http.MethodGet, url: "/users", accessToken: signIn.Tokens.AccessToken, status: http.StatusOK},
+		{name: "missing permission", method: http.MethodGet, url: "/users", accessToken: plain.Tokens.AccessToken, status: http.StatusForbidden},
+		{name: "no token", method: http.MethodGet, url: "/users", status: http.StatusUnauthorized},
+		{name: "admin token", method: http.MethodGet, url: "/users", adminToken: "admin-token", status: http.StatusUnauthorized},
+		{name: "wrong admin token", method: http.MethodGet, url: "/users", adminToken: "wrong", status: http.StatusUnauthorized},
+		{name: "shared resource with user token", method: http.MethodPut, url: "/permissions", accessToken: signIn.Tokens.AccessToken, status: http.StatusUnauthorized},
+		{name: "shared resource with admin token", method: http.MethodPut, url: "/permissions", adminToken: "admin-token", status: http.StatusOK},
+		{name: "role assignment with admin token", method: http.MethodPut, url: "/roles", adminToken: "admin-token", status: http.StatusOK},
+		{name: "role assignment without permission", method: http.MethodPut, url: "/roles", accessToken: signIn.Tokens.AccessToken, status: http.StatusForbidden},
+		{name: "role assignment with wrong admin token", method: http.MethodPut, url: "/roles", adminToken: "wrong", status: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		if test.accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+test.accessToken)
+		}
+		if test.adminToken != "" {
+			req.Header.Set(consts.HeaderAdminToken, test.adminToken)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		require.Equal(t, test.status, res.Code, test.name)
+	}
+}
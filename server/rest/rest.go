@@ -13,9 +13,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 )
 
@@ -29,22 +26,29 @@ type REST struct {
 	user        *controllers.UserController
 	auth        *controllers.AuthController
 	oAuth       *controllers.OAuthController
+	admin       *controllers.AdminController
 	middlewares *middlewares.Middlewares
 }
 
 func InitREST(config *models.Config, service interfaces.IService, log *zap.Logger) *REST {
 	m := metrics.NewAPIMetrics()
+	engine := router.InitGinRouter(config.ENV)
+	if err := engine.SetTrustedProxies(config.TrustedProxiesList()); err != nil {
+		log.Fatal(fmt.Sprintf("set trusted proxies: %s", err))
+	}
+
 	return &REST{
 		log:     log,
 		config:  config,
 		service: service,
-		router:  router.InitGinRouter(config.ENV),
+		router:  engine,
 
 		user:  controllers.InitUserController(config, service, m, log.Named("[USER]")),
 		auth:  controllers.InitAuthController(config, service, m, log.Named("[AUTH]")),
 		oAuth: controllers.InitOAuthController(config, service, m, log.Named("[OAUTH]")),
+		admin: controllers.InitAdminController(config, service, m, log.Named("[ADMIN]")),
 
-		middlewares: middlewares.InitMiddlewares(service, m, log.Named("[MIDDLEWARE]")),
+		middlewares: middlewares.InitMiddlewares(config, service, m, log.Named("[MIDDLEWARE]")),
 	}
 }
 
@@ -64,18 +68,16 @@ func (r *REST) Run() {
 			r.log.Error(fmt.Sprintf("r.ListenAndServer: %v", err))
 		}
 	}()
+}
 
-	go func() {
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-		<-ch
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		if err := r.server.Shutdown(ctx); err != nil {
-			r.log.Error(fmt.Sprintf("r.server.Stop: %s", err))
-		}
-		r.log.Info("REST graceful shut down...")
-	}()
+// Shutdown stops accepting requests and waits for the active ones, it is called on application stop
+func (r *REST) Shutdown(ctx context.Context) error {
+	if r.server == nil {
+		return nil
+	}
+	if err := r.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("r.server.Shutdown: %w", err)
+	}
+	r.log.Info("REST graceful shut down...")
+	return nil
 }
@@ -1,9 +1,13 @@
 package rest
 
+import "auth-api/internal/models/consts"
+
 func (r *REST) InitRoutes() {
 	router := r.router
 	router.GET("/metrics", r.middlewares.GinMetricsHandler())
 	router.Use(r.middlewares.ErrorHandler())
+	router.Use(r.middlewares.ClientInfo())
+	router.Use(r.middlewares.Tenant())
 
 	{
 		v1 := router.Group("/v1")
@@ -15,20 +19,83 @@ func (r *REST) InitRoutes() {
 			auth.PUT("/refresh", r.auth.Refresh)
 			auth.GET("/logout", r.auth.Logout)
 			auth.GET("/verify", r.auth.VerifySession)
+			auth.GET("/sessions/revoke", r.auth.RevokeSessionPage)
+			auth.POST("/sessions/revoke", r.auth.RevokeSession)
+			auth.GET("/exports/download", r.user.DownloadExport)
 
 			google := auth.Group("/google")
 			{
 				google.GET("/call-back", r.oAuth.GoogleCallBack)
-				google.GET("/:state/redirect", r.oAuth.GoogleRedirect)
+				google.GET("/redirect", r.oAuth.GoogleRedirect)
+			}
+
+			oAuth := auth.Group("/oauth")
+			{
+				oAuth.GET("/:provider/redirect", r.oAuth.Redirect)
+				oAuth.GET("/:provider/call-back", r.oAuth.CallBack)
+				oAuth.POST("/exchange", r.oAuth.Exchange)
 			}
 
 			user := auth.Group("/user")
 			{
+				user.GET("/me/activity", r.middlewares.VerifySession, r.user.GetMyActivity)
+				user.PATCH("/me", r.middlewares.VerifySession, r.user.UpdateMe)
+				user.POST("/me/confirm-contact", r.middlewares.VerifySession, r.user.ConfirmContact)
+				user.DELETE("/me", r.middlewares.VerifySession, r.user.DeleteMe)
+				user.POST("/me/cancel-deletion", r.middlewares.VerifySession, r.user.CancelMyDeletion)
+				user.POST("/me/exports", r.middlewares.VerifySession, r.user.RequestExport)
+				user.GET("/me/exports/:export_id", r.middlewares.VerifySession, r.user.GetMyExport)
+				user.GET("/me/identities", r.middlewares.VerifySession, r.user.GetMyIdentities)
+				user.POST("/me/identities/:provider", r.middlewares.VerifySession, r.user.LinkIdentity)
+				user.DELETE("/me/identities/:provider", r.middlewares.VerifySession, r.user.UnlinkIdentity)
+				user.PUT("/me/password", r.middlewares.VerifySession, r.user.SetPassword)
 				user.GET("/:user_idcode",
 					//r.middlewares.VerifySession,
 					r.user.GetByUserIDCode)
 				user.GET("/send-verify-code", r.user.SendVerifyCode)
 			}
 		}
+
+		admin := v1.Group("/admin")
+		{
+			admin.GET("/auth-events", r.middlewares.RequirePermission(consts.PermissionAuditRead), r.admin.GetAuthEvents)
+
+			// the mails dead letter queue is shared by all tenants
+			deadLetters := admin.Group("/dead-letters", r.middlewares.RequireAdminToken)
+			{
+				deadLetters.GET("/mails", r.admin.GetDeadLetterMails)
+				deadLetters.POST("/mails/requeue", r.admin.RequeueDeadLetterMails)
+			}
+
+			readUsers := r.middlewares.RequirePermission(consts.PermissionUsersRead)
+			manageUsers := r.middlewares.RequirePermission(consts.PermissionUsersManage)
+			users := admin.Group("/users")
+			{
+				users.GET("", readUsers, r.admin.GetUsers)
+				users.GET("/:user_idcode", readUsers, r.admin.GetUser)
+				users.DELETE("/:user_idcode", manageUsers, r.admin.DeleteUser)
+				users.POST("/:user_idcode/disable", manageUsers, r.admin.DisableUser)
+				users.POST("/:user_idcode/enable", manageUsers, r.admin.EnableUser)
+				users.POST("/:user_idcode/logout", manageUsers, r.admin.LogoutUser)
+				users.POST("/:user_idcode/resend-verification",
+					r.middlewares.RequirePermission(consts.PermissionMailsManage), r.admin.ResendVerification)
+				users.POST("/:user_idcode/cancel-deletion", manageUsers, r.admin.CancelUserDeletion)
+			}
+
+			manageRoles := r.middlewares.RequirePermission(consts.PermissionRolesManage)
+			{
+				admin.GET("/roles", manageRoles, r.admin.GetRoles)
+				admin.PUT("/roles/:role", manageRoles, r.admin.SaveRole)
+				admin.DELETE("/roles/:role", manageRoles, r.admin.DeleteRole)
+				admin.GET("/permissions", manageRoles, r.admin.GetPermissions)
+				// permissions are shared by all tenants
+				admin.PUT("/permissions/:permission", r.middlewares.RequireAdminToken, r.admin.SavePermission)
+				admin.GET("/users/:user_idcode/roles", manageRoles, r.admin.GetUserRoles)
+				// the admin api token assigns the first admins of the tenant
+				admin.PUT("/users/:user_idcode/roles/:role",
+					r.middlewares.RequirePermissionOrAdminToken(consts.PermissionRolesManage), r.admin.AssignRole)
+				admin.DELETE("/users/:user_idcode/roles/:role", manageRoles, r.admin.UnassignRole)
+			}
+		}
 	}
 }